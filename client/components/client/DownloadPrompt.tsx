@@ -13,32 +13,56 @@ import {
   Radio,
   RadioGroup,
   Stack,
+  TextField,
 } from "@mui/material";
 import LoadingBackdrop from "../global/LoadingBackdrop";
 import api from "@/lib/api";
 import { getImageDownloadURL, getZipDownloadURL } from "@/helpers/photos";
-import { EventModel } from "@/lib/models";
+import { DownloadPolicy, EventModel, SignedURLs } from "@/lib/models";
 
 type Props = {
   galleryID: number;
+  galleryPublicID: string;
   imageID: number | null;
+  imagePublicID: string | null;
   fileName: string;
   open: boolean;
   handleClose: () => void;
   zip?: boolean;
+  policy?: DownloadPolicy;
+  urls?: SignedURLs;
 };
 
+// Sent in headers so the PIN and email don't end up in the server logs
+const PIN_HEADER = "X-Download-Pin";
+const EMAIL_HEADER = "X-Download-Email";
+
 const DownloadPrompt = ({
   galleryID,
+  galleryPublicID,
   imageID,
+  imagePublicID,
   fileName,
   open,
   handleClose,
   zip,
+  policy,
+  urls,
 }: Props) => {
   const [loading, setLoading] = React.useState({ loading: false, info: "" });
   const [downloading, setDownloading] = React.useState(false);
   const [downloadSize, setDownloadSize] = React.useState("original");
+  const [pinRequired, setPinRequired] = React.useState(
+    policy?.pin_required ?? false
+  );
+  const [emailRequired, setEmailRequired] = React.useState(
+    policy?.email_required ?? false
+  );
+  const [pin, setPin] = React.useState("");
+  const [email, setEmail] = React.useState("");
+  const [errors, setErrors] = React.useState<{ pin?: string; email?: string }>(
+    {}
+  );
 
   const downloadFile = () => {
     setDownloading(true);
@@ -48,17 +72,48 @@ const DownloadPrompt = ({
     const filename = fileName;
 
     if (zip) {
-      file = getZipDownloadURL(galleryID, downloadSize);
+      file = getZipDownloadURL(galleryPublicID, downloadSize, urls);
     } else {
-      file = getImageDownloadURL(imageID as number, downloadSize);
+      file = getImageDownloadURL(
+        imagePublicID as string,
+        downloadSize,
+        urls
+      );
     }
 
+    const headers: Record<string, string> = {};
+    if (pin) headers[PIN_HEADER] = pin;
+    if (email) headers[EMAIL_HEADER] = email;
+
     try {
       fetch(file, {
         method: "GET",
+        headers: headers,
       })
-        .then((response) => response.blob())
+        .then(async (response) => {
+          if (response.ok) return response.blob();
+
+          // Ask for the PIN or email when the gallery requires them and
+          // try again, other failures are shown as is
+          const body = await response.json().catch(() => null);
+          const data = body?.data ?? {};
+          if (data.pin || data.email) {
+            if (data.pin) setPinRequired(true);
+            if (data.email) setEmailRequired(true);
+            setErrors({ pin: data.pin, email: data.email });
+            return null;
+          }
+
+          const message = Object.values(data)[0] ?? body?.message;
+          throw new Error(String(message ?? response.statusText));
+        })
         .then((blobby) => {
+          if (!blobby) {
+            anchor.remove();
+            setDownloading(false);
+            return;
+          }
+
           let objectUrl = window.URL.createObjectURL(blobby);
 
           anchor.href = objectUrl;
@@ -66,6 +121,7 @@ const DownloadPrompt = ({
           anchor.click();
 
           window.URL.revokeObjectURL(objectUrl);
+          anchor.remove();
 
           const bytes = blobby.size; // Update bytes to the correct number of bytes in the blobby response
 
@@ -77,12 +133,13 @@ const DownloadPrompt = ({
             size: downloadSize,
             bytes: bytes,
           } as EventModel);
+          safelyClose();
         })
         .catch((err) => {
           console.error(err);
-          alert("Unable to download gallery.");
-        })
-        .finally(safelyClose);
+          alert(`Unable to download: ${err.message}`);
+          safelyClose();
+        });
     } catch (error) {
       console.error(error);
       alert("Something went wrong downloading the gallery!");
@@ -92,6 +149,7 @@ const DownloadPrompt = ({
   const safelyClose = () => {
     setLoading({ loading: false, info: "" });
     setDownloading(false);
+    setErrors({});
     handleClose();
   };
 
@@ -132,6 +190,28 @@ const DownloadPrompt = ({
                   />
                 </RadioGroup>
               </FormControl>
+              {pinRequired && (
+                <TextField
+                  fullWidth
+                  label="Download PIN"
+                  type="password"
+                  value={pin}
+                  error={!!errors.pin}
+                  helperText={errors.pin}
+                  onChange={(e) => setPin(e.target.value)}
+                />
+              )}
+              {emailRequired && (
+                <TextField
+                  fullWidth
+                  label="Email"
+                  type="email"
+                  value={email}
+                  error={!!errors.email}
+                  helperText={errors.email}
+                  onChange={(e) => setEmail(e.target.value)}
+                />
+              )}
               <Button fullWidth onClick={downloadFile} variant="contained">
                 Download Now
               </Button>
@@ -6,26 +6,30 @@ import ResponsiveGallery from "./ResponsiveGallery";
 
 type Props = {
   galleryID: number;
+  galleryPublicID: string;
   photos: PhotoModel[];
   loading: boolean;
+  favorites?: number[];
+  onFavorite?: (photo: PhotoModel, favorite: boolean) => void;
 };
 
 function Gallery(props: Props) {
   const { setLightboxIndex, renderLightbox, setLightboxGalleryID } =
     useLightbox();
 
-  setLightboxGalleryID(props.galleryID);
+  setLightboxGalleryID(props.galleryID, props.galleryPublicID);
 
   // Lightbox slides expects slightly different format
   const photos = props.photos.map((photo) => {
     return {
-      src: getImageSrc(photo.ID),
+      src: getImageSrc(photo.public_id, photo.urls?.query),
       width: photo.width,
       height: photo.height,
       alt: `${photo.ID}`,
-      key: photo.filename,
+      key: photo.public_id,
       blurDataURL: photo.blurDataURL,
       download: photo.filename,
+      urls: photo.urls,
     };
   });
 
@@ -34,8 +38,11 @@ function Gallery(props: Props) {
       <ResponsiveGallery
         photos={props.photos}
         galleryID={props.galleryID}
+        galleryPublicID={props.galleryPublicID}
         onClick={(index: number) => setLightboxIndex(index)}
         quality={100}
+        favorites={props.favorites}
+        onFavorite={props.onFavorite}
       />
 
       {renderLightbox({ slides: photos })}
@@ -5,8 +5,11 @@ import Gallery from "./Gallery";
 
 type Props = {
   galleryID: number;
+  galleryPublicID: string;
   photos: Photo[];
   loading: boolean;
+  favorites?: number[];
+  onFavorite?: (photo: Photo, favorite: boolean) => void;
 };
 
 const GalleryHandler = (props: Props) => {
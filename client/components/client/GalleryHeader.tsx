@@ -76,11 +76,15 @@ const GalleryHeader = (props: Props) => {
     <Container maxWidth="xl">
       <DownloadPrompt
         galleryID={props.gallery.ID}
+        galleryPublicID={props.gallery.public_id}
         imageID={null}
+        imagePublicID={null}
         zip={true}
         open={open}
         fileName="gallery.zip"
         handleClose={handleClose}
+        policy={props.gallery.download_policy}
+        urls={props.gallery.urls}
       />
 
       <Grid
@@ -26,7 +26,7 @@ const HeroImage = (props: Props) => {
     >
       <Image
         alt="main image"
-        src={getImageSrc(img.ID)}
+        src={getImageSrc(img.public_id, img.urls?.query)}
         placeholder={
           (img.blurDataURL as `data:image/${string}`) ||
           `data:image/svg+xml;base64,${toBase64(
@@ -8,7 +8,7 @@ import {
   useTheme,
 } from "@mui/material";
 import React from "react";
-import { getImageSrc, shimmer, toBase64 } from "@/helpers/photos";
+import { getImageFullSrc, shimmer, toBase64 } from "@/helpers/photos";
 import HeaderImage from "../global/HeaderImage";
 import Link from "next/link";
 import SkeletonGalleries from "./SkeletonGalleries";
@@ -52,7 +52,12 @@ const PublicGalleryList = ({ galleries, isLoading, isError }: Props) => {
                 className="img-fluid"
                 id={`img-${gallery.ID}`}
                 alt={`img-${gallery.ID}`}
-                src={`${getImageSrc(gallery.featured_image.ID)}/256/75`}
+                src={getImageFullSrc(
+                  gallery.featured_image.public_id,
+                  256,
+                  75,
+                  gallery.featured_image.urls?.query
+                )}
                 fill
                 placeholder={
                   (gallery.featured_image
@@ -15,7 +15,7 @@ import {
   shimmer,
   toBase64,
 } from "@/helpers/photos";
-import { Download } from "@mui/icons-material";
+import { Download, Favorite, FavoriteBorder } from "@mui/icons-material";
 import DownloadPrompt from "./DownloadPrompt";
 import Image from "next/image";
 
@@ -23,12 +23,24 @@ type Props = {
   photos: PhotoModel[];
   onClick: (index: number) => void;
   galleryID: number;
+  galleryPublicID: string;
   quality: number;
+  // IDs of the favorited images, favorites are shown when onFavorite is set
+  favorites?: number[];
+  onFavorite?: (photo: PhotoModel, favorite: boolean) => void;
 };
 
-const ResponsiveGallery = ({ photos, galleryID, onClick, quality }: Props) => {
+const ResponsiveGallery = ({
+  photos,
+  galleryID,
+  galleryPublicID,
+  onClick,
+  quality,
+  favorites,
+  onFavorite,
+}: Props) => {
   const [open, setOpen] = React.useState(false);
-  const [src, setSrc] = React.useState(0);
+  const [src, setSrc] = React.useState<PhotoModel | null>(null);
   const [fileName, setFileName] = React.useState("");
   const handleClose = () => setOpen(false);
 
@@ -39,7 +51,7 @@ const ResponsiveGallery = ({ photos, galleryID, onClick, quality }: Props) => {
   const isLg = useMediaQuery(theme.breakpoints.only("lg"));
 
   const downloadImage = async (photo: PhotoModel) => {
-    setSrc(photo.ID);
+    setSrc(photo);
     setFileName(photo.filename);
     setOpen(true);
   };
@@ -89,7 +101,7 @@ const ResponsiveGallery = ({ photos, galleryID, onClick, quality }: Props) => {
           >
             <Image
               onClick={() => onClick(index)}
-              src={getImageSrc(photo.ID)}
+              src={getImageSrc(photo.public_id, photo.urls?.query)}
               loading="lazy"
               placeholder={
                 (photo.blurDataURL as `data:image/${string}`) ||
@@ -97,7 +109,7 @@ const ResponsiveGallery = ({ photos, galleryID, onClick, quality }: Props) => {
                   shimmer(photo.width, photo.height)
                 )}`
               }
-              alt={photo.filename}
+              alt={photo.alt_text || photo.title || photo.filename}
               height={imageSize.height}
               width={imageSize.width}
               quality={quality}
@@ -118,6 +130,19 @@ const ResponsiveGallery = ({ photos, galleryID, onClick, quality }: Props) => {
                 justifyContent="end"
                 spacing={1}
               >
+                {onFavorite && (
+                  <IconButton
+                    onClick={() =>
+                      onFavorite(photo, !favorites?.includes(photo.ID))
+                    }
+                  >
+                    {favorites?.includes(photo.ID) ? (
+                      <Favorite color="error" />
+                    ) : (
+                      <FavoriteBorder color="info" />
+                    )}
+                  </IconButton>
+                )}
                 <IconButton onClick={() => downloadImage(photo)}>
                   <Download color="info" />
                 </IconButton>
@@ -126,10 +151,13 @@ const ResponsiveGallery = ({ photos, galleryID, onClick, quality }: Props) => {
           </ImageListItem>
         );
       })}
-      {src !== 0 && open && (
+      {src && open && (
         <DownloadPrompt
           galleryID={galleryID}
-          imageID={src}
+          galleryPublicID={galleryPublicID}
+          imageID={src.ID}
+          imagePublicID={src.public_id}
+          urls={src.urls}
           open={open}
           fileName={fileName}
           handleClose={handleClose}
@@ -8,15 +8,20 @@ import type {
 import Download from "yet-another-react-lightbox/plugins/download";
 import NextLightboxImage from "./NextLightboxImage";
 import DownloadPrompt from "../DownloadPrompt";
+import { SignedURLs } from "@/lib/models";
 
 const Lightbox = dynamic(() => import("./Lightbox"));
 
+// Slides carry the signed URLs of the image to download it with
+type DownloadSlide = SlideImage & { urls?: SignedURLs };
+
 export default function useLightbox() {
   const [index, setIndex] = React.useState(-1);
   const [interactive, setInteractive] = React.useState(false);
   const [dlPrompt, setDlPrompt] = React.useState(false);
-  const [dlImage, setDlImage] = React.useState<SlideImage | null>(null);
+  const [dlImage, setDlImage] = React.useState<DownloadSlide | null>(null);
   const [galleryID, setGalleryID] = React.useState<number | null>(null);
+  const [galleryPublicID, setGalleryPublicID] = React.useState("");
 
   const setLightboxIndex = React.useCallback((i: number) => {
     setIndex(i);
@@ -24,10 +29,11 @@ export default function useLightbox() {
   }, []);
 
   const setLightboxGalleryID = React.useCallback(
-    (id: number) => {
+    (id: number, publicID: string) => {
       if (id !== galleryID) setGalleryID(id);
+      if (publicID !== galleryPublicID) setGalleryPublicID(publicID);
     },
-    [galleryID]
+    [galleryID, galleryPublicID]
   );
 
   const renderLightbox = React.useCallback(
@@ -37,7 +43,10 @@ export default function useLightbox() {
           {dlImage && galleryID && (
             <DownloadPrompt
               galleryID={galleryID}
+              galleryPublicID={galleryPublicID}
               imageID={Number(dlImage.alt)}
+              imagePublicID={dlImage.key as string}
+              urls={dlImage.urls}
               open={dlPrompt}
               fileName={dlImage.download as string}
               handleClose={() => {
@@ -56,7 +65,7 @@ export default function useLightbox() {
               download: ({ slide, saveAs }: DownloadFunctionProps) => {
                 // saveAs(slide.download as string, slide.alt);
                 // using the saveAs we can't pass auth token
-                setDlImage(slide);
+                setDlImage(slide as DownloadSlide);
                 setDlPrompt(true);
                 setIndex(-1);
               },
@@ -65,7 +74,7 @@ export default function useLightbox() {
           />
         </>
       ) : null,
-    [index, interactive, dlImage, dlPrompt, galleryID]
+    [index, interactive, dlImage, dlPrompt, galleryID, galleryPublicID]
   );
 
   return { setLightboxIndex, setLightboxGalleryID, renderLightbox };
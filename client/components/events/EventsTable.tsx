@@ -96,12 +96,14 @@ const EventsTable = (props: Props) => {
                     </TableCell>
                     <TableCell align="right">
                       <Stack justifyContent="end" direction="row">
-                        <Tooltip title={!event.image_id ? "" : "View Image"}>
+                        <Tooltip
+                          title={!event.image_public_id ? "" : "View Image"}
+                        >
                           <IconButton
-                            disabled={!event.image_id}
+                            disabled={!event.image_public_id}
                             onClick={() => {
                               window.open(
-                                getImageSrc(event.image_id as number) +
+                                getImageSrc(event.image_public_id as string) +
                                   `/${event.size}/100`,
                                 "_blank"
                               );
@@ -10,7 +10,7 @@ import {
 import React from "react";
 import {
   calcImageSize,
-  getImageSrc,
+  getImageFullSrc,
   shimmer,
   toBase64,
 } from "@/helpers/photos";
@@ -62,7 +62,12 @@ const GalleryList = ({ galleries }: Props) => {
                 className="img-fluid"
                 id={`img-${gallery.ID}`}
                 alt={`img-${gallery.ID}`}
-                src={`${getImageSrc(gallery.featured_image.ID)}/256/75`}
+                src={getImageFullSrc(
+                  gallery.featured_image.public_id,
+                  256,
+                  75,
+                  gallery.featured_image.urls?.query
+                )}
                 fill
                 placeholder={`data:image/svg+xml;base64,${toBase64(
                   shimmer(imageSize.width, imageSize.height)
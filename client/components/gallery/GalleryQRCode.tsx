@@ -4,7 +4,8 @@ import Image from "next/image";
 import React from "react";
 
 type Props = {
-  galleryID: number;
+  galleryPublicID: string;
+  signature?: string;
   open: boolean;
   handleClose: () => void;
 };
@@ -21,7 +22,7 @@ const GalleryQRCode = (props: Props) => {
         <DialogContent>
           <Image
             alt="QR Code"
-            src={getGalleryQRCodeURL(props.galleryID)}
+            src={getGalleryQRCodeURL(props.galleryPublicID, props.signature)}
             height={256}
             width={256}
           />
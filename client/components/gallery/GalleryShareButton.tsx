@@ -92,7 +92,8 @@ const GalleryShareButton = ({ gallery, setSnackBar }: Props) => {
       <GalleryQRCode
         open={showQR}
         handleClose={() => setShowQR(false)}
-        galleryID={gallery.ID}
+        galleryPublicID={gallery.public_id}
+        signature={gallery.urls?.query}
       />
     </React.Fragment>
   );
@@ -56,7 +56,7 @@ const Confirmation = ({ open, handleClose, image }: Props) => {
         <Stack direction="row" justifyContent="center">
           <Image
             alt={"image to delete"}
-            src={getImageSrc(image.ID)}
+            src={getImageSrc(image.public_id, image.urls?.query)}
             quality={Number(process.env.NEXT_PUBLIC_ADMIN_IMAGE_QUALITY || 40)}
             height={imgSize.height}
             width={imgSize.width}
@@ -16,7 +16,7 @@ type Props = {
 };
 
 const FeaturedImage = ({ photo, maxHeight, maxWidth }: Props) => {
-  const { ID, gallery_id, height, width, position, CreatedAt } = photo;
+  const { ID, public_id, urls, gallery_id, height, width, position, CreatedAt } = photo;
   const imageSize = calcImageSize(height, width, maxHeight, maxWidth);
   return (
     <Grid sx={{ m: 3 }} container justifyContent="center">
@@ -25,7 +25,7 @@ const FeaturedImage = ({ photo, maxHeight, maxWidth }: Props) => {
           className="img-fluid"
           id={`img-${ID}`}
           alt={`img-${ID}`}
-          src={getImageSrc(ID)}
+          src={getImageSrc(public_id, urls?.query)}
           quality={Number(process.env.NEXT_PUBLIC_ADMIN_IMAGE_QUALITY || 40)}
           height={imageSize.height || 200}
           width={imageSize.width || 300}
@@ -148,7 +148,15 @@ const GalleryImages = (props: Props) => {
         //   children: "Image url copied to clipboard.",
         //   severity: "success",
         // });
-        window.open(`${getImageSrc(contextImage.ID)}/original/100`, "_blank");
+        window.open(
+          getImageFullSrc(
+            contextImage.public_id,
+            "original",
+            100,
+            contextImage.urls?.query
+          ),
+          "_blank"
+        );
         handleContextMenuClose();
         break;
       default:
@@ -175,7 +183,7 @@ const GalleryImages = (props: Props) => {
               data-aos-duration={500}
             >
               <Image
-                src={getImageSrc(photo.ID)}
+                src={getImageSrc(photo.public_id, photo.urls?.query)}
                 loading="lazy"
                 placeholder={
                   (photo.blurDataURL as `data:image/${string}`) ||
@@ -19,7 +19,7 @@ type Props = {
 };
 
 const SortableImage = ({ photo, index, cols }: Props) => {
-  const { ID, filename, width, height, blurDataURL } = photo;
+  const { ID, public_id, urls, filename, width, height, blurDataURL } = photo;
   const sortable = useSortable({ id: ID });
   const { attributes, listeners, setNodeRef, transform, transition } = sortable;
 
@@ -61,7 +61,7 @@ const SortableImage = ({ photo, index, cols }: Props) => {
     >
       <Image
         className="link"
-        src={getImageSrc(ID)}
+        src={getImageSrc(public_id, urls?.query)}
         loading="lazy"
         placeholder={
           (blurDataURL as `data:image/${string}`) ||
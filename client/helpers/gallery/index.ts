@@ -46,3 +46,12 @@ export const randomFeaturedImage = (photos: Photo[]) => {
 
   return randomlyChosenPhoto;
 };
+
+// Time the signed URLs of the gallery expire, null when it has none
+export const signedURLsExpiration = (gallery: GalleryModel): Date | null => {
+  const expires = new URLSearchParams(gallery.urls?.query ?? "").get(
+    "expires"
+  );
+
+  return expires ? new Date(Number(expires) * 1000) : null;
+};
@@ -1,3 +1,5 @@
+import { SignedURLs } from "@/lib/models";
+
 // Helper method to get the height and width of images for next/image
 export function calcImageSize(
   height: number,
@@ -66,27 +68,61 @@ export const shimmer = (w: number, h: number) => `
   <animate xlink:href="#r" attributeName="x" from="-${w}" to="${w}" dur="1s" repeatCount="indefinite"  />
 </svg>`;
 
-export const getImageDownloadURL = (imageId: number, size: string) =>
-  `${process.env.NEXT_PUBLIC_API_URL}/api/v1/download/${size}/image/${imageId}`;
+// Galleries with signed URLs come with the download URLs already signed,
+// which have to be used or the download is refused
+const getSignedDownloadURL = (size: string, urls?: SignedURLs) => {
+  const url = size === "web" ? urls?.download_web : urls?.download_original;
+  return url ? `${process.env.NEXT_PUBLIC_API_URL}${url}` : null;
+};
+
+export const getImageDownloadURL = (
+  imagePublicId: string,
+  size: string,
+  urls?: SignedURLs
+) =>
+  getSignedDownloadURL(size, urls) ??
+  `${process.env.NEXT_PUBLIC_API_URL}/api/v1/download/${size}/image/${imagePublicId}`;
 
-export const getZipDownloadURL = (galleryId: number, size: string) =>
-  `${process.env.NEXT_PUBLIC_API_URL}/api/v1/download/${size}/gallery/${galleryId}`;
+export const getZipDownloadURL = (
+  galleryPublicId: string,
+  size: string,
+  urls?: SignedURLs
+) =>
+  getSignedDownloadURL(size, urls) ??
+  `${process.env.NEXT_PUBLIC_API_URL}/api/v1/download/${size}/gallery/${galleryPublicId}`;
 
-export const getImageSrc = (ID: number) =>
-  `${process.env.NEXT_PUBLIC_API_URL}/api/v1/images/${ID}`;
+export const getImageSrc = (publicId: string, signature?: string) =>
+  `${process.env.NEXT_PUBLIC_API_URL}/api/v1/images/${publicId}${
+    signature ? `?${signature}` : ""
+  }`;
 
 export const getImageFullSrc = (
-  ID: number,
+  publicId: string,
   width: number | string,
-  quality: number
+  quality: number,
+  signature?: string
 ) =>
-  `${process.env.NEXT_PUBLIC_API_URL}/api/v1/images/${ID}/${width}/${quality}`;
+  `${process.env.NEXT_PUBLIC_API_URL}/api/v1/images/${publicId}/${width}/${quality}${
+    signature ? `?${signature}` : ""
+  }`;
 
-export const getImageBlurURL = (ID: number, width: number, quality: number) =>
-  `${process.env.NEXT_PUBLIC_API_URL}/api/v1/images/${ID}/${width}/${quality}`;
+export const getImageBlurURL = (
+  publicId: string,
+  width: number,
+  quality: number,
+  signature?: string
+) =>
+  `${process.env.NEXT_PUBLIC_API_URL}/api/v1/images/${publicId}/${width}/${quality}${
+    signature ? `?${signature}` : ""
+  }`;
 
 export const normalize = (value: number, MIN: number, MAX: number) =>
   ((value - MIN) * 100) / (MAX - MIN);
 
-export const getGalleryQRCodeURL = (galleryId: number) =>
-  `${process.env.NEXT_PUBLIC_API_URL}/api/v1/galleries/id/${galleryId}/qr-code`;
+export const getGalleryQRCodeURL = (
+  galleryPublicId: string,
+  signature?: string
+) =>
+  `${process.env.NEXT_PUBLIC_API_URL}/api/v1/galleries/public-id/${galleryPublicId}/qr-code${
+    signature ? `?${signature}` : ""
+  }`;
@@ -8,11 +8,15 @@ import {
   CompanyResponse,
   EventModel,
   GalleriesResponse,
+  GalleryCloneModel,
+  GalleryGrantResponse,
+  GalleryPreviewResponse,
   GalleryResponse,
   GalleryUpdateModel,
   ImageDeleteResponse,
   LoginResponse,
   NewGalleryModel,
+  PreviewLinkResponse,
   SettingsResponse,
   UserUpdateModel,
 } from "@/lib/models";
@@ -105,18 +109,20 @@ const updateAuthAdmin = async (email: string) =>
       .catch((err) => reject(err.response?.data || err))
   );
 
-const getGallery = async (path: string) =>
+const getGallery = async (path: string, grant?: string) =>
   new Promise<GalleryResponse>((resolve, reject) =>
     axiosApi(<ApiObject>{
       method: "get",
-      url: `v1/galleries/path/${path}`,
+      url: `v1/galleries/path/${path}${
+        grant ? `?grant=${encodeURIComponent(grant)}` : ""
+      }`,
     })
       .then((res) => resolve(res.data))
       .catch((err) => reject(err.response?.data || err))
   );
 
 const unlockGallery = async (path: string, password: string) =>
-  new Promise<GalleryResponse>((resolve, reject) =>
+  new Promise<GalleryGrantResponse>((resolve, reject) =>
     axiosApi(<ApiObject>{
       method: "post",
       url: `v1/galleries/path/${path}`,
@@ -126,6 +132,37 @@ const unlockGallery = async (path: string, password: string) =>
       .catch((err) => reject(err.response?.data || err))
   );
 
+// The preview link token is sent in a header so it stays out of URLs
+const getGalleryPreview = async (token: string) =>
+  new Promise<GalleryPreviewResponse>((resolve, reject) =>
+    axiosApi(<ApiObject>{
+      method: "get",
+      url: `v1/galleries/preview`,
+      headers: {
+        "X-Preview-Token": token,
+      },
+    })
+      .then((res) => resolve(res.data))
+      .catch((err) => reject(err.response?.data || err))
+  );
+
+const updatePreviewFavorite = async (
+  token: string,
+  imagePublicID: string,
+  favorite: boolean
+) =>
+  new Promise<PreviewLinkResponse>((resolve, reject) =>
+    axiosApi(<ApiObject>{
+      method: favorite ? "post" : "delete",
+      url: `v1/galleries/preview/favorites/${imagePublicID}`,
+      headers: {
+        "X-Preview-Token": token,
+      },
+    })
+      .then((res) => resolve(res.data))
+      .catch((err) => reject(err.response?.data || err))
+  );
+
 const getPublicGalleries = async () =>
   new Promise<GalleriesResponse>((resolve, reject) =>
     axiosApi(<ApiObject>{
@@ -144,7 +181,12 @@ const getPublicGalleries = async () =>
         for (const gallery of galleries.data) {
           try {
             const response = await axios.get(
-              getImageBlurURL(gallery.featured_image.ID, 64, 30),
+              getImageBlurURL(
+                gallery.featured_image.public_id,
+                64,
+                30,
+                gallery.featured_image.urls?.query
+              ),
               {
                 responseType: "arraybuffer", // Ensure response is treated as binary data
               }
@@ -269,6 +311,21 @@ const deleteGallery = async (id: number) =>
       .catch((err) => reject(err.response?.data || err))
   );
 
+const cloneGallery = async (data: GalleryCloneModel, id: number) =>
+  new Promise<GalleryResponse>((resolve, reject) =>
+    axiosApi(<ApiObject>{
+      method: "post",
+      url: `v1/galleries/id/${id}/clone`,
+      data,
+      headers: {
+        Authorization: "Bearer " + getCookie("admin-token"),
+        "Content-Type": "application/json",
+      },
+    })
+      .then((res) => resolve(res.data))
+      .catch((err) => reject(err.response?.data || err))
+  );
+
 const uploadGalleryImage = async (uploadData: any, id: number | string) =>
   new Promise<any>((resolve, reject) =>
     axiosApi(<ApiObject>{
@@ -336,31 +393,31 @@ const deleteImage = async (id: number) =>
       .catch((err) => reject(err.response?.data || err))
   );
 
-const downloadImage = async (size: string, imageId: number) =>
+const downloadImage = async (size: string, imagePublicId: string) =>
   new Promise<any>((resolve, reject) =>
     axiosApi(<ApiObject>{
       method: "get",
-      url: `v1/download/${size}/image/${imageId}`,
+      url: `v1/download/${size}/image/${imagePublicId}`,
     })
       .then((res) => resolve(res.data))
       .catch((err) => reject(err.response?.data || err))
   );
 
-const downloadImages = async (size: string, imageIds: number[]) =>
+const downloadImages = async (size: string, imagePublicIds: string[]) =>
   new Promise<any>((resolve, reject) =>
     axiosApi(<ApiObject>{
       method: "get",
-      url: `v1/download/${size}/image/${imageIds.join(",")}`,
+      url: `v1/download/${size}/images/${imagePublicIds.join(",")}`,
     })
       .then((res) => resolve(res.data))
       .catch((err) => reject(err.response?.data || err))
   );
 
-const downloadGallery = async (size: string, galleryId: number) =>
+const downloadGallery = async (size: string, galleryPublicId: string) =>
   new Promise<any>((resolve, reject) =>
     axiosApi(<ApiObject>{
       method: "get",
-      url: `v1/download/${size}/gallery/${galleryId}`,
+      url: `v1/download/${size}/gallery/${galleryPublicId}`,
     })
       .then((res) => resolve(res.data))
       .catch((err) => reject(err.response?.data || err))
@@ -548,6 +605,7 @@ const api = {
   deleteCompany,
   deleteEvent,
   deleteGallery,
+  cloneGallery,
   deleteImage,
   downloadGallery,
   downloadImage,
@@ -558,6 +616,7 @@ const api = {
   getEvents,
   getGalleries,
   getGallery,
+  getGalleryPreview,
   getGalleryById,
   getGalleryByIdServerSide,
   getImage,
@@ -574,6 +633,7 @@ const api = {
   updateCompany,
   updateGallery,
   updateGalleryImagesOrder,
+  updatePreviewFavorite,
   updateSettings,
   updateUser,
   uploadGalleryImage,
@@ -28,8 +28,11 @@ const imageLoader = ({ src, width, quality }: ImageLoaderProps) => {
     imgWidth = MIN_WIDTH;
   }
 
-  // Add /width/height to the image source
-  const resizedSrc = `${src}/${imgWidth}/${quality || 75}`;
+  // Add /width/height to the image source, keeping any signature at the end
+  const [path, signature] = src.split("?");
+  const resizedSrc = `${path}/${imgWidth}/${quality || 75}${
+    signature ? `?${signature}` : ""
+  }`;
 
   return resizedSrc;
 };
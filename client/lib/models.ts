@@ -4,19 +4,52 @@ import dayjs from "dayjs";
 
 export interface Photo {
   ID: number;
+  public_id: string;
   CreatedAt: Date;
   UpdatedAt: Date;
   DeletedAt: Date | null;
   gallery_id: number;
+  set_id: number | null;
   featured_gallery_id?: number | null;
   size: number;
   height: number;
   width: number;
   position: number;
   filename: string;
+  original_filename: string;
+  title: string;
+  caption: string;
+  alt_text: string;
+  hidden: boolean;
+  tags: Tag[];
+  urls?: SignedURLs;
   blurDataURL?: string;
 }
 
+export interface SignedURLs {
+  query: string;
+  download_web: string;
+  download_original: string;
+}
+
+export interface Tag {
+  ID: number;
+  CreatedAt: Date;
+  UpdatedAt: Date;
+  DeletedAt: Date | null;
+  name: string;
+}
+
+export interface GallerySet {
+  ID: number;
+  CreatedAt: Date;
+  UpdatedAt: Date;
+  DeletedAt: Date | null;
+  gallery_id: number;
+  title: string;
+  position: number;
+}
+
 export interface SortablePhotoModel {
   src: string;
   width: number;
@@ -61,6 +94,7 @@ export interface LightboxPhoto {
 
 export interface GalleryModel {
   ID: number;
+  public_id: string;
   CreatedAt: Date;
   UpdatedAt: Date;
   DeletedAt: Date | null;
@@ -75,11 +109,56 @@ export interface GalleryModel {
   expiration: Date;
   images_count: number | null;
   zips_ready: boolean;
+  password_hint?: string | null;
+  live_notification: boolean;
+  live_notified_at?: Date | null;
+  live_fired_at?: Date | null;
+  expired_fired_at?: Date | null;
   reminder: boolean;
   reminder_emails: string | null;
+  reminder_days: string;
+  contacts?: GalleryContact[];
   hero_enabled: boolean;
   hero_variant: number;
+  signed_urls: boolean;
+  urls?: SignedURLs;
+  download_policy: DownloadPolicy;
   events: EventModel[];
+  sets: GallerySet[];
+  archived_at?: Date | null;
+  retention_warned_at?: Date | null;
+}
+
+export type DownloadSizes = "disabled" | "web" | "full";
+
+export interface DownloadPolicy {
+  sizes: DownloadSizes;
+  single: boolean;
+  selection: boolean;
+  gallery: boolean;
+  pin_required: boolean;
+  email_required: boolean;
+  limit: number;
+}
+
+export interface DownloadPolicyUpdate {
+  sizes?: DownloadSizes;
+  single?: boolean;
+  selection?: boolean;
+  gallery?: boolean;
+  pin?: string;
+  email_required?: boolean;
+  limit?: number;
+}
+
+export interface GalleryCloneModel {
+  title: string;
+  path: string;
+  event_date?: Date;
+  live?: Date;
+  expiration?: Date;
+  images?: boolean;
+  sets?: boolean;
 }
 
 export interface GalleryUpdateModel {
@@ -91,11 +170,98 @@ export interface GalleryUpdateModel {
   public?: boolean;
   protected?: boolean;
   password?: string | null;
+  password_hint?: string | null;
+  live_notification?: boolean;
   featured_image_id?: number | null;
   reminder?: boolean | null;
   reminder_emails?: string | null;
+  reminder_days?: string;
   hero_enabled?: boolean | null;
   hero_variant?: number | null;
+  signed_urls?: boolean;
+  download_policy?: DownloadPolicyUpdate;
+}
+
+export type ReminderStatus =
+  | "pending"
+  | "queued"
+  | "sent"
+  | "failed"
+  | "skipped";
+
+export interface GalleryReminder {
+  ID: number;
+  CreatedAt: Date;
+  UpdatedAt: Date;
+  gallery_id: number;
+  days: number;
+  email: string;
+  status: ReminderStatus;
+  outbox_email_id: number | null;
+  attempts: number;
+  last_error: string | null;
+  sent_at: Date | null;
+}
+
+export type ContactRole = "client" | "couple" | "family" | "planner" | "other";
+
+export interface Client {
+  ID: number;
+  email: string;
+  name: string;
+  contacts?: GalleryContact[];
+}
+
+export interface GalleryContact {
+  ID: number;
+  gallery_id: number;
+  client_id: number;
+  client?: Client;
+  role: ContactRole;
+}
+
+export interface GalleryContactUpdate {
+  name?: string;
+  email?: string;
+  role?: ContactRole;
+}
+
+export interface GalleryGrant {
+  grant: string;
+  expires: Date;
+}
+
+export interface PreviewFavorite {
+  ID: number;
+  CreatedAt: Date;
+  preview_link_id: number;
+  image_id: number;
+}
+
+export interface PreviewLink {
+  ID: number;
+  CreatedAt: Date;
+  UpdatedAt: Date;
+  DeletedAt: Date | null;
+  gallery_id: number;
+  name: string;
+  allow_favorites: boolean;
+  expiration: Date;
+  revoked_at: Date | null;
+  token?: string;
+  url?: string;
+  favorites: PreviewFavorite[];
+}
+
+export interface PreviewLinkCreate {
+  name: string;
+  allow_favorites?: boolean;
+  expiration?: Date;
+}
+
+export interface GalleryPreview {
+  gallery: GalleryModel;
+  preview: PreviewLink;
 }
 
 export interface NewGalleryModel {
@@ -109,7 +275,149 @@ export interface NewGalleryModel {
   password: string | null;
   reminder: boolean;
   reminder_emails: string | null;
+  reminder_days?: string;
+  live_notification?: boolean;
   hero_enabled: boolean;
+  preset_id?: number;
+}
+
+export interface GalleryPreset {
+  ID: number;
+  CreatedAt: Date;
+  UpdatedAt: Date;
+  DeletedAt: Date | null;
+  name: string;
+  default: boolean;
+  expiration_days: number;
+  public: boolean;
+  protected: boolean;
+  reminder: boolean;
+  reminder_emails?: string | null;
+  reminder_days: string;
+  hero_enabled: boolean;
+  hero_variant: number;
+  signed_urls: boolean;
+  download_policy: DownloadPolicy;
+}
+
+export interface GalleryPresetUpdate {
+  name?: string;
+  default?: boolean;
+  expiration_days?: number;
+  public?: boolean;
+  protected?: boolean;
+  reminder?: boolean;
+  reminder_emails?: string;
+  reminder_days?: string;
+  live_notification?: boolean;
+  hero_enabled?: boolean;
+  hero_variant?: number;
+  signed_urls?: boolean;
+  download_policy?: DownloadPolicyUpdate;
+}
+
+export type OutboxStatus = "pending" | "sent" | "failed";
+
+export type EmailKind = "two_factor" | "reminder" | "live" | "alert";
+
+export interface OutboxEmail {
+  ID: number;
+  CreatedAt: Date;
+  kind: EmailKind;
+  to: string;
+  subject: string;
+  status: OutboxStatus;
+  attempts: number;
+  next_attempt_at: Date;
+  last_error: string | null;
+  sent_at: Date | null;
+  expires_at?: Date;
+}
+
+export interface LiveNotification {
+  ID: number;
+  CreatedAt: Date;
+  gallery_id: number;
+  email: string;
+  outbox_email_id: number | null;
+  status: OutboxStatus;
+  last_error: string | null;
+  sent_at: Date | null;
+}
+
+export interface EmailTemplate {
+  ID: number;
+  kind: EmailKind;
+  subject: string;
+  html: string;
+  text: string;
+  custom: boolean;
+}
+
+export interface EmailTemplateUpdate {
+  subject?: string;
+  html?: string;
+  text?: string;
+}
+
+export interface RenderedEmail {
+  subject: string;
+  html: string;
+  text: string;
+}
+
+export type WebhookEvent =
+  | "gallery.created"
+  | "gallery.updated"
+  | "gallery.deleted"
+  | "gallery.live"
+  | "gallery.expired"
+  | "image.uploaded"
+  | "image.deleted"
+  | "download.image"
+  | "download.images"
+  | "download.gallery"
+  | "download.set"
+  | "favorite.added"
+  | "favorite.removed"
+  | "auth.login"
+  | "auth.failed"
+  | "webhook.ping";
+
+export interface Webhook {
+  ID: number;
+  CreatedAt: Date;
+  UpdatedAt: Date;
+  url: string;
+  events: string;
+  secret_hint: string;
+  enabled: boolean;
+}
+
+// Returned only when the webhook is created or its secret is rotated
+export interface WebhookWithSecret extends Webhook {
+  secret: string;
+}
+
+export interface WebhookUpdate {
+  url?: string;
+  events?: string;
+  enabled?: boolean;
+  rotate_secret?: boolean;
+}
+
+export interface WebhookDelivery {
+  ID: number;
+  CreatedAt: Date;
+  webhook_id: number;
+  event: WebhookEvent;
+  payload: string;
+  status: OutboxStatus;
+  attempts: number;
+  next_attempt_at: Date;
+  response_status: number | null;
+  last_error: string | null;
+  delivered_at: Date | null;
 }
 
 export interface Event {
@@ -124,6 +432,7 @@ export interface EventModel {
   DeletedAt: Date | null;
   gallery_id: number;
   image_id: number | null;
+  image_public_id?: string | null;
   requestor: string;
   filename: string;
   size: string;
@@ -171,10 +480,19 @@ export interface SocialModel {
   link: string;
 }
 
+export type DeployStatus = "" | "success" | "failed" | "skipped";
+
+export type Deployer = "docker" | "revalidate" | "command" | "none";
+
 export interface SettingsModel {
   update: boolean;
   version: string;
   uptime: number;
+  deployer: Deployer;
+  deploy_status: DeployStatus;
+  deploy_paths: string;
+  deploy_error?: string;
+  deployed_at: Date | null;
 }
 
 export interface PublicSettingsModel {
@@ -225,6 +543,21 @@ export interface GalleryResponse {
   data: GalleryModel;
 }
 
+export interface GalleryGrantResponse {
+  status: string;
+  data: GalleryGrant;
+}
+
+export interface GalleryPreviewResponse {
+  status: string;
+  data: GalleryPreview;
+}
+
+export interface PreviewLinkResponse {
+  status: string;
+  data: PreviewLink;
+}
+
 export interface ImageDeleteResponse {
   status: string;
   data: string;
@@ -18,7 +18,10 @@ import GalleryHeader from "@/components/client/GalleryHeader";
 import { ParsedUrlQuery } from "querystring";
 import api from "@/lib/api";
 import { LoadingButton } from "@mui/lab";
-import { randomFeaturedImage } from "@/helpers/gallery";
+import {
+  randomFeaturedImage,
+  signedURLsExpiration,
+} from "@/helpers/gallery";
 import DefaultLayout from "@/layouts/DefaultLayout";
 import { getImageBlurURL } from "@/helpers/photos";
 import axios from "axios";
@@ -35,20 +38,69 @@ type Props = {
   locked: boolean;
 };
 
+// Signed URLs are refreshed when they expire within this many milliseconds
+const SIGNED_URLS_MARGIN = 5 * 60 * 1000;
+
 const ClientGalleryHandler = (props: Props) => {
   const [showPassword, setShowPassword] = React.useState(false);
   const [password, setPassword] = React.useState("");
   const [loading, setLoading] = React.useState(false);
   const [error, setError] = React.useState(false);
   const [locked, setLocked] = React.useState(props.locked);
+  const [gallery, setGallery] = React.useState(props.gallery);
   const router = useRouter();
   const { p } = router.query;
 
+  // Fetch the gallery again to get URLs signed for this visitor, keeping the
+  // blur placeholders and featured image of the generated page
+  const refreshGallery = React.useCallback(
+    async (grant?: string) => {
+      const res = await api.getGallery(props.gallery.path, grant);
+      const blurs = new Map(
+        props.gallery.images.map((image) => [image.ID, image.blurDataURL])
+      );
+
+      const featured = res.data.images.find(
+        (image) => image.ID === props.gallery.featured_image.ID
+      );
+
+      setGallery({
+        ...res.data,
+        featured_image: {
+          ...props.gallery.featured_image,
+          urls: featured?.urls ?? res.data.featured_image.urls,
+        },
+        images: res.data.images.map((image) => ({
+          ...image,
+          blurDataURL: blurs.get(image.ID),
+        })),
+      });
+    },
+    [props.gallery]
+  );
+
+  React.useEffect(() => setGallery(props.gallery), [props.gallery]);
+
+  // The page is generated ahead of time, so its signed URLs can expire
+  // before a visitor opens it
   React.useEffect(() => {
-    if (typeof p === "string") {
+    const expiration = signedURLsExpiration(props.gallery);
+    if (
+      !props.locked &&
+      expiration &&
+      expiration.getTime() - Date.now() < SIGNED_URLS_MARGIN
+    ) {
+      refreshGallery().catch((err) => console.error(err));
+    }
+  }, [props.gallery, props.locked, refreshGallery]);
+
+  const unlock = React.useCallback(
+    (pass: string) => {
       setLoading(true);
+
       api
-        .unlockGallery(props.gallery.path, p)
+        .unlockGallery(props.gallery.path, pass)
+        .then((res) => refreshGallery(res.data.grant))
         .then(() => {
           setLocked(false);
           setError(false);
@@ -58,24 +110,17 @@ const ClientGalleryHandler = (props: Props) => {
           setError(true);
         })
         .finally(() => setLoading(false));
+    },
+    [props.gallery.path, refreshGallery]
+  );
+
+  React.useEffect(() => {
+    if (typeof p === "string") {
+      unlock(p);
     }
-  }, [p, props.gallery.path]);
-
-  const checkPassword = (pass: string) => {
-    setLoading(true);
-
-    api
-      .unlockGallery(props.gallery.path, pass)
-      .then(() => {
-        setLocked(false);
-        setError(false);
-      })
-      .catch((err) => {
-        console.error(err);
-        setError(true);
-      })
-      .finally(() => setLoading(false));
-  };
+  }, [p, unlock]);
+
+  const checkPassword = (pass: string) => unlock(pass);
 
   const handleKeyDown = (event: React.KeyboardEvent<HTMLInputElement>) => {
     if (event.key === "Enter") {
@@ -89,11 +134,11 @@ const ClientGalleryHandler = (props: Props) => {
       <div>
         <Head>
           <title>
-            {props.gallery.title} | {process.env.NEXT_PUBLIC_PHOTOGRAPHER_NAME}
+            {gallery.title} | {process.env.NEXT_PUBLIC_PHOTOGRAPHER_NAME}
           </title>
           <meta
             name="description"
-            content={`{process.env.NEXT_PUBLIC_PHOTOGRAPHER_NAME}'s gallery for ${props.gallery.title}.`}
+            content={`{process.env.NEXT_PUBLIC_PHOTOGRAPHER_NAME}'s gallery for ${gallery.title}.`}
           />
           <link
             rel="shortcut icon"
@@ -169,37 +214,38 @@ const ClientGalleryHandler = (props: Props) => {
     <div>
       <Head>
         <title>
-          {props.gallery.title} | {process.env.NEXT_PUBLIC_PHOTOGRAPHER_NAME}
+          {gallery.title} | {process.env.NEXT_PUBLIC_PHOTOGRAPHER_NAME}
         </title>
         <meta
           name="description"
-          content={`${process.env.NEXT_PUBLIC_PHOTOGRAPHER_NAME}'s gallery for ${props.gallery.title}.`}
+          content={`${process.env.NEXT_PUBLIC_PHOTOGRAPHER_NAME}'s gallery for ${gallery.title}.`}
         />
         <link rel="icon" href="/favicon.ico" />
       </Head>
-      {props.gallery.hero_enabled && (
+      {gallery.hero_enabled && (
         <React.Fragment>
-          {props.gallery.hero_variant === 0 && (
+          {gallery.hero_variant === 0 && (
             <HeroImage
-              img={props.gallery.featured_image}
-              title={props.gallery.title}
+              img={gallery.featured_image}
+              title={gallery.title}
               padding={0}
             />
           )}
-          {props.gallery.hero_variant === 1 && (
+          {gallery.hero_variant === 1 && (
             <HeroImageAlt
-              img={props.gallery.featured_image}
-              title={props.gallery.title}
+              img={gallery.featured_image}
+              title={gallery.title}
               padding={0}
             />
           )}
         </React.Fragment>
       )}
       <Container maxWidth={false} id="gallery-header">
-        <GalleryHeader gallery={props.gallery} />
+        <GalleryHeader gallery={gallery} />
         <GalleryHandler
-          galleryID={props.gallery.ID}
-          photos={props.gallery.images}
+          galleryID={gallery.ID}
+          galleryPublicID={gallery.public_id}
+          photos={gallery.images}
           loading={false}
         />
       </Container>
@@ -237,7 +283,12 @@ export const getStaticProps: GetStaticProps = async (
 
       try {
         const response = await axios.get(
-          getImageBlurURL(res.data.featured_image.ID, 64, 30),
+          getImageBlurURL(
+          res.data.featured_image.public_id,
+          64,
+          30,
+          res.data.featured_image.urls?.query
+        ),
           {
             responseType: "arraybuffer", // Ensure response is treated as binary data
           }
@@ -264,7 +315,12 @@ export const getStaticProps: GetStaticProps = async (
       for (let i = 0; i < res.data.images.length; i++) {
         try {
           const response = await axios.get(
-            getImageBlurURL(res.data.images[i].ID, 64, 30),
+            getImageBlurURL(
+              res.data.images[i].public_id,
+              64,
+              30,
+              res.data.images[i].urls?.query
+            ),
             {
               responseType: "arraybuffer", // Ensure response is treated as binary data
             }
@@ -289,8 +345,18 @@ export const getStaticProps: GetStaticProps = async (
         }
       }
 
+      // Regenerate the page well before its signed URLs expire
+      const expiration = signedURLsExpiration(res.data);
+      const revalidate = expiration
+        ? Math.max(
+            60,
+            Math.floor((expiration.getTime() - Date.now()) / 2000)
+          )
+        : false;
+
       return {
         props: { gallery: res.data, locked },
+        revalidate,
       };
     } catch (error) {
       console.warn("Gallery not found");
@@ -320,6 +386,8 @@ export const getStaticPaths: GetStaticPaths = async () => {
 
   return {
     paths,
-    fallback: false, // can also be true or 'blocking'
+    // Galleries that go live after the build are rendered on their first
+    // request or when the server revalidates them
+    fallback: "blocking",
   };
 };
@@ -182,6 +182,7 @@ const ClientGalleryPreviewHandler = (props: Props) => {
         <GalleryHeader gallery={props.gallery} />
         <GalleryHandler
           galleryID={props.gallery.ID}
+          galleryPublicID={props.gallery.public_id}
           photos={props.gallery.images}
           loading={false}
         />
@@ -219,7 +220,12 @@ export const getServerSideProps: GetServerSideProps = async (
 
     try {
       const response = await axios.get(
-        getImageBlurURL(res.data.featured_image.ID, 64, 30),
+        getImageBlurURL(
+          res.data.featured_image.public_id,
+          64,
+          30,
+          res.data.featured_image.urls?.query
+        ),
         {
           responseType: "arraybuffer", // Ensure response is treated as binary data
         }
@@ -246,7 +252,12 @@ export const getServerSideProps: GetServerSideProps = async (
     for (let i = 0; i < res.data.images.length; i++) {
       try {
         const response = await axios.get(
-          getImageBlurURL(res.data.images[i].ID, 64, 30),
+          getImageBlurURL(
+            res.data.images[i].public_id,
+            64,
+            30,
+            res.data.images[i].urls?.query
+          ),
           {
             responseType: "arraybuffer", // Ensure response is treated as binary data
           }
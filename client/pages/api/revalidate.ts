@@ -0,0 +1,41 @@
+import type { NextApiRequest, NextApiResponse } from "next";
+
+// Regenerates the pages the server sends after the galleries change, used
+// when the server's CLIENT_DEPLOYER is revalidate
+export default async function handler(
+  req: NextApiRequest,
+  res: NextApiResponse
+) {
+  if (req.method !== "POST") {
+    res.setHeader("Allow", "POST");
+    return res.status(405).json({ message: "Method not allowed" });
+  }
+
+  const secret = process.env.CLIENT_REVALIDATE_SECRET;
+  if (!secret || req.headers.authorization !== `Bearer ${secret}`) {
+    return res.status(401).json({ message: "Invalid revalidate secret" });
+  }
+
+  const paths: unknown = req.body?.paths;
+  if (
+    !Array.isArray(paths) ||
+    !paths.every((path) => typeof path === "string" && path.startsWith("/"))
+  ) {
+    return res.status(400).json({ message: "paths must be a list of paths" });
+  }
+
+  const failed: string[] = [];
+  for (const path of paths) {
+    try {
+      await res.revalidate(path);
+    } catch (error) {
+      console.error(`Unable to revalidate ${path}:`, error);
+      failed.push(path);
+    }
+  }
+
+  // The server records the deploy as failed unless every path regenerated
+  return res
+    .status(failed.length > 0 ? 500 : 200)
+    .json({ revalidated: paths.length - failed.length, failed });
+}
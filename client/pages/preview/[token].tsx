@@ -0,0 +1,104 @@
+import GalleryHandler from "@/components/client/GalleryHandler";
+import GalleryHeader from "@/components/client/GalleryHeader";
+import DefaultLayout from "@/layouts/DefaultLayout";
+import { getFormattedTableDate } from "@/helpers/format";
+import api from "@/lib/api";
+import { GalleryModel, Photo, PreviewLink } from "@/lib/models";
+import { Alert, CircularProgress, Container, Stack } from "@mui/material";
+import Head from "next/head";
+import { useRouter } from "next/router";
+import React from "react";
+
+// The page is rendered in the browser only, the token of a preview link is
+// a secret and the gallery doesn't have to be live
+const GalleryPreviewHandler = () => {
+  const [gallery, setGallery] = React.useState<GalleryModel | null>(null);
+  const [preview, setPreview] = React.useState<PreviewLink | null>(null);
+  const [loading, setLoading] = React.useState(true);
+  const [error, setError] = React.useState("");
+  const router = useRouter();
+  const { token } = router.query;
+
+  React.useEffect(() => {
+    if (typeof token !== "string") return;
+
+    setLoading(true);
+    api
+      .getGalleryPreview(token)
+      .then((res) => {
+        setGallery(res.data.gallery);
+        setPreview(res.data.preview);
+        setError("");
+      })
+      .catch((err) => {
+        console.error(err);
+        setError("This preview link is invalid, expired or was revoked.");
+      })
+      .finally(() => setLoading(false));
+  }, [token]);
+
+  const updateFavorite = (photo: Photo, favorite: boolean) => {
+    if (typeof token !== "string") return;
+
+    api
+      .updatePreviewFavorite(token, photo.public_id, favorite)
+      .then((res) => setPreview(res.data))
+      .catch((err) => {
+        console.error(err);
+        setError("Unable to update the favorites.");
+      });
+  };
+
+  return (
+    <div>
+      <Head>
+        <title>
+          {gallery ? `${gallery.title} Preview` : "Gallery Preview"} |{" "}
+          {process.env.NEXT_PUBLIC_PHOTOGRAPHER_NAME}
+        </title>
+        <meta name="robots" content="noindex" />
+        <link
+          rel="shortcut icon"
+          href={process.env.NEXT_PUBLIC_PHOTOGRAPHER_FAVICON || "/favicon.ico"}
+        />
+      </Head>
+      {loading && (
+        <Stack sx={{ mt: 8 }} alignItems="center">
+          <CircularProgress />
+        </Stack>
+      )}
+      <Container maxWidth="xl">
+        {error && (
+          <Alert sx={{ mt: 2 }} severity="error" onClose={() => setError("")}>
+            {error}
+          </Alert>
+        )}
+        {preview && (
+          <Alert sx={{ mt: 2 }} severity="info">
+            Preview for {preview.name}, available until{" "}
+            {getFormattedTableDate(preview.expiration)}.
+            {preview.allow_favorites &&
+              " Use the heart on an image to add it to your favorites."}
+          </Alert>
+        )}
+      </Container>
+      {gallery && preview && (
+        <Container maxWidth={false} id="gallery-header">
+          <GalleryHeader gallery={gallery} />
+          <GalleryHandler
+            galleryID={gallery.ID}
+            galleryPublicID={gallery.public_id}
+            photos={gallery.images}
+            loading={false}
+            favorites={preview.favorites.map((favorite) => favorite.image_id)}
+            onFavorite={preview.allow_favorites ? updateFavorite : undefined}
+          />
+        </Container>
+      )}
+    </div>
+  );
+};
+
+GalleryPreviewHandler.Layout = DefaultLayout;
+
+export default GalleryPreviewHandler;
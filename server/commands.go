@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/austinbspencer/gshare-server/internal/models"
+	"github.com/austinbspencer/gshare-server/runner"
+)
+
+// runCommand runs a one-off maintenance command instead of the server
+// Usage: ./server reprocess [galleryID]
+func runCommand(args []string) int {
+	switch args[0] {
+	case "reprocess":
+		return reprocessCommand(args[1:])
+	default:
+		fmt.Fprintf(os.Stderr, "Unknown command: %s\nAvailable commands: reprocess [galleryID]\n", args[0])
+		return 1
+	}
+}
+
+// reprocessCommand rebuilds the image derivatives of one or every gallery
+func reprocessCommand(args []string) int {
+	var galleryID *uint
+	if len(args) > 0 {
+		id, err := strconv.ParseUint(args[0], 10, 32)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid gallery ID given (%s): %v\n", args[0], err)
+			return 1
+		}
+		uintID := uint(id)
+		galleryID = &uintID
+	}
+
+	job, err := runner.RunReprocessJob(galleryID, func(job models.ReprocessJob) {
+		fmt.Printf("\rReprocessed %d/%d images (%d failed)", job.Processed, job.Total, job.Failed)
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to reprocess images: %v\n", err)
+		return 1
+	}
+	fmt.Println()
+
+	for _, jobErr := range job.Errors {
+		fmt.Fprintln(os.Stderr, jobErr)
+	}
+
+	if job.Status != models.JobCompleted {
+		return 1
+	}
+
+	return 0
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/austinbspencer/gshare-server/internal/queries"
 	"github.com/austinbspencer/gshare-server/pkg/auth"
 	"github.com/austinbspencer/gshare-server/pkg/configs"
+	"github.com/austinbspencer/gshare-server/platform/webhook"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/log"
 )
@@ -37,6 +38,7 @@ func GetNewAccessToken(c *fiber.Ctx) error {
 
 	user, err := userQueries.GetFullUserByEmail(creds.Email)
 	if err != nil {
+		fireAuthFailed(c, creds.Email, "unknown email")
 		// Return status 400 and error message.
 		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
 			Status: "fail",
@@ -48,6 +50,7 @@ func GetNewAccessToken(c *fiber.Ctx) error {
 
 	// Check if the password is correct
 	if err := user.CheckPassword(creds.Password); err != nil {
+		fireAuthFailed(c, user.Email, "incorrect password")
 		// Return status 400 and error message.
 		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
 			Status: "fail",
@@ -85,6 +88,12 @@ func GetNewAccessToken(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
 	}
 
+	webhook.Fire(models.WebhookAuthLogin, fiber.Map{
+		"user_id": user.ID,
+		"email":   user.Email,
+		"ip":      c.IP(),
+	})
+
 	return c.JSON(models.APIResponse{
 		Status: "success",
 		Data: fiber.Map{
@@ -188,6 +197,7 @@ func TwoFactorAuth(c *fiber.Ctx) error {
 
 	user, err := userQueries.GetFullUserByEmail(creds.Email)
 	if err != nil {
+		fireAuthFailed(c, creds.Email, "unknown email")
 		// Return status 400 and error message.
 		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
 			Status: "fail",
@@ -199,6 +209,7 @@ func TwoFactorAuth(c *fiber.Ctx) error {
 
 	// Check if the 2FA code is correct
 	if err := user.CheckTwoFactorAuthCode(creds.Code); err != nil {
+		fireAuthFailed(c, user.Email, "incorrect 2FA code")
 		// Return status 400 and error message.
 		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
 			Status: "fail",
@@ -216,6 +227,12 @@ func TwoFactorAuth(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
 	}
 
+	webhook.Fire(models.WebhookAuthLogin, fiber.Map{
+		"user_id": user.ID,
+		"email":   user.Email,
+		"ip":      c.IP(),
+	})
+
 	return c.JSON(models.APIResponse{
 		Status: "success",
 		Data: fiber.Map{
@@ -224,3 +241,12 @@ func TwoFactorAuth(c *fiber.Ctx) error {
 		},
 	})
 }
+
+// fireAuthFailed sends the failed sign in to the webhooks
+func fireAuthFailed(c *fiber.Ctx, email, reason string) {
+	webhook.Fire(models.WebhookAuthFailed, fiber.Map{
+		"email":  email,
+		"ip":     c.IP(),
+		"reason": reason,
+	})
+}
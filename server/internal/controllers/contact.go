@@ -0,0 +1,354 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/austinbspencer/gshare-server/internal/models"
+	"github.com/austinbspencer/gshare-server/internal/queries"
+	"github.com/austinbspencer/gshare-server/pkg/auth"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+)
+
+// @Description  Get the contacts of the gallery along with their client.
+// @Summary      get the contacts of a gallery
+// @Tags         Contact
+// @Produce      json
+// @Param        galleryID   path       string  true  "Gallery ID"
+// @Security     ApiKeyAuth
+// @Success      200        {object}  []models.GalleryContact
+// @Router       /v1/galleries/id/{galleryID}/contacts [get]
+func GetGalleryContacts(c *fiber.Ctx) error {
+	_, _, err := auth.IsAuthenticated(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+
+	// Read the param galleryID
+	galleryID := c.Params("galleryID")
+
+	galleryQueries := queries.NewGalleryRepository()
+
+	gallery, err := galleryQueries.GetGalleryByID(galleryID)
+	if err != nil || gallery == nil {
+		log.Debugf("No gallery with ID %s in DB\n", galleryID)
+		return fiber.NewError(fiber.StatusNotFound, "No gallery with the given ID")
+	}
+
+	// Return success and the contacts
+	return c.JSON(models.APIResponse{
+		Status: "success",
+		Data:   gallery.Contacts,
+	})
+}
+
+// @Description  Add a contact to the gallery. The contact is linked to the
+// @Description  client with the email, which is created when there isn't one.
+// @Summary      add a contact to a gallery
+// @Tags         Contact
+// @Accept       json
+// @Produce      json
+// @Param        galleryID   path       string  true  "Gallery ID"
+// @Param        payload     body       models.GalleryContactUpdate  true  "New Contact"
+// @Security     ApiKeyAuth
+// @Success      201        {object}  models.GalleryContact
+// @Router       /v1/galleries/id/{galleryID}/contacts [post]
+func CreateGalleryContact(c *fiber.Ctx) error {
+	_, _, err := auth.IsAuthenticated(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+
+	// Read the param galleryID
+	galleryID := c.Params("galleryID")
+
+	galleryQueries := queries.NewGalleryRepository()
+
+	gallery, err := galleryQueries.GetGalleryByID(galleryID)
+	if err != nil || gallery == nil {
+		log.Debugf("No gallery with ID %s in DB\n", galleryID)
+		return fiber.NewError(fiber.StatusNotFound, "No gallery with the given ID")
+	}
+
+	contactUpdate := new(models.GalleryContactUpdate)
+
+	if err := c.BodyParser(contactUpdate); err != nil {
+		log.Errorf("Unable to parse new contact: %v\n", err)
+		// Return status 400 and error message.
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Status: "fail",
+			Data: fiber.Map{
+				"issue": err.Error(),
+			},
+		})
+	}
+
+	if contactUpdate.Email == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Status: "fail",
+			Data: fiber.Map{
+				"email": "Email is required.",
+			},
+		})
+	}
+
+	if failData := validateGalleryContact(contactUpdate); failData != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Status: "fail",
+			Data:   failData,
+		})
+	}
+
+	contact := &models.GalleryContact{
+		GalleryID: gallery.ID,
+		Role:      models.ContactClient,
+	}
+
+	contactQueries := queries.NewContactRepository()
+
+	if err := contactQueries.CreateGalleryContact(contact, *contactUpdate); err != nil {
+		if errors.Is(err, queries.ErrContactExists) {
+			return c.Status(fiber.StatusConflict).JSON(models.APIResponse{
+				Status: "fail",
+				Data: fiber.Map{
+					"email": "The client is already a contact of this gallery.",
+				},
+			})
+		}
+		log.Errorf("Unable to create new contact in DB: %v\n", err)
+		// Return status 500 and error message.
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	// Return the created contact
+	return c.Status(fiber.StatusCreated).JSON(models.APIResponse{
+		Status: "success",
+		Data:   contact,
+	})
+}
+
+// @Description  Update a contact of the gallery. A new email links the contact
+// @Description  to the client with the email, and the name is changed for the
+// @Description  client in every gallery.
+// @Summary      update a contact of a gallery
+// @Tags         Contact
+// @Accept       json
+// @Produce      json
+// @Param        galleryID   path       string  true  "Gallery ID"
+// @Param        contactID   path       string  true  "Contact ID"
+// @Param        payload     body       models.GalleryContactUpdate  true  "Contact Update"
+// @Security     ApiKeyAuth
+// @Success      200        {object}  models.GalleryContact
+// @Router       /v1/galleries/id/{galleryID}/contacts/{contactID} [put]
+func UpdateGalleryContact(c *fiber.Ctx) error {
+	_, _, err := auth.IsAuthenticated(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+
+	contact, err := getGalleryContact(c)
+	if err != nil || contact == nil {
+		return err
+	}
+
+	contactUpdate := new(models.GalleryContactUpdate)
+
+	if err := c.BodyParser(contactUpdate); err != nil {
+		log.Errorf("Unable to parse contact update: %v\n", err)
+		// Return status 400 and error message.
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Status: "fail",
+			Data: fiber.Map{
+				"issue": err.Error(),
+			},
+		})
+	}
+
+	if failData := validateGalleryContact(contactUpdate); failData != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Status: "fail",
+			Data:   failData,
+		})
+	}
+
+	contactQueries := queries.NewContactRepository()
+
+	if err := contactQueries.UpdateGalleryContact(contact, *contactUpdate); err != nil {
+		if errors.Is(err, queries.ErrContactExists) {
+			return c.Status(fiber.StatusConflict).JSON(models.APIResponse{
+				Status: "fail",
+				Data: fiber.Map{
+					"email": "The client is already a contact of this gallery.",
+				},
+			})
+		}
+		log.Errorf("Unable to update contact in DB: %v\n", err)
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	// Return the updated contact
+	return c.JSON(models.APIResponse{
+		Status: "success",
+		Data:   contact,
+	})
+}
+
+// @Description  Remove a contact from the gallery, the client is kept.
+// @Summary      remove a contact from a gallery
+// @Tags         Contact
+// @Produce      json
+// @Param        galleryID   path       string  true  "Gallery ID"
+// @Param        contactID   path       string  true  "Contact ID"
+// @Security     ApiKeyAuth
+// @Success      200
+// @Router       /v1/galleries/id/{galleryID}/contacts/{contactID} [delete]
+func DeleteGalleryContact(c *fiber.Ctx) error {
+	_, _, err := auth.IsAuthenticated(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+
+	contact, err := getGalleryContact(c)
+	if err != nil || contact == nil {
+		return err
+	}
+
+	contactQueries := queries.NewContactRepository()
+
+	if err := contactQueries.DeleteGalleryContact(contact); err != nil {
+		log.Errorf("Unable to delete contact in DB: %v\n", err)
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	// Return success
+	return c.JSON(models.APIResponse{
+		Status: "success",
+		Data:   "Contact removed.",
+	})
+}
+
+// @Description  Get the clients along with the galleries they are a contact of.
+// @Summary      get all clients
+// @Tags         Contact
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Success      200        {object}  []models.Client
+// @Router       /v1/clients [get]
+func GetClients(c *fiber.Ctx) error {
+	_, _, err := auth.IsAuthenticated(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+
+	contactQueries := queries.NewContactRepository()
+
+	clients, err := contactQueries.GetClients()
+	if err != nil {
+		log.Errorf("Error retrieving clients from DB: %v\n", err)
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	// Return success and the clients
+	return c.JSON(models.APIResponse{
+		Status: "success",
+		Data:   clients,
+	})
+}
+
+// getGalleryContact reads the contact from the galleryID and contactID
+// params. A nil contact means the response was already sent.
+func getGalleryContact(c *fiber.Ctx) (*models.GalleryContact, error) {
+	galleryID := c.Params("galleryID")
+	contactID := c.Params("contactID")
+
+	galleryQueries := queries.NewGalleryRepository()
+
+	gallery, err := galleryQueries.GetGalleryByID(galleryID)
+	if err != nil || gallery == nil {
+		log.Debugf("No gallery with ID %s in DB\n", galleryID)
+		return nil, fiber.NewError(fiber.StatusNotFound, "No gallery with the given ID")
+	}
+
+	contactQueries := queries.NewContactRepository()
+
+	contact, err := contactQueries.GetGalleryContactByID(gallery.ID, contactID)
+	if err != nil || contact == nil {
+		log.Debugf("No contact with ID %s in gallery %s\n", contactID, galleryID)
+		return nil, fiber.NewError(fiber.StatusNotFound, "No contact with the given ID")
+	}
+
+	return contact, nil
+}
+
+// validateGalleryContact checks the contact update, the name is trimmed and
+// the email is put in lowercase without a display name
+func validateGalleryContact(update *models.GalleryContactUpdate) fiber.Map {
+	failData := fiber.Map{}
+
+	if update.Name != nil {
+		name := strings.TrimSpace(*update.Name)
+		update.Name = &name
+
+		if len(name) > models.MaxContactNameLength {
+			failData["name"] = fmt.Sprintf("Name must be at most %d characters.", models.MaxContactNameLength)
+		}
+	}
+
+	if update.Email != nil {
+		email, err := models.NormalizeContactEmail(*update.Email)
+		if err != nil {
+			failData["email"] = "Email must be a valid email address."
+		} else {
+			update.Email = &email
+		}
+	}
+
+	if update.Role != nil && !models.ValidContactRole(*update.Role) {
+		failData["role"] = "Role must be one of (client, couple, family, planner, other)."
+	}
+
+	if len(failData) == 0 {
+		return nil
+	}
+
+	return failData
+}
+
+// validateContactEmails checks the space separated emails that replace the
+// contacts of a gallery
+func validateContactEmails(value string) ([]string, fiber.Map) {
+	emails, err := models.ParseContactEmails(value)
+	if err != nil {
+		return nil, fiber.Map{
+			"reminder_emails": fmt.Sprintf("Reminder emails must be valid email addresses separated by spaces, %v.", err),
+		}
+	}
+
+	return emails, nil
+}
+
+// setGalleryContactEmails replaces the contacts of the gallery with the
+// clients with the emails
+func setGalleryContactEmails(gallery *models.Gallery, emails []string) error {
+	contactQueries := queries.NewContactRepository()
+
+	if err := contactQueries.SetGalleryContactEmails(gallery.ID, emails); err != nil {
+		return err
+	}
+
+	contacts, err := contactQueries.GetGalleryContacts(gallery.ID)
+	if err != nil {
+		return err
+	}
+
+	gallery.Contacts = contacts
+	gallery.ReminderEmails = nil
+	if len(contacts) > 0 {
+		reminderEmails := strings.Join(models.ContactEmails(contacts), " ")
+		gallery.ReminderEmails = &reminderEmails
+	}
+
+	return nil
+}
@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"fmt"
+	"net/mail"
 	"os"
 	"strconv"
 	"strings"
@@ -9,8 +10,12 @@ import (
 
 	"github.com/austinbspencer/gshare-server/internal/models"
 	"github.com/austinbspencer/gshare-server/internal/queries"
+	"github.com/austinbspencer/gshare-server/pkg/auth"
+	"github.com/austinbspencer/gshare-server/pkg/cachestore"
+	"github.com/austinbspencer/gshare-server/pkg/configs"
 	"github.com/austinbspencer/gshare-server/pkg/images"
 	"github.com/austinbspencer/gshare-server/pkg/utils"
+	"github.com/austinbspencer/gshare-server/platform/webhook"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/log"
 )
@@ -20,13 +25,13 @@ import (
 // @Tags         Download
 // @Produce      json
 // @Param        size      path       string  true  "Image Size"
-// @Param        imageID   path       string  true  "Image ID"
+// @Param        imagePublicID   path       string  true  "Image Public ID"
 // @Success      200        {object}  models.Image
-// @Router       /v1/download/{size}/image/{imageID} [get]
+// @Router       /v1/download/{size}/image/{imagePublicID} [get]
 func DownloadImage(c *fiber.Ctx) error {
 	// Read the param imageID
 	size := strings.ToLower(c.Params("size"))
-	imageID := c.Params("imageID")
+	imagePublicID := c.Params("imagePublicID")
 	imageSize := models.ImageSize(size)
 
 	// Check the given size is valid
@@ -42,12 +47,32 @@ func DownloadImage(c *fiber.Ctx) error {
 
 	imageQueries := queries.NewImageRepository()
 
-	image, err := imageQueries.GetImageByID(imageID)
-	if err != nil || image == nil {
+	image, err := imageQueries.GetImageByPublicID(imagePublicID)
+	if err != nil || image == nil || image.Hidden {
 		log.Error("Image not found for download")
 		return fiber.NewError(fiber.StatusNotFound, "No image with the given ID")
 	}
 
+	galleryQueries := queries.NewGalleryRepository()
+
+	gallery, err := galleryQueries.GetGalleryByID(fmt.Sprint(image.GalleryID))
+	if err != nil || gallery == nil {
+		log.Errorf("No gallery with the given ID: %v\n", err)
+		return fiber.NewError(fiber.StatusNotFound, "No gallery with the given ID")
+	}
+
+	if err := checkGalleryAccess(c, gallery, auth.ImageResource(image.ID)); err != nil {
+		return err
+	}
+
+	download, status, failData := checkDownloadPolicy(c, gallery, models.DownloadSingle, imageSize)
+	if failData != nil {
+		return c.Status(status).JSON(models.APIResponse{
+			Status: "fail",
+			Data:   failData,
+		})
+	}
+
 	imgPath := images.GetImagePath(image.GalleryID, string(imageSize), image.Filename)
 
 	// Read the image from disk
@@ -67,28 +92,27 @@ func DownloadImage(c *fiber.Ctx) error {
 
 	contentType := utils.GetMimeTypeFromExtension(image.Filename)
 
-	log.Debugf("Setting content disposition to %s\n", image.Filename)
+	log.Debugf("Setting content disposition to %s\n", image.DownloadName())
 
-	c.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s", image.Filename))
+	c.Set("Content-Disposition", utils.ContentDisposition("attachment", image.DownloadName()))
 	c.Set("Content-Type", contentType)
 	c.Set("Content-Length", fmt.Sprintf("%d", len(imageBytes)))
 
-	galleryQueries := queries.NewGalleryRepository()
+	recordDownload(download)
+	fireDownload(c, models.WebhookDownloadImage, gallery, imageSize, download, fiber.Map{
+		"image_id":        image.ID,
+		"image_public_id": image.PublicID,
+	})
 
-	gallery, err := galleryQueries.GetGalleryByID(fmt.Sprint(image.GalleryID))
-	if err != nil || gallery == nil {
-		log.Errorf("No gallery with the given ID: %v\n", err)
+	// Downloads that are checked or sent to webhooks on each request can't
+	// be cached
+	if !cacheDownload(gallery, models.WebhookDownloadImage) {
 		return c.Send(imageBytes)
 	}
 
 	// Set cache time so we don't repeat processing on each request
 	// for the exact same image download
-	// Setting the cache duration as the time until expiration of the gallery
-	duration := gallery.Expiration.Sub(time.Now())
-
-	log.Debugf("Setting cache expiration time for image download to %s\n", time.Now().Add(duration))
-
-	c.Set("Cache-Time", fmt.Sprint(int(duration.Seconds())))
+	setGalleryCacheTime(c, gallery)
 
 	// Return success and the individual image
 	return c.Send(imageBytes)
@@ -99,26 +123,15 @@ func DownloadImage(c *fiber.Ctx) error {
 // @Tags         Download
 // @Produce      json
 // @Param        size      path       string  true  "Image Size"
-// @Param        imageID   path       string  true  "Comma separated Image IDs"
+// @Param        imagePublicIDs   path       string  true  "Comma separated Image Public IDs"
 // @Success      200        {object}  models.Image
-// @Router       /v1/download/{size}/images/{imageIDs} [get]
+// @Router       /v1/download/{size}/images/{imagePublicIDs} [get]
 func DownloadImages(c *fiber.Ctx) error {
 	// Read the param imageID
 	size := strings.ToLower(c.Params("size"))
-	imageIDsParam := c.Params("imageIDs")
+	imagePublicIDs := strings.Split(c.Params("imagePublicIDs"), ",")
 	imageSize := models.ImageSize(size)
 
-	imageIDsStr := strings.Split(imageIDsParam, ",")
-	var imageIDs []uint
-	for _, id := range imageIDsStr {
-		uintId, err := strconv.ParseUint(id, 10, 64)
-		if err != nil {
-			log.Errorf("Invalid image ID given to download: %v\n", err)
-			continue
-		}
-		imageIDs = append(imageIDs, uint(uintId))
-	}
-
 	// Check the given size is valid
 	if !models.ValidImageSize(imageSize) {
 		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
@@ -131,12 +144,14 @@ func DownloadImages(c *fiber.Ctx) error {
 
 	imageQueries := queries.NewImageRepository()
 
-	specificImages, err := imageQueries.GetSpecificImages(imageIDs)
+	specificImages, err := imageQueries.GetSpecificImagesByPublicIDs(imagePublicIDs)
 	if err != nil {
 		log.Errorf("Unable to find image requested for download: %v\n", err)
 		return fiber.NewError(fiber.StatusNotFound, "No image with the given ID")
 	}
 
+	specificImages = visibleImages(specificImages)
+
 	if len(specificImages) == 0 {
 		log.Warn("No images were found for download.")
 		return fiber.NewError(fiber.StatusNotFound, "No images match your request.")
@@ -150,14 +165,29 @@ func DownloadImages(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusNotFound, "No gallery with the given ID")
 	}
 
-	// Convert the images to an array of filenames
-	var imageFilenames []string
+	if err := checkGalleryAccess(c, gallery, auth.GalleryResource(gallery.ID)); err != nil {
+		return err
+	}
+
+	download, status, failData := checkDownloadPolicy(c, gallery, models.DownloadSelection, imageSize)
+	if failData != nil {
+		return c.Status(status).JSON(models.APIResponse{
+			Status: "fail",
+			Data:   failData,
+		})
+	}
+
+	// Add the selected images of the gallery to the root of the zip
+	var entries []images.ZipEntry
 	for _, img := range specificImages {
-		imageFilenames = append(imageFilenames, img.Filename)
+		if img.GalleryID != gallery.ID {
+			continue
+		}
+		entries = append(entries, images.ZipEntry{Filename: img.Filename, Name: img.DownloadName()})
 	}
 
 	// Generate the zip on demand
-	zipBytes, err := images.GenerateZipOnDemand(gallery.ID, string(imageSize), imageFilenames)
+	zipBytes, err := images.GenerateZipOnDemand(gallery.ID, string(imageSize), entries)
 	if err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
 			"error": "Unable to generate zip file for download.",
@@ -168,14 +198,20 @@ func DownloadImages(c *fiber.Ctx) error {
 	c.Set("Content-Type", "application/octet-stream")
 	c.Set("Content-Length", fmt.Sprintf("%d", len(zipBytes)))
 
-	// Set cache time so we don't repeat processing on each request
-	// for the exact same images download
-	// Setting the cache duration as the time until expiration of the gallery
-	duration := gallery.Expiration.Sub(time.Now())
+	recordDownload(download)
+	fireDownload(c, models.WebhookDownloadImages, gallery, imageSize, download, fiber.Map{
+		"image_count": len(entries),
+	})
 
-	log.Debugf("Setting cache time for images download to %s\n", time.Now().Add(duration))
+	// Downloads that are checked or sent to webhooks on each request can't
+	// be cached
+	if !cacheDownload(gallery, models.WebhookDownloadImages) {
+		return c.Send(zipBytes)
+	}
 
-	c.Set("Cache-Time", fmt.Sprint(int(duration.Seconds())))
+	// Set cache time so we don't repeat processing on each request
+	// for the exact same images download
+	setGalleryCacheTime(c, gallery)
 
 	// Return success and the zip of images
 	return c.Send(zipBytes)
@@ -186,13 +222,13 @@ func DownloadImages(c *fiber.Ctx) error {
 // @Tags         Download
 // @Produce      json
 // @Param        size           path       string  true  "Image Size"
-// @Param        galleryID      path       string  true  "Gallery ID"
+// @Param        galleryPublicID      path       string  true  "Gallery Public ID"
 // @Success      200        {object}  models.Image
-// @Router       /v1/download/{size}/gallery/{galleryID} [get]
+// @Router       /v1/download/{size}/gallery/{galleryPublicID} [get]
 func DownloadGallery(c *fiber.Ctx) error {
 	// Read the param galleryID
 	size := strings.ToLower(c.Params("size"))
-	galleryID := c.Params("galleryID")
+	galleryPublicID := c.Params("galleryPublicID")
 	imageSize := models.ImageSize(size)
 
 	// Check the given size is valid
@@ -208,12 +244,24 @@ func DownloadGallery(c *fiber.Ctx) error {
 
 	galleryQueries := queries.NewGalleryRepository()
 
-	gallery, err := galleryQueries.GetGalleryByID(galleryID)
+	gallery, err := galleryQueries.GetGalleryByPublicID(galleryPublicID)
 	if err != nil || gallery == nil {
 		log.Warnf("No gallery with the given ID: %v\n", err)
 		return fiber.NewError(fiber.StatusNotFound, "No gallery with the given ID")
 	}
 
+	if err := checkGalleryAccess(c, gallery, auth.GalleryResource(gallery.ID)); err != nil {
+		return err
+	}
+
+	download, status, failData := checkDownloadPolicy(c, gallery, models.DownloadGallery, imageSize)
+	if failData != nil {
+		return c.Status(status).JSON(models.APIResponse{
+			Status: "fail",
+			Data:   failData,
+		})
+	}
+
 	var fileBytes []byte
 	var exists bool = false
 
@@ -223,17 +271,12 @@ func DownloadGallery(c *fiber.Ctx) error {
 	}
 
 	if !exists {
-		log.Warnf("Unable to find zip for gallery: %s\n", galleryID)
+		log.Warnf("Unable to find zip for gallery: %d\n", gallery.ID)
 		// The zip likely doesn't exist -- Generate the zip on demand
-		// We need to get the filenames of all the images in the gallery
-		// Convert the images to an array of filenames
-		var imageFilenames []string
-
-		for _, img := range gallery.Images {
-			imageFilenames = append(imageFilenames, img.Filename)
-		}
+		// with a folder for each set of the gallery
+		entries := galleryZipEntries(gallery.Sets, gallery.Images)
 
-		fileBytes, err = images.GenerateZipOnDemand(gallery.ID, string(imageSize), imageFilenames)
+		fileBytes, err = images.GenerateZipOnDemand(gallery.ID, string(imageSize), entries)
 		if err != nil {
 			log.Errorf("Unable to generate zip for gallery: %v\n", err)
 			// Return status 500 and error message.
@@ -259,18 +302,374 @@ func DownloadGallery(c *fiber.Ctx) error {
 	c.Set("Content-Type", "application/zip")
 	c.Set("Content-Length", fmt.Sprintf("%d", len(fileBytes)))
 
+	recordDownload(download)
+	fireDownload(c, models.WebhookDownloadGallery, gallery, imageSize, download, fiber.Map{})
+
+	// Downloads that are checked or sent to webhooks on each request can't
+	// be cached
+	if !cacheDownload(gallery, models.WebhookDownloadGallery) {
+		return c.Send(fileBytes)
+	}
+
 	// Set cache time so we don't repeat processing on each request
 	// for the exact same images download
 	// Not sure if we want to cache this at all, would mean that if the
 	// gallery is updated, the zip would not be updated until the cache
 	// time expires or the cache is cleared.
-	// Setting the cache duration as the time until expiration of the gallery
-	duration := gallery.Expiration.Sub(time.Now())
-
-	log.Debugf("Setting cache time for images download to %s\n", time.Now().Add(duration))
-
-	c.Set("Cache-Time", fmt.Sprint(int(duration.Seconds())))
+	setGalleryCacheTime(c, gallery)
 
 	// Return success and the zip file
 	return c.Send(fileBytes)
 }
+
+// @Description  Download the images of a set in the gallery.
+// @Summary      download a single set of the gallery
+// @Tags         Download
+// @Produce      json
+// @Param        size           path       string  true  "Image Size"
+// @Param        galleryPublicID      path       string  true  "Gallery Public ID"
+// @Param        setID          path       string  true  "Set ID"
+// @Success      200
+// @Router       /v1/download/{size}/gallery/{galleryPublicID}/set/{setID} [get]
+func DownloadGallerySet(c *fiber.Ctx) error {
+	// Read the params
+	size := strings.ToLower(c.Params("size"))
+	galleryPublicID := c.Params("galleryPublicID")
+	setID := c.Params("setID")
+	imageSize := models.ImageSize(size)
+
+	// Check the given size is valid
+	if !models.ValidImageSize(imageSize) {
+		log.Warnf("Invalid download size was given: %s\n", imageSize)
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Status: "fail",
+			Data: fiber.Map{
+				"size": "Size is not valid. Must be one of (web, original)",
+			},
+		})
+	}
+
+	galleryQueries := queries.NewGalleryRepository()
+
+	gallery, err := galleryQueries.GetGalleryByPublicID(galleryPublicID)
+	if err != nil || gallery == nil {
+		log.Warnf("No gallery with the given ID: %v\n", err)
+		return fiber.NewError(fiber.StatusNotFound, "No gallery with the given ID")
+	}
+
+	if err := checkGalleryAccess(c, gallery, auth.GalleryResource(gallery.ID)); err != nil {
+		return err
+	}
+
+	// A set is downloaded as a part of the gallery
+	download, status, failData := checkDownloadPolicy(c, gallery, models.DownloadGallery, imageSize)
+	if failData != nil {
+		return c.Status(status).JSON(models.APIResponse{
+			Status: "fail",
+			Data:   failData,
+		})
+	}
+
+	setQueries := queries.NewGallerySetRepository()
+
+	set, err := setQueries.GetGallerySetByID(gallery.ID, setID)
+	if err != nil || set == nil {
+		log.Warnf("No set with the given ID: %v\n", err)
+		return fiber.NewError(fiber.StatusNotFound, "No set with the given ID")
+	}
+
+	setImages, err := setQueries.GetGallerySetImages(set)
+	if err != nil {
+		log.Errorf("Unable to retrieve set images: %v\n", err)
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	setImages = visibleImages(setImages)
+
+	if len(setImages) == 0 {
+		return fiber.NewError(fiber.StatusNotFound, "No images in the set.")
+	}
+
+	// Add the set images to the root of the zip
+	var entries []images.ZipEntry
+	for _, img := range setImages {
+		entries = append(entries, images.ZipEntry{Filename: img.Filename, Name: img.DownloadName()})
+	}
+
+	zipBytes, err := images.GenerateZipOnDemand(gallery.ID, string(imageSize), entries)
+	if err != nil {
+		log.Errorf("Unable to generate zip for set: %v\n", err)
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	setSlug := strings.ToLower(strings.Join(strings.Fields(setFolderName(*set)), "-"))
+
+	c.Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", gallery.Path+"-"+setSlug+".zip"))
+	c.Set("Content-Type", "application/zip")
+	c.Set("Content-Length", fmt.Sprintf("%d", len(zipBytes)))
+
+	recordDownload(download)
+	fireDownload(c, models.WebhookDownloadSet, gallery, imageSize, download, fiber.Map{
+		"set_id":      set.ID,
+		"set_title":   set.Title,
+		"image_count": len(entries),
+	})
+
+	// Downloads that are checked or sent to webhooks on each request can't
+	// be cached
+	if !cacheDownload(gallery, models.WebhookDownloadSet) {
+		return c.Send(zipBytes)
+	}
+
+	// Set cache time so we don't repeat processing on each request
+	setGalleryCacheTime(c, gallery)
+
+	// Return success and the zip of the set
+	return c.Send(zipBytes)
+}
+
+// visibleImages filters out the images that are hidden from clients
+func visibleImages(galleryImages []models.Image) []models.Image {
+	visible := make([]models.Image, 0, len(galleryImages))
+	for _, img := range galleryImages {
+		if !img.Hidden {
+			visible = append(visible, img)
+		}
+	}
+
+	return visible
+}
+
+// @Description  Get the recorded downloads of the gallery. Downloads are recorded
+// @Description  when the gallery requires an email or has a download limit.
+// @Summary      get the downloads of visitors to the gallery
+// @Tags         Download
+// @Produce      json
+// @Param        galleryID      path       string  true  "Gallery ID"
+// @Security     ApiKeyAuth
+// @Success      200        {object}  []models.GalleryDownload
+// @Router       /v1/galleries/id/{galleryID}/downloads [get]
+func GetGalleryDownloads(c *fiber.Ctx) error {
+	_, _, err := auth.IsAuthenticated(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+
+	// Read the param galleryID
+	galleryID := c.Params("galleryID")
+
+	galleryQueries := queries.NewGalleryRepository()
+
+	gallery, err := galleryQueries.GetGalleryByID(galleryID)
+	if err != nil || gallery == nil {
+		log.Debugf("No gallery with ID %s in DB\n", galleryID)
+		return fiber.NewError(fiber.StatusNotFound, "No gallery with the given ID")
+	}
+
+	downloadQueries := queries.NewDownloadRepository()
+
+	downloads, err := downloadQueries.GetGalleryDownloads(gallery.ID)
+	if err != nil {
+		log.Errorf("Unable to retrieve gallery downloads from DB: %v\n", err)
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	// Return success and the downloads
+	return c.JSON(models.APIResponse{
+		Status: "success",
+		Data:   downloads,
+	})
+}
+
+const (
+	// Wrong download PINs a visitor can enter for a gallery within the window
+	defaultPinAttempts = 5
+	// Wrong PINs allowed for a gallery from every visitor together, as a
+	// multiple of the attempts of a single visitor
+	galleryPinAttemptsFactor = 10
+	pinAttemptWindow         = 15 * time.Minute
+)
+
+// validateDownloadPolicy checks the sizes, PIN and limit of the policy
+func validateDownloadPolicy(update *models.DownloadPolicyUpdate) fiber.Map {
+	failData := fiber.Map{}
+
+	if update.Sizes != nil && !models.ValidDownloadSizes(*update.Sizes) {
+		failData["sizes"] = "Sizes must be one of (disabled, web, full)."
+	}
+	if update.Pin != nil && len(*update.Pin) > models.MaxDownloadPinLength {
+		failData["pin"] = fmt.Sprintf("PIN must be at most %d characters.", models.MaxDownloadPinLength)
+	}
+	if update.Limit != nil && *update.Limit < 0 {
+		failData["limit"] = "Limit must be 0 (unlimited) or more."
+	}
+
+	if len(failData) == 0 {
+		return nil
+	}
+
+	return failData
+}
+
+// checkDownloadPolicy enforces the download policy of the gallery. When the
+// download isn't allowed, the status and fail data for the response are
+// returned. When the visitor's downloads are tracked, the download to record
+// once it succeeds is returned.
+func checkDownloadPolicy(c *fiber.Ctx, gallery *models.Gallery, kind models.DownloadKind, size models.ImageSize) (*models.GalleryDownload, int, fiber.Map) {
+	policy := gallery.DownloadPolicy
+
+	if policy.Sizes == models.DownloadsDisabled {
+		return nil, fiber.StatusForbidden, fiber.Map{
+			"download": "Downloads are disabled for this gallery.",
+		}
+	}
+
+	if !policy.AllowsSize(size) {
+		return nil, fiber.StatusForbidden, fiber.Map{
+			"size": "Only web sized downloads are allowed for this gallery.",
+		}
+	}
+
+	if !policy.AllowsKind(kind) {
+		return nil, fiber.StatusForbidden, fiber.Map{
+			"download": fmt.Sprintf("Downloads of type '%s' are not allowed for this gallery.", kind),
+		}
+	}
+
+	// The PIN and email are sent in headers so they never end up in the
+	// request logs
+	if policy.Pin != nil {
+		if pinAttemptsBlocked(gallery.ID, c.IP()) {
+			return nil, fiber.StatusTooManyRequests, fiber.Map{
+				"pin": "Too many wrong PINs, try again later.",
+			}
+		}
+
+		pin := c.Get(models.DownloadPinHeader)
+		if pin == "" || policy.CheckPin(pin) != nil {
+			if pin != "" {
+				recordPinAttempt(gallery.ID, c.IP())
+			}
+			return nil, fiber.StatusUnauthorized, fiber.Map{
+				"pin": "A valid download PIN is required.",
+			}
+		}
+	}
+
+	var email *string
+	if policy.EmailRequired {
+		address := strings.ToLower(strings.TrimSpace(c.Get(models.DownloadEmailHeader)))
+		parsed, err := mail.ParseAddress(address)
+		if err != nil || parsed.Address != address {
+			return nil, fiber.StatusBadRequest, fiber.Map{
+				"email": "A valid email is required to download from this gallery.",
+			}
+		}
+		email = &address
+	}
+
+	if !policy.Tracked() {
+		return nil, 0, nil
+	}
+
+	download := &models.GalleryDownload{
+		GalleryID: gallery.ID,
+		IP:        c.IP(),
+		Email:     email,
+		Kind:      kind,
+		Size:      size,
+	}
+
+	if policy.Limit > 0 {
+		downloadQueries := queries.NewDownloadRepository()
+
+		count, err := downloadQueries.CountVisitorDownloads(gallery.ID, download.IP, email)
+		if err != nil {
+			log.Errorf("Unable to count the downloads of the visitor: %v\n", err)
+			return nil, fiber.StatusInternalServerError, fiber.Map{
+				"download": "Unable to check the download limit.",
+			}
+		}
+
+		if count >= int64(policy.Limit) {
+			return nil, fiber.StatusTooManyRequests, fiber.Map{
+				"limit": fmt.Sprintf("The limit of %d downloads for this gallery has been reached.", policy.Limit),
+			}
+		}
+	}
+
+	return download, 0, nil
+}
+
+// pinAttemptKeys returns the keys counting the wrong PINs of the visitor
+// and of everyone for the gallery
+func pinAttemptKeys(galleryID uint, ip string) (string, string) {
+	return fmt.Sprintf("download-pin:%d:%s", galleryID, ip), fmt.Sprintf("download-pin:%d", galleryID)
+}
+
+// pinAttempts returns the number of wrong PINs counted for the key
+func pinAttempts(key string) int {
+	value, err := cachestore.Get().Get(key)
+	if err != nil {
+		log.Errorf("Unable to get the download PIN attempts: %v\n", err)
+		return 0
+	}
+
+	attempts, _ := strconv.Atoi(string(value))
+	return attempts
+}
+
+// pinAttemptsBlocked checks if the visitor, or everyone, guessed too many
+// wrong PINs for the gallery within the attempt window
+func pinAttemptsBlocked(galleryID uint, ip string) bool {
+	maxAttempts := configs.GetenvInt("DOWNLOAD_PIN_ATTEMPTS", defaultPinAttempts)
+	visitorKey, galleryKey := pinAttemptKeys(galleryID, ip)
+
+	return pinAttempts(visitorKey) >= maxAttempts ||
+		pinAttempts(galleryKey) >= maxAttempts*galleryPinAttemptsFactor
+}
+
+// recordPinAttempt counts a wrong PIN for the visitor and the gallery
+func recordPinAttempt(galleryID uint, ip string) {
+	visitorKey, galleryKey := pinAttemptKeys(galleryID, ip)
+
+	for _, key := range []string{visitorKey, galleryKey} {
+		attempts := pinAttempts(key) + 1
+		if err := cachestore.Get().Set(key, []byte(strconv.Itoa(attempts)), pinAttemptWindow); err != nil {
+			log.Errorf("Unable to save the download PIN attempts: %v\n", err)
+		}
+	}
+}
+
+// recordDownload saves the download of a visitor whose downloads are tracked
+func recordDownload(download *models.GalleryDownload) {
+	if download == nil {
+		return
+	}
+
+	downloadQueries := queries.NewDownloadRepository()
+	if err := downloadQueries.CreateGalleryDownload(download); err != nil {
+		log.Errorf("Unable to record the download: %v\n", err)
+	}
+}
+
+// cacheDownload checks if the download can be cached. A cached download is
+// served without reaching the handler, so it would never be recorded or
+// sent to the webhooks subscribed to it.
+func cacheDownload(gallery *models.Gallery, event models.WebhookEvent) bool {
+	return gallery.CacheDownloads() && !webhook.Subscribed(event)
+}
+
+// fireDownload sends the download to the webhooks with the gallery, size
+// and visitor added to the data
+func fireDownload(c *fiber.Ctx, event models.WebhookEvent, gallery *models.Gallery, size models.ImageSize, download *models.GalleryDownload, data fiber.Map) {
+	data["gallery_id"] = gallery.ID
+	data["gallery_title"] = gallery.Title
+	data["size"] = size
+	data["ip"] = c.IP()
+	if download != nil && download.Email != nil {
+		data["email"] = *download.Email
+	}
+
+	webhook.Fire(event, data)
+}
@@ -1,22 +1,28 @@
 package controllers
 
 import (
+	"errors"
 	"fmt"
-	"image"
 	"image/png"
 	"os"
+	"path/filepath"
 
 	"github.com/austinbspencer/gshare-server/internal/models"
 	"github.com/austinbspencer/gshare-server/internal/queries"
 	"github.com/austinbspencer/gshare-server/pkg/auth"
+	"github.com/austinbspencer/gshare-server/pkg/cachestore"
 	"github.com/austinbspencer/gshare-server/pkg/images"
 	"github.com/austinbspencer/gshare-server/pkg/utils"
+	"github.com/austinbspencer/gshare-server/platform/webhook"
+	"github.com/austinbspencer/gshare-server/runner"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/log"
 	"github.com/skip2/go-qrcode"
 )
 
-// @Description  Create a new gallery.
+// @Description  Create a new gallery. The gallery starts with the settings of
+// @Description  the preset given by preset_id, or the default preset when there
+// @Description  is one, and the settings in the body take precedence.
 // @Summary      create a new gallery
 // @Tags         Gallery
 // @Accept       json
@@ -31,7 +37,47 @@ func CreateGallery(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
 	}
 
-	gallery := new(models.Gallery)
+	options := new(models.GalleryCreateOptions)
+
+	if err := c.BodyParser(options); err != nil {
+		log.Errorf("Unable to parse new gallery options: %v\n", err)
+		// Return status 400 and error message.
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Status: "fail",
+			Data: fiber.Map{
+				"issue": err.Error(),
+			},
+		})
+	}
+
+	presetQueries := queries.NewGalleryPresetRepository()
+
+	var preset *models.GalleryPreset
+	if options.PresetID != nil {
+		preset, err = presetQueries.GetGalleryPresetByID(fmt.Sprint(*options.PresetID))
+		if err != nil || preset == nil {
+			return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+				Status: "fail",
+				Data: fiber.Map{
+					"preset_id": "No preset with the given ID.",
+				},
+			})
+		}
+	} else {
+		preset, err = presetQueries.GetDefaultGalleryPreset()
+		if err != nil {
+			log.Errorf("Error retrieving the default preset from DB: %v\n", err)
+		}
+	}
+
+	// Without a preset, galleries allow every download unless the policy
+	// is given
+	if preset == nil {
+		defaultPreset := models.DefaultGalleryPreset()
+		preset = &defaultPreset
+	}
+
+	gallery := preset.NewGallery()
 
 	// Store the body in the gallery and return error if encountered
 	if err := c.BodyParser(gallery); err != nil {
@@ -45,6 +91,41 @@ func CreateGallery(c *fiber.Ctx) error {
 		})
 	}
 
+	// The preset gives the expiration relative to the live date
+	if gallery.Expiration.IsZero() && preset.ExpirationDays > 0 {
+		gallery.Expiration = gallery.Live.AddDate(0, 0, preset.ExpirationDays)
+	}
+
+	if gallery.ReminderDays != "" {
+		if failData := validateReminderDays(&gallery.ReminderDays); failData != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+				Status: "fail",
+				Data:   failData,
+			})
+		}
+	}
+
+	var contactEmails []string
+	if gallery.ReminderEmails != nil {
+		var failData fiber.Map
+		if contactEmails, failData = validateContactEmails(*gallery.ReminderEmails); failData != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+				Status: "fail",
+				Data:   failData,
+			})
+		}
+	}
+
+	if failData := validateDownloadPolicy(&models.DownloadPolicyUpdate{
+		Sizes: &gallery.DownloadPolicy.Sizes,
+		Limit: &gallery.DownloadPolicy.Limit,
+	}); failData != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Status: "fail",
+			Data:   failData,
+		})
+	}
+
 	galleryQueries := queries.NewGalleryRepository()
 
 	if err := galleryQueries.CreateNewGallery(gallery); err != nil {
@@ -53,6 +134,13 @@ func CreateGallery(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
 	}
 
+	if len(contactEmails) > 0 {
+		if err := setGalleryContactEmails(gallery, contactEmails); err != nil {
+			log.Errorf("Unable to add the contacts of the new gallery in DB: %v\n", err)
+			return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		}
+	}
+
 	if gallery.IsLive() {
 		// Set update as true if the gallery was updated while live
 		settingsQueries := queries.NewSettingsRepository()
@@ -61,6 +149,8 @@ func CreateGallery(c *fiber.Ctx) error {
 		}
 	}
 
+	webhook.Fire(models.WebhookGalleryCreated, models.NewWebhookGallery(gallery))
+
 	// Return the created gallery
 	return c.JSON(models.APIResponse{
 		Status: "success",
@@ -68,6 +158,113 @@ func CreateGallery(c *fiber.Ctx) error {
 	})
 }
 
+// @Description  Clone a gallery into a new gallery with its settings. The
+// @Description  images, featured image and sets are copied when asked, and the
+// @Description  image files are shared with the original rather than stored
+// @Description  again.
+// @Summary      clone a gallery
+// @Tags         Gallery
+// @Accept       json
+// @Produce      json
+// @Param        galleryID   path       string  true  "Gallery ID"
+// @Param        payload   body       models.GalleryClone  true  "Clone options"
+// @Security     ApiKeyAuth
+// @Success      201        {object}  models.Gallery
+// @Router       /v1/galleries/id/{galleryID}/clone [post]
+func CloneGallery(c *fiber.Ctx) error {
+	_, _, err := auth.IsAuthenticated(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+
+	// Read the param galleryID
+	galleryID := c.Params("galleryID")
+
+	galleryQueries := queries.NewGalleryRepository()
+
+	gallery, err := galleryQueries.GetGalleryByID(galleryID)
+	if err != nil || gallery == nil {
+		log.Debugf("No gallery with ID %s in DB\n", galleryID)
+		return fiber.NewError(fiber.StatusNotFound, "No gallery with the given ID")
+	}
+
+	options := new(models.GalleryClone)
+
+	if err := c.BodyParser(options); err != nil || options.Title == "" || options.Path == "" {
+		// Return status 400 and error message.
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Status: "fail",
+			Data: fiber.Map{
+				"gallery": "The title and path of the new gallery are expected in the payload.",
+			},
+		})
+	}
+
+	clone := gallery.Clone(*options)
+
+	// Give each copied image a new filename, the files are shared once the
+	// clone is created
+	filenames := map[uint]string{}
+	if options.Images {
+		for _, image := range gallery.Images {
+			filenames[image.ID] = utils.GenerateRandomState(&images.FilenameLength) + filepath.Ext(image.Filename)
+		}
+	}
+
+	if err := galleryQueries.CloneGallery(gallery, clone, options.Sets || options.Images, filenames); err != nil {
+		if errors.Is(err, queries.ErrGalleryConflict) {
+			return c.Status(fiber.StatusConflict).JSON(models.APIResponse{
+				Status: "fail",
+				Data: fiber.Map{
+					"gallery": "Another gallery is using the title or path.",
+				},
+			})
+		}
+		log.Errorf("Unable to clone gallery in DB: %v\n", err)
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	if err := images.CreateGalleryDirectory(clone.ID); err != nil {
+		log.Errorf("Unable to create directory for cloned gallery %d: %v\n", clone.ID, err)
+	}
+
+	var linked []string
+	for _, image := range gallery.Images {
+		filename, ok := filenames[image.ID]
+		if !ok {
+			continue
+		}
+
+		if err := images.LinkImage(gallery.ID, clone.ID, image.Filename, filename); err != nil {
+			log.Errorf("Unable to share image %d with cloned gallery %d: %v\n", image.ID, clone.ID, err)
+			removeImageFiles(clone.ID, linked)
+			if err := galleryQueries.DeleteGallery(clone); err != nil {
+				log.Errorf("Unable to remove cloned gallery %d: %v\n", clone.ID, err)
+			}
+			return fiber.NewError(fiber.StatusInternalServerError, "Error sharing the image files.")
+		}
+		linked = append(linked, filename)
+	}
+
+	if clone.IsLive() {
+		// Set update as true if the gallery was updated while live
+		settingsQueries := queries.NewSettingsRepository()
+		if err := settingsQueries.SetSettingsUpdate(true); err != nil {
+			log.Errorf("Error setting settings update to true: %v\n", err)
+		}
+	}
+
+	signGalleryPreviewURLs(clone)
+
+	webhook.Fire(models.WebhookGalleryCreated, models.NewWebhookGallery(clone))
+
+	// Return the cloned gallery
+	return c.Status(fiber.StatusCreated).JSON(models.APIResponse{
+		Status: "success",
+		Data:   clone,
+	})
+}
+
 // @Description  Get all galleries.
 // @Summary      get all galleries that exist
 // @Tags         Gallery
@@ -97,6 +294,8 @@ func GetGalleries(c *fiber.Ctx) error {
 		} else {
 			galleries[idx].ImagesCount = count
 		}
+
+		signGalleryPreviewURLs(&galleries[idx])
 	}
 
 	// Return success and all galleries
@@ -140,6 +339,10 @@ func GetPublicGalleries(c *fiber.Ctx) error {
 				galleries[idx].FeaturedImage = *featImg
 			}
 		}
+
+		if galleries[idx].SignedURLs && galleries[idx].FeaturedImage.ID != 0 {
+			signImageURLs(&galleries[idx].FeaturedImage, "")
+		}
 	}
 
 	// Return success and all galleries
@@ -172,6 +375,10 @@ func GetLiveGalleries(c *fiber.Ctx) error {
 		} else {
 			galleries[idx].ImagesCount = count
 		}
+
+		if galleries[idx].SignedURLs && galleries[idx].FeaturedImage.ID != 0 {
+			signImageURLs(&galleries[idx].FeaturedImage, "")
+		}
 	}
 
 	// Return success and all galleries
@@ -206,6 +413,8 @@ func GetGallery(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusNotFound, "No gallery with the given ID")
 	}
 
+	signGalleryPreviewURLs(gallery)
+
 	// Return success and the individual gallery
 	return c.JSON(models.APIResponse{
 		Status: "success",
@@ -218,6 +427,7 @@ func GetGallery(c *fiber.Ctx) error {
 // @Tags         Gallery
 // @Produce      json
 // @Param        galleryPath   path       string  true  "Gallery Path"
+// @Param        grant         query      string  false "Access grant from unlocking the gallery"
 // @Success      200        {object}  models.Gallery
 // @Router       /v1/galleries/path/{galleryPath} [get]
 func GetGalleryByPath(c *fiber.Ctx) error {
@@ -232,6 +442,24 @@ func GetGalleryByPath(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusNotFound, "No live gallery with the given path")
 	}
 
+	// Signed URLs of protected galleries are bound to the grant given when
+	// the gallery was unlocked
+	grant := c.Query("grant")
+	if grant != "" {
+		if err := auth.VerifyGalleryGrant(grant, gallery.ID, galleryPasswordHash(gallery)); err != nil {
+			return c.Status(fiber.StatusUnauthorized).JSON(models.APIResponse{
+				Status: "fail",
+				Data: fiber.Map{
+					"grant": "The access grant is invalid or expired, unlock the gallery again.",
+				},
+			})
+		}
+	}
+
+	if !gallery.Protected || grant != "" {
+		signGalleryURLs(gallery, grant)
+	}
+
 	// Return success and the individual gallery
 	return c.JSON(models.APIResponse{
 		Status: "success",
@@ -243,21 +471,25 @@ func GetGalleryByPath(c *fiber.Ctx) error {
 // @Summary      create a QR Code for the gallery
 // @Tags         Gallery
 // @Produce      json
-// @Param        galleryID   path       string  true  "Gallery ID"
+// @Param        galleryPublicID   path       string  true  "Gallery Public ID"
 // @Success      200        {object}  models.Gallery
-// @Router       /v1/galleries/id/{galleryID}/qr-code [get]
+// @Router       /v1/galleries/public-id/{galleryPublicID}/qr-code [get]
 func GetGalleryQRCode(c *fiber.Ctx) error {
-	// Read the param galleryID
-	galleryID := c.Params("galleryID")
+	// Read the param galleryPublicID
+	galleryPublicID := c.Params("galleryPublicID")
 
 	galleryQueries := queries.NewGalleryRepository()
 
-	gallery, err := galleryQueries.GetGalleryByID(galleryID)
+	gallery, err := galleryQueries.GetGalleryByPublicID(galleryPublicID)
 	if err != nil || gallery == nil {
-		log.Debugf("No gallery with ID %s in DB\n", galleryID)
+		log.Debugf("No gallery with public ID %s in DB\n", galleryPublicID)
 		return fiber.NewError(fiber.StatusNotFound, "No gallery with the given ID")
 	}
 
+	if err := checkGalleryAccess(c, gallery, auth.GalleryResource(gallery.ID)); err != nil {
+		return err
+	}
+
 	// Generate the QR code
 	q, err := qrcode.New(fmt.Sprintf("%s/%s", os.Getenv("NEXT_PUBLIC_CLIENT_URL"), gallery.Path), qrcode.Medium)
 	if err != nil {
@@ -284,7 +516,7 @@ func GetGalleryQRCode(c *fiber.Ctx) error {
 // @Accept       json
 // @Produce      json
 // @Param        galleryPath   path       string  true  "Gallery Path"
-// @Success      201
+// @Success      200        {object}  models.GalleryGrant
 // @Router       /v1/galleries/path/{galleryPath} [post]
 func UnlockGallery(c *fiber.Ctx) error {
 	// Read the param galleryPath
@@ -323,9 +555,16 @@ func UnlockGallery(c *fiber.Ctx) error {
 		})
 	}
 
-	// Return success
+	// Grant access to the signed URLs of the gallery
+	grant, expires := auth.GenerateGalleryGrant(gallery.ID, galleryPasswordHash(gallery))
+
+	// Return success and the access grant
 	return c.JSON(models.APIResponse{
 		Status: "success",
+		Data: models.GalleryGrant{
+			Grant:   grant,
+			Expires: expires,
+		},
 	})
 }
 
@@ -355,8 +594,8 @@ func CreateGalleryImageZips(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusNotFound, "No gallery with the given ID")
 	}
 
-	// Generate the zips
-	if err := images.GenerateGalleryZips(gallery.ID); err != nil {
+	// Generate the zips with a folder for each set of the gallery
+	if err := images.GenerateGalleryZips(gallery.ID, galleryZipEntries(gallery.Sets, gallery.Images)); err != nil {
 		log.Errorf("Error generating zips for gallery: %v\n", err)
 		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
 	}
@@ -372,17 +611,15 @@ func CreateGalleryImageZips(c *fiber.Ctx) error {
 	})
 }
 
-// @Description  Upload a new Image.
-// @Summary      upload a new Image to the gallery
+// @Description  Rebuild the derivatives of the gallery images from their originals.
+// @Summary      start a job to reprocess the gallery images
 // @Tags         Gallery
-// @Accept       json
 // @Produce      json
 // @Param        galleryID   path       string  true  "Gallery ID"
-// @Param        src        formData	file	true  "Image file to upload"
 // @Security     ApiKeyAuth
-// @Success      201        {object}  models.Image
-// @Router       /v1/galleries/id/{galleryID}/images [post]
-func UploadGalleryImage(c *fiber.Ctx) error {
+// @Success      202        {object}  models.ReprocessJob
+// @Router       /v1/galleries/id/{galleryID}/images/reprocess [post]
+func ReprocessGalleryImages(c *fiber.Ctx) error {
 	_, _, err := auth.IsAuthenticated(c)
 	if err != nil {
 		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
@@ -394,83 +631,81 @@ func UploadGalleryImage(c *fiber.Ctx) error {
 	galleryQueries := queries.NewGalleryRepository()
 
 	gallery, err := galleryQueries.GetGalleryByID(galleryID)
-	if err != nil {
+	if err != nil || gallery == nil {
 		log.Debugf("No gallery with ID %s in DB\n", galleryID)
 		return fiber.NewError(fiber.StatusNotFound, "No gallery with the given ID")
 	}
 
-	file, err := c.FormFile("src")
+	job, err := runner.StartReprocessJob(&gallery.ID)
 	if err != nil {
-		log.Errorf("Unable to get image from request to upload: %v\n", err)
-		c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
-			Status: "fail",
-			Data: fiber.Map{
-				"src": "The image file must be at 'src'",
-			},
-		})
+		log.Errorf("Unable to start reprocessing job for gallery: %v\n", err)
+		if errors.Is(err, runner.ErrReprocessRunning) {
+			return fiber.NewError(fiber.StatusConflict, err.Error())
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
 	}
 
-	// Get Buffer from file
-	buffer, err := file.Open()
+	// Return the started job
+	return c.Status(fiber.StatusAccepted).JSON(models.APIResponse{
+		Status: "success",
+		Data:   job,
+	})
+}
+
+// @Description  Upload a new Image.
+// @Summary      upload a new Image to the gallery
+// @Tags         Gallery
+// @Accept       json
+// @Produce      json
+// @Param        galleryID   path       string  true  "Gallery ID"
+// @Param        src        formData	file	true  "Image file to upload"
+// @Security     ApiKeyAuth
+// @Success      201        {object}  models.Image
+// @Router       /v1/galleries/id/{galleryID}/images [post]
+func UploadGalleryImage(c *fiber.Ctx) error {
+	_, _, err := auth.IsAuthenticated(c)
 	if err != nil {
-		log.Errorf("Unable to open image from request to upload: %v\n", err)
-		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
-			Status: "fail",
-			Data: fiber.Map{
-				"src": "Unable to open file.",
-			},
-		})
+		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
 	}
-	defer buffer.Close()
 
-	// Retrieve image dimensions (width and height)
-	img, _, err := image.DecodeConfig(buffer)
+	// Read the param galleryID
+	galleryID := c.Params("galleryID")
+
+	galleryQueries := queries.NewGalleryRepository()
+
+	gallery, err := galleryQueries.GetGalleryByID(galleryID)
 	if err != nil {
-		log.Errorf("Unable to get image dimensions from request to upload: %v\n", err)
-		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
-			Status: "fail",
-			Data: fiber.Map{
-				"src": "Failed to retrieve image dimensions.",
-			},
-		})
+		log.Debugf("No gallery with ID %s in DB\n", galleryID)
+		return fiber.NewError(fiber.StatusNotFound, "No gallery with the given ID")
 	}
 
-	validMimeTypes := []string{"image/jpeg", "image/png"}
-	contentType, err := utils.GetFileType(file)
-	if err != nil || !utils.Contains(validMimeTypes, contentType) {
-		log.Errorf("Invalid image filetype (%s) for upload: %v\n", contentType, err)
+	upload, failData := parseImageUpload(c)
+	if failData != nil {
+		// Return status 400 and error message.
 		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
 			Status: "fail",
-			Data: fiber.Map{
-				"src": "Invalid file type; File must be of type: jpeg, jpg or png",
-			},
+			Data:   failData,
 		})
 	}
-
-	filename := utils.GenerateRandomState(&images.FilenameLength)
-
-	if contentType == "image/jpeg" {
-		filename += ".jpg"
-	} else {
-		filename += ".png"
-	}
-
-	// Reset the buffer to the beginning
-	buffer.Seek(0, 0)
+	defer upload.file.Close()
 
 	// Upload the image to disk
-	if err := images.UploadGalleryImage(gallery.ID, contentType, filename, buffer); err != nil {
+	if err := images.UploadGalleryImage(gallery.ID, upload.contentType, upload.filename, upload.file); err != nil {
 		log.Errorf("Unable to upload image to gallery: %v\n", err)
+		if processErr := processingError(c, err); processErr != nil {
+			return processErr
+		}
 		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
 	}
 
 	// Create the image in the DB
 	galleryImage := models.Image{
-		GalleryID: gallery.ID,
-		Size:      file.Size,
-		Filename:  filename,
-		Width:     img.Width,
-		Height:    img.Height,
+		GalleryID:        gallery.ID,
+		Size:             upload.header.Size,
+		Filename:         upload.filename,
+		OriginalFilename: upload.originalFilename,
+		Width:            upload.config.Width,
+		Height:           upload.config.Height,
 	}
 
 	imageQueries := queries.NewImageRepository()
@@ -495,6 +730,8 @@ func UploadGalleryImage(c *fiber.Ctx) error {
 		}
 	}
 
+	webhook.Fire(models.WebhookImageUploaded, models.NewWebhookImage(&galleryImage))
+
 	// Return success
 	return c.Status(fiber.StatusCreated).JSON(models.APIResponse{
 		Status: "success",
@@ -543,6 +780,45 @@ func UpdateGallery(c *fiber.Ctx) error {
 
 	log.Debugf("Gallery update request body: %v\n", galleryUpdate)
 
+	// The images of an archived gallery were removed, so it can't come back
+	if gallery.ArchivedAt != nil && galleryUpdate.Expiration != nil && !galleryUpdate.Expiration.Equal(gallery.Expiration) {
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Status: "fail",
+			Data: fiber.Map{
+				"expiration": "The expiration of an archived gallery can't be changed.",
+			},
+		})
+	}
+
+	if galleryUpdate.ReminderDays != nil {
+		if failData := validateReminderDays(galleryUpdate.ReminderDays); failData != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+				Status: "fail",
+				Data:   failData,
+			})
+		}
+	}
+
+	var contactEmails []string
+	if galleryUpdate.ReminderEmails != nil {
+		var failData fiber.Map
+		if contactEmails, failData = validateContactEmails(*galleryUpdate.ReminderEmails); failData != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+				Status: "fail",
+				Data:   failData,
+			})
+		}
+	}
+
+	if galleryUpdate.DownloadPolicy != nil {
+		if failData := validateDownloadPolicy(galleryUpdate.DownloadPolicy); failData != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+				Status: "fail",
+				Data:   failData,
+			})
+		}
+	}
+
 	// Handle featured image updates here
 	if galleryUpdate.FeaturedImageID != nil {
 		// Need to update featured image
@@ -586,11 +862,40 @@ func UpdateGallery(c *fiber.Ctx) error {
 		}
 	}
 
+	expiration := gallery.Expiration
+
 	if err := galleryQueries.UpdateGallery(gallery, *galleryUpdate); err != nil {
 		log.Errorf("Error updating the gallery in DB: %v\n", err)
 		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
 	}
 
+	if galleryUpdate.ReminderEmails != nil {
+		if err := setGalleryContactEmails(gallery, contactEmails); err != nil {
+			log.Errorf("Error updating the gallery contacts in DB: %v\n", err)
+			return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		}
+	}
+
+	// Reminders are sent again for the new expiration
+	if !gallery.Expiration.Equal(expiration) {
+		reminderQueries := queries.NewReminderRepository()
+		if err := reminderQueries.DeleteGalleryReminders(gallery.ID); err != nil {
+			log.Errorf("Error resetting the gallery reminders in DB: %v\n", err)
+		}
+	}
+
+	if galleryUpdate.DownloadPolicy != nil || galleryUpdate.SignedURLs != nil ||
+		galleryUpdate.Live != nil || galleryUpdate.Expiration != nil {
+		// Cached images and downloads were allowed by the previous settings
+		// and are cached until the previous expiration
+		var imageIDs []string
+		for _, image := range gallery.Images {
+			imageIDs = append(imageIDs, image.PublicID)
+		}
+		cachestore.InvalidateImages(imageIDs...)
+		cachestore.InvalidateGallery(gallery.PublicID)
+	}
+
 	if gallery.IsLive() {
 		// Set update as true if the gallery was updated while live
 		settingsQueries := queries.NewSettingsRepository()
@@ -599,6 +904,10 @@ func UpdateGallery(c *fiber.Ctx) error {
 		}
 	}
 
+	signGalleryPreviewURLs(gallery)
+
+	webhook.Fire(models.WebhookGalleryUpdated, models.NewWebhookGallery(gallery))
+
 	// Return the updated gallery
 	return c.JSON(models.APIResponse{
 		Status: "success",
@@ -606,12 +915,13 @@ func UpdateGallery(c *fiber.Ctx) error {
 	})
 }
 
-// @Description  Update gallery images order.
+// @Description  Update gallery images order. Either an array of image IDs, or the
+// @Description  order of the sets along with the images in each set.
 // @Summary      update gallery images order
 // @Tags         Gallery
 // @Produce      json
 // @Param        galleryID   path	string  	true  "Gallery ID"
-// @Param        images  	 body   []string 	true  "Image IDs"
+// @Param        images  	 body   models.GalleryImagesOrder 	true  "Image IDs or the sets order"
 // @Success      200  {string}  status  "ok"
 // @Security     ApiKeyAuth
 // @Router       /v1/galleries/id/{galleryID}/images [put]
@@ -635,13 +945,41 @@ func UpdateGalleryImagesOrder(c *fiber.Ctx) error {
 	var images []int
 
 	if err := c.BodyParser(&images); err != nil {
-		log.Errorf("Error parsing gallery update: %v\n", err)
-		// Return status 400 and error message.
-		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
-			Status: "fail",
-			Data: fiber.Map{
-				"images": "Array of image IDs is expected in the payload.",
-			},
+		// Not a flat order, so it should be the order of the sets
+		order := new(models.GalleryImagesOrder)
+
+		if err := c.BodyParser(order); err != nil {
+			log.Errorf("Error parsing gallery update: %v\n", err)
+			// Return status 400 and error message.
+			return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+				Status: "fail",
+				Data: fiber.Map{
+					"images": "Array of image IDs or the order of the sets is expected in the payload.",
+				},
+			})
+		}
+
+		setQueries := queries.NewGallerySetRepository()
+
+		if err := setQueries.SetGalleryImagesOrder(gallery.ID, *order); err != nil {
+			if errors.Is(err, queries.ErrUnknownGallerySet) {
+				return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+					Status: "fail",
+					Data: fiber.Map{
+						"sets": "Every set must belong to the gallery.",
+					},
+				})
+			}
+			log.Errorf("Error setting new sets order in DB: %v\n", err)
+			return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		}
+
+		// Images may have moved between the set folders of the zips
+		galleryImagesChanged(gallery, true)
+
+		// Return success
+		return c.JSON(models.APIResponse{
+			Status: "success",
 		})
 	}
 
@@ -655,13 +993,7 @@ func UpdateGalleryImagesOrder(c *fiber.Ctx) error {
 		}
 	}
 
-	if gallery.IsLive() {
-		// Set update as true if the gallery was updated while live
-		settingsQueries := queries.NewSettingsRepository()
-		if err := settingsQueries.SetSettingsUpdate(true); err != nil {
-			log.Errorf("Error setting settings update to true: %v\n", err)
-		}
-	}
+	galleryImagesChanged(gallery, false)
 
 	// Return success
 	return c.JSON(models.APIResponse{
@@ -669,7 +1001,7 @@ func UpdateGalleryImagesOrder(c *fiber.Ctx) error {
 	})
 }
 
-// @Description  Delete gallery by given ID.
+// @Description  Move the gallery with the given ID and its images to the trash.
 // @Summary      remove gallery by given ID
 // @Tags         Gallery
 // @Produce      json
@@ -694,16 +1026,18 @@ func DeleteGallery(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusNotFound, "No gallery with the given ID")
 	}
 
-	// Before deleting - delete the gallery images directory
-	err = os.RemoveAll(images.GetGalleryPath(gallery.ID))
-	if err != nil {
-		log.Warnf("Unable to remove images directory for gallery: %v\n", err)
+	// The images directory is kept until the gallery is purged from the trash
+	if err := galleryQueries.TrashGallery(gallery); err != nil {
+		log.Errorf("Unable to move gallery to the trash: %v\n", err)
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
 	}
 
-	if err := galleryQueries.DeleteGallery(gallery); err != nil {
-		log.Errorf("Unable to delete gallery from DB: %v\n", err)
-		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	var imageIDs []string
+	for _, image := range gallery.Images {
+		imageIDs = append(imageIDs, image.PublicID)
 	}
+	cachestore.InvalidateImages(imageIDs...)
+	cachestore.InvalidateGallery(gallery.PublicID)
 
 	if gallery.IsLive() {
 		// Set update as true if the gallery was updated while live
@@ -713,8 +1047,25 @@ func DeleteGallery(c *fiber.Ctx) error {
 		}
 	}
 
+	webhook.Fire(models.WebhookGalleryDeleted, models.NewWebhookGallery(gallery))
+
 	// Return success
 	return c.JSON(models.APIResponse{
 		Status: "success",
 	})
 }
+
+// validateReminderDays checks the days before expiration that reminders are
+// sent, the days are put in descending order without duplicates
+func validateReminderDays(days *string) fiber.Map {
+	parsed, err := models.ParseReminderDays(*days)
+	if err != nil {
+		return fiber.Map{
+			"reminder_days": fmt.Sprintf("Reminder days must be whole numbers between 1 and %d separated by spaces.", models.MaxReminderDays),
+		}
+	}
+
+	*days = models.FormatReminderDays(parsed)
+
+	return nil
+}
@@ -1,16 +1,21 @@
 package controllers
 
 import (
+	"errors"
 	"fmt"
+	"image"
+	"mime/multipart"
 	"os"
+	"path/filepath"
 	"strconv"
-	"time"
 
 	"github.com/austinbspencer/gshare-server/internal/models"
 	"github.com/austinbspencer/gshare-server/internal/queries"
 	"github.com/austinbspencer/gshare-server/pkg/auth"
+	"github.com/austinbspencer/gshare-server/pkg/cachestore"
 	"github.com/austinbspencer/gshare-server/pkg/images"
 	"github.com/austinbspencer/gshare-server/pkg/utils"
+	"github.com/austinbspencer/gshare-server/platform/webhook"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/log"
 )
@@ -47,10 +52,13 @@ func GetImage(c *fiber.Ctx) error {
 	})
 }
 
-// @Description  Get all images.
-// @Summary      get all images that exist
+// @Description  Get all images, optionally filtered by a search.
+// @Summary      get all images that exist or match the search
 // @Tags         Image
 // @Produce      json
+// @Param        q            query     string    false  "Text to match in the title, caption, alt text or filename"
+// @Param        tag          query     []string  false  "Tags the images must have"
+// @Param        gallery_id   query     int       false  "Gallery ID"
 // @Security     ApiKeyAuth
 // @Success      200        {object}  []models.Image
 // @Router       /v1/images [get]
@@ -60,9 +68,22 @@ func GetImages(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
 	}
 
+	search := new(models.ImageSearch)
+
+	if err := c.QueryParser(search); err != nil {
+		log.Errorf("Error parsing image search: %v\n", err)
+		// Return status 400 and error message.
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Status: "fail",
+			Data: fiber.Map{
+				"search": "Invalid search parameters.",
+			},
+		})
+	}
+
 	imageQueries := queries.NewImageRepository()
 
-	images, err := imageQueries.GetImages()
+	images, err := imageQueries.SearchImages(*search)
 	if err != nil {
 		log.Errorf("Error retrieving images from DB: %v\n", err)
 		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
@@ -75,42 +96,531 @@ func GetImages(c *fiber.Ctx) error {
 	})
 }
 
+// @Description  Get all image tags.
+// @Summary      get all tags that have been added to images
+// @Tags         Image
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Success      200        {object}  []models.Tag
+// @Router       /v1/images/tags [get]
+func GetImageTags(c *fiber.Ctx) error {
+	_, _, err := auth.IsAuthenticated(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+
+	imageQueries := queries.NewImageRepository()
+
+	tags, err := imageQueries.GetTags()
+	if err != nil {
+		log.Errorf("Error retrieving tags from DB: %v\n", err)
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	// Return success and all tags
+	return c.JSON(models.APIResponse{
+		Status: "success",
+		Data:   tags,
+	})
+}
+
+// @Description  Update the title, caption, alt text, visibility and tags of the image.
+// @Summary      update the details of an image
+// @Tags         Image
+// @Accept       json
+// @Produce      json
+// @Param        imageID   path       string  true  "Image ID"
+// @Param        image     body       models.ImageUpdate  true  "Image details"
+// @Security     ApiKeyAuth
+// @Success      200        {object}  models.Image
+// @Router       /v1/images/{imageID} [put]
+func UpdateImage(c *fiber.Ctx) error {
+	_, _, err := auth.IsAuthenticated(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+
+	// Read the param imageID
+	imageID := c.Params("imageID")
+
+	imageQueries := queries.NewImageRepository()
+
+	image, err := imageQueries.GetImageByID(imageID)
+	if err != nil || image == nil {
+		log.Debugf("No image with ID %s in DB\n", imageID)
+		return fiber.NewError(fiber.StatusNotFound, "No image with the given ID")
+	}
+
+	update := new(models.ImageUpdate)
+
+	if err := c.BodyParser(update); err != nil {
+		log.Errorf("Error parsing image update: %v\n", err)
+		// Return status 400 and error message.
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Status: "fail",
+			Data: fiber.Map{
+				"image": "Invalid image update payload.",
+			},
+		})
+	}
+
+	if failData := validateImageUpdate(update); failData != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Status: "fail",
+			Data:   failData,
+		})
+	}
+
+	if err := imageQueries.UpdateImages([]models.Image{*image}, *update); err != nil {
+		log.Errorf("Error updating image in DB: %v\n", err)
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	if update.Hidden != nil {
+		imagesVisibilityChanged([]models.Image{*image})
+	} else {
+		imagesUpdated(image.GalleryID)
+	}
+
+	// Retrieve the image with the updated tags
+	image, err = imageQueries.GetImageByID(imageID)
+	if err != nil {
+		log.Errorf("Error retrieving updated image from DB: %v\n", err)
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	// Return the updated image
+	return c.JSON(models.APIResponse{
+		Status: "success",
+		Data:   image,
+	})
+}
+
+// @Description  Apply the same title, caption, alt text, visibility and tag changes to several images.
+// @Summary      update the details of several images
+// @Tags         Image
+// @Accept       json
+// @Produce      json
+// @Param        images    body       models.ImagesUpdate  true  "Image IDs and details"
+// @Security     ApiKeyAuth
+// @Success      200        {object}  []models.Image
+// @Router       /v1/images [put]
+func UpdateImages(c *fiber.Ctx) error {
+	_, _, err := auth.IsAuthenticated(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+
+	update := new(models.ImagesUpdate)
+
+	if err := c.BodyParser(update); err != nil {
+		log.Errorf("Error parsing images update: %v\n", err)
+		// Return status 400 and error message.
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Status: "fail",
+			Data: fiber.Map{
+				"images": "Invalid images update payload.",
+			},
+		})
+	}
+
+	if len(update.IDs) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Status: "fail",
+			Data: fiber.Map{
+				"ids": "Array of image IDs is expected in the payload.",
+			},
+		})
+	}
+
+	if failData := validateImageUpdate(&update.ImageUpdate); failData != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Status: "fail",
+			Data:   failData,
+		})
+	}
+
+	imageQueries := queries.NewImageRepository()
+
+	images, err := imageQueries.GetSpecificImages(update.IDs)
+	if err != nil {
+		log.Errorf("Error retrieving images from DB: %v\n", err)
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	if len(images) == 0 {
+		return fiber.NewError(fiber.StatusNotFound, "No images with the given IDs")
+	}
+
+	if err := imageQueries.UpdateImages(images, update.ImageUpdate); err != nil {
+		log.Errorf("Error updating images in DB: %v\n", err)
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	if update.Hidden != nil {
+		imagesVisibilityChanged(images)
+	} else {
+		var galleryIDs []uint
+		for _, image := range images {
+			galleryIDs = append(galleryIDs, image.GalleryID)
+		}
+		imagesUpdated(galleryIDs...)
+	}
+
+	// Retrieve the images with the updated tags
+	images, err = imageQueries.SearchImages(models.ImageSearch{IDs: update.IDs})
+	if err != nil {
+		log.Errorf("Error retrieving updated images from DB: %v\n", err)
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	// Return the updated images
+	return c.JSON(models.APIResponse{
+		Status: "success",
+		Data:   images,
+	})
+}
+
+// @Description  Move images to another gallery along with their files and versions.
+// @Summary      move images to another gallery
+// @Tags         Image
+// @Accept       json
+// @Produce      json
+// @Param        payload   body       models.ImagesTransfer  true  "Image IDs and the gallery ID"
+// @Security     ApiKeyAuth
+// @Success      200        {object}  []models.Image
+// @Router       /v1/images/move [post]
+func MoveImages(c *fiber.Ctx) error {
+	_, _, err := auth.IsAuthenticated(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+
+	transfer, gallery, transferImages, err := parseImagesTransfer(c)
+	if err != nil || transfer == nil {
+		return err
+	}
+
+	imageQueries := queries.NewImageRepository()
+
+	// Move the files first so the rows never point at missing files
+	var moved []models.Image
+	for _, image := range transferImages {
+		versions, err := imageQueries.GetImageVersions(image.ID)
+		if err == nil {
+			err = images.MoveImage(image.GalleryID, gallery.ID, image.Filename, versionFilenames(versions))
+		}
+		if err != nil {
+			log.Errorf("Unable to move image %d to gallery %d: %v\n", image.ID, gallery.ID, err)
+			moveImagesBack(imageQueries, gallery.ID, moved)
+			return fiber.NewError(fiber.StatusInternalServerError, "Error moving the image files.")
+		}
+		moved = append(moved, image)
+	}
+
+	if err := imageQueries.MoveImages(transferImages, gallery.ID); err != nil {
+		log.Errorf("Error moving images in DB: %v\n", err)
+		moveImagesBack(imageQueries, gallery.ID, moved)
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	// Both galleries changed
+	galleryIDs := map[uint]bool{gallery.ID: true}
+	var imageIDs []uint
+	var publicIDs []string
+	for _, image := range transferImages {
+		galleryIDs[image.GalleryID] = true
+		imageIDs = append(imageIDs, image.ID)
+		publicIDs = append(publicIDs, image.PublicID)
+	}
+	cachestore.InvalidateImages(publicIDs...)
+	galleriesImagesChanged(galleryIDs)
+
+	// Return the moved images
+	movedImages, err := imageQueries.SearchImages(models.ImageSearch{IDs: imageIDs})
+	if err != nil {
+		log.Errorf("Error retrieving moved images from DB: %v\n", err)
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	return c.JSON(models.APIResponse{
+		Status: "success",
+		Data:   movedImages,
+	})
+}
+
+// @Description  Copy images to another gallery along with their details and tags.
+// @Summary      copy images to another gallery
+// @Tags         Image
+// @Accept       json
+// @Produce      json
+// @Param        payload   body       models.ImagesTransfer  true  "Image IDs and the gallery ID"
+// @Security     ApiKeyAuth
+// @Success      201        {object}  []models.Image
+// @Router       /v1/images/copy [post]
+func CopyImages(c *fiber.Ctx) error {
+	_, _, err := auth.IsAuthenticated(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+
+	transfer, gallery, transferImages, err := parseImagesTransfer(c)
+	if err != nil || transfer == nil {
+		return err
+	}
+
+	// Copy the files under new filenames
+	var filenames []string
+	for _, image := range transferImages {
+		filename := utils.GenerateRandomState(&images.FilenameLength) + filepath.Ext(image.Filename)
+
+		if err := images.CopyImage(image.GalleryID, gallery.ID, image.Filename, filename); err != nil {
+			log.Errorf("Unable to copy image %d to gallery %d: %v\n", image.ID, gallery.ID, err)
+			removeImageFiles(gallery.ID, filenames)
+			return fiber.NewError(fiber.StatusInternalServerError, "Error copying the image files.")
+		}
+		filenames = append(filenames, filename)
+	}
+
+	imageQueries := queries.NewImageRepository()
+
+	copies, err := imageQueries.CopyImages(transferImages, gallery.ID, filenames)
+	if err != nil {
+		log.Errorf("Error copying images in DB: %v\n", err)
+		removeImageFiles(gallery.ID, filenames)
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	galleriesImagesChanged(map[uint]bool{gallery.ID: true})
+
+	// Return the copied images
+	return c.Status(fiber.StatusCreated).JSON(models.APIResponse{
+		Status: "success",
+		Data:   copies,
+	})
+}
+
+// parseImagesTransfer reads the images and the gallery they are moving or
+// copying to. A nil transfer means the response was already sent.
+func parseImagesTransfer(c *fiber.Ctx) (*models.ImagesTransfer, *models.Gallery, []models.Image, error) {
+	transfer := new(models.ImagesTransfer)
+
+	if err := c.BodyParser(transfer); err != nil || len(transfer.IDs) == 0 || transfer.GalleryID == 0 {
+		// Return status 400 and error message.
+		return nil, nil, nil, c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Status: "fail",
+			Data: fiber.Map{
+				"images": "Array of image IDs and the gallery ID are expected in the payload.",
+			},
+		})
+	}
+
+	galleryQueries := queries.NewGalleryRepository()
+
+	gallery, err := galleryQueries.GetGalleryByID(fmt.Sprint(transfer.GalleryID))
+	if err != nil || gallery == nil {
+		log.Debugf("No gallery with ID %d in DB\n", transfer.GalleryID)
+		return nil, nil, nil, fiber.NewError(fiber.StatusNotFound, "No gallery with the given ID")
+	}
+
+	imageQueries := queries.NewImageRepository()
+
+	transferImages, err := imageQueries.SearchImages(models.ImageSearch{IDs: transfer.IDs})
+	if err != nil {
+		log.Errorf("Error retrieving images from DB: %v\n", err)
+		return nil, nil, nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	if len(transferImages) == 0 {
+		return nil, nil, nil, fiber.NewError(fiber.StatusNotFound, "No images with the given IDs")
+	}
+
+	for _, image := range transferImages {
+		if image.GalleryID == gallery.ID {
+			return nil, nil, nil, c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+				Status: "fail",
+				Data: fiber.Map{
+					"gallery_id": fmt.Sprintf("Image %d is already in the gallery.", image.ID),
+				},
+			})
+		}
+	}
+
+	return transfer, gallery, transferImages, nil
+}
+
+// moveImagesBack returns the files of the moved images to their galleries
+func moveImagesBack(imageQueries queries.ImageRepository, galleryID uint, moved []models.Image) {
+	for _, image := range moved {
+		versions, err := imageQueries.GetImageVersions(image.ID)
+		if err != nil {
+			log.Errorf("Error retrieving image versions from DB: %v\n", err)
+		}
+
+		if err := images.MoveImage(galleryID, image.GalleryID, image.Filename, versionFilenames(versions)); err != nil {
+			log.Errorf("Unable to move image %d back to gallery %d: %v\n", image.ID, image.GalleryID, err)
+		}
+	}
+}
+
+// removeImageFiles removes the copied files from the gallery
+func removeImageFiles(galleryID uint, filenames []string) {
+	for _, filename := range filenames {
+		if err := images.RemoveImage(galleryID, filename); err != nil {
+			log.Errorf("Unable to remove copied image %s: %v\n", filename, err)
+		}
+	}
+}
+
+// versionFilenames returns the filenames of the image versions
+func versionFilenames(versions []models.ImageVersion) []string {
+	filenames := make([]string, 0, len(versions))
+	for _, version := range versions {
+		filenames = append(filenames, version.Filename)
+	}
+
+	return filenames
+}
+
+// galleriesImagesChanged marks the zips of the galleries as stale after
+// their images changed
+func galleriesImagesChanged(galleryIDs map[uint]bool) {
+	galleryQueries := queries.NewGalleryRepository()
+
+	for galleryID := range galleryIDs {
+		gallery, err := galleryQueries.GetGalleryByID(fmt.Sprint(galleryID))
+		if err != nil || gallery == nil {
+			log.Warnf("Unable to retrieve gallery %d: %v\n", galleryID, err)
+			continue
+		}
+
+		galleryImagesChanged(gallery, true)
+	}
+}
+
+// imagesVisibilityChanged clears the cached responses of images that were
+// hidden or shown and marks the zips of their galleries as stale
+func imagesVisibilityChanged(changed []models.Image) {
+	galleryIDs := map[uint]bool{}
+	var imageIDs []string
+	for _, image := range changed {
+		galleryIDs[image.GalleryID] = true
+		imageIDs = append(imageIDs, image.PublicID)
+	}
+
+	cachestore.InvalidateImages(imageIDs...)
+	galleriesImagesChanged(galleryIDs)
+}
+
+// validateImageUpdate checks the lengths of the image details and tags
+func validateImageUpdate(update *models.ImageUpdate) fiber.Map {
+	failData := fiber.Map{}
+
+	if update.Title != nil && len(*update.Title) > models.MaxImageTitleLength {
+		failData["title"] = fmt.Sprintf("Title must be at most %d characters.", models.MaxImageTitleLength)
+	}
+	if update.Caption != nil && len(*update.Caption) > models.MaxImageCaptionLength {
+		failData["caption"] = fmt.Sprintf("Caption must be at most %d characters.", models.MaxImageCaptionLength)
+	}
+	if update.AltText != nil && len(*update.AltText) > models.MaxImageAltTextLength {
+		failData["alt_text"] = fmt.Sprintf("Alt text must be at most %d characters.", models.MaxImageAltTextLength)
+	}
+
+	tags := append(append([]string{}, update.AddTags...), update.RemoveTags...)
+	if update.Tags != nil {
+		tags = append(tags, *update.Tags...)
+	}
+	for _, tag := range tags {
+		if len(tag) > models.MaxImageTagLength {
+			failData["tags"] = fmt.Sprintf("Tags must be at most %d characters.", models.MaxImageTagLength)
+			break
+		}
+	}
+
+	if len(failData) > 0 {
+		return failData
+	}
+
+	return nil
+}
+
+// imagesUpdated flags the settings for an update when any of the galleries
+// are live so the client rebuilds the gallery pages
+func imagesUpdated(galleryIDs ...uint) {
+	galleryQueries := queries.NewGalleryRepository()
+
+	for _, galleryID := range galleryIDs {
+		gallery, err := galleryQueries.GetGalleryByID(fmt.Sprint(galleryID))
+		if err != nil || gallery == nil {
+			log.Warnf("Unable to retrieve gallery %d: %v\n", galleryID, err)
+			continue
+		}
+
+		if gallery.IsLive() {
+			// Set update as true if the gallery was updated while live
+			settingsQueries := queries.NewSettingsRepository()
+			if err := settingsQueries.SetSettingsUpdate(true); err != nil {
+				log.Errorf("Error setting settings update to true: %v\n", err)
+			}
+			return
+		}
+	}
+}
+
 // @Description  Get image with specified size.
 // @Summary      get an image with the desired width and quality; quality only works with
 // the image as jpeg
 // @Tags         Image
 // @Accept       json
 // @Produce      json
-// @Param        imageID   path       string  true  "Image ID"
+// @Param        imagePublicID   path       string  true  "Image Public ID"
 // @Param        width     path       int     true  "Image Width"
 // @Param        quality    path       int     true  "Image Quality"
 // @Success      200
-// @Router       /v1/images/{imageID}/{width}/{quality} [get]
+// @Router       /v1/images/{imagePublicID}/{width}/{quality} [get]
 func GetImageSized(c *fiber.Ctx) error {
-	// Read the param imageID
-	imageID := c.Params("imageID")
+	// Read the param imagePublicID
+	imagePublicID := c.Params("imagePublicID")
 	width := c.Params("width")
 	quality := c.Params("quality")
 
 	imageQueries := queries.NewImageRepository()
 
-	image, err := imageQueries.GetImageByID(imageID)
+	image, err := imageQueries.GetImageByPublicID(imagePublicID)
 	if err != nil || image == nil {
-		log.Debugf("No image with ID %s in DB\n", imageID)
+		log.Debugf("No image with public ID %s in DB\n", imagePublicID)
 		return fiber.NewError(fiber.StatusNotFound, "No image with the given ID")
 	}
 
+	// Hidden images are only served to admins
+	if image.Hidden && !isAdminPreview(c, auth.ImageResource(image.ID)) {
+		log.Debugf("Image %s is hidden from clients\n", imagePublicID)
+		return fiber.NewError(fiber.StatusNotFound, "No image with the given ID")
+	}
+
+	galleryQueries := queries.NewGalleryRepository()
+
+	gallery, err := galleryQueries.GetGalleryByID(fmt.Sprint(image.GalleryID))
+	if err != nil || gallery == nil {
+		log.Errorf("No gallery with the given ID: %v\n", err)
+		return fiber.NewError(fiber.StatusNotFound, "No gallery with the given ID")
+	}
+
+	if err := checkGalleryAccess(c, gallery, auth.ImageResource(image.ID)); err != nil {
+		return err
+	}
+
 	var widthInt uint64
 
 	if models.ValidImageSize(models.ImageSize(width)) == false {
 		// Convert params to int
 		widthInt, err = strconv.ParseUint(width, 10, 32)
-		if err != nil {
+		if err != nil || widthInt < 1 {
 			log.Errorf("Invalid width given (%s): %v\n", width, err)
 			return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
 				Status: "fail",
 				Data: fiber.Map{
-					"width": "Width must be a valid integer.",
+					"width": "Width must be a positive integer.",
 				},
 			})
 		}
@@ -155,43 +665,38 @@ func GetImageSized(c *fiber.Ctx) error {
 	contentType := utils.GetMimeTypeFromExtension(image.Filename)
 
 	// Resize the image
-	resizedImage, err := utils.ResizeImage(imageBytes, uint(widthInt), uint(qualityInt), contentType)
+	resizedImage, err := images.ResizeImage(imageBytes, uint(widthInt), uint(qualityInt), contentType)
 	if err != nil {
 		log.Errorf("Unable to resize image: %v\n", err)
+		if processErr := processingError(c, err); processErr != nil {
+			return processErr
+		}
 		return fiber.NewError(fiber.StatusInternalServerError, "Error encountered while resizing the image.")
 	}
 
 	// Set the headers for the file transfer and return the file
 	c.Set("Content-Description", "File Transfer")
 	c.Set("Content-Transfer-Encoding", "binary")
-	c.Set("Content-Disposition", fmt.Sprintf("inline; filename=%s", image.Filename))
+	c.Set("Content-Disposition", utils.ContentDisposition("inline", image.DownloadName()))
 	// No need to get mime type with data received since we set
 	// file extension with evaluated mimetype on upload
 	c.Set("Content-Type", contentType)
 	c.Set("Content-Length", fmt.Sprintf("%d", len(resizedImage)))
 
-	// Get the gallery to set the cache time
-	galleryQueries := queries.NewGalleryRepository()
-
-	gallery, err := galleryQueries.GetGalleryByID(fmt.Sprint(image.GalleryID))
-	if err != nil || gallery == nil {
-		log.Errorf("No gallery with the given ID: %v\n", err)
+	// Hidden images aren't cached so they are never served to clients, and
+	// signed images are checked on each request
+	if image.Hidden || gallery.SignedURLs {
 		return c.Send(resizedImage)
 	}
 
 	// Set cache time so we don't repeat processing on each request
 	// for the exact same image and dimensions
-	// Set the cache time to the expiration time of the gallery
-	duration := gallery.Expiration.Sub(time.Now())
-
-	log.Warnf("Setting cache expiration time for image to %s\n", time.Now().Add(duration))
-
-	c.Set("Cache-Time", fmt.Sprint(int(duration.Seconds())))
+	setGalleryCacheTime(c, gallery)
 
 	return c.Send(resizedImage)
 }
 
-// @Description  Delete image by given ID.
+// @Description  Move the image with the given ID to the trash.
 // @Summary      remove image by given ID
 // @Tags         Image
 // @Produce      json
@@ -216,22 +721,19 @@ func DeleteImage(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusNotFound, "No image with the given ID")
 	}
 
-	galleryID := fmt.Sprint(image.GalleryID)
-
-	// Remove the image from disk
-	if err := images.RemoveImage(image.GalleryID, image.Filename); err != nil {
-		log.Errorf("Error removing image from gallery directory: %v\n", err)
-		return fiber.NewError(fiber.StatusInternalServerError, "Error removing image from gallery directory.")
+	// The files are kept until the image is purged from the trash
+	if err := imageQueries.TrashImage(image); err != nil {
+		log.Errorf("Error moving image to the trash: %v\n", err)
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
 	}
 
-	if err := imageQueries.DeleteImage(image); err != nil {
-		log.Errorf("Error removing image from DB: %v\n", err)
-		return fiber.NewError(fiber.StatusInternalServerError, "Image removed but not removed from DB.")
-	}
+	cachestore.InvalidateImages(image.PublicID)
+
+	webhook.Fire(models.WebhookImageDeleted, models.NewWebhookImage(image))
 
 	galleryQueries := queries.NewGalleryRepository()
 
-	gallery, err := galleryQueries.GetGalleryByID(galleryID)
+	gallery, err := galleryQueries.GetGalleryByID(fmt.Sprint(image.GalleryID))
 	if err != nil || gallery == nil {
 		log.Warn("Image that was removed was not linked to a valid gallery.")
 		// Return success
@@ -241,21 +743,312 @@ func DeleteImage(c *fiber.Ctx) error {
 		})
 	}
 
-	if gallery.ZipsReady {
-		if err := galleryQueries.SetZipsReady(gallery, false); err != nil {
-			log.Errorf("Unable to update ZipsReady field in gallery: %v\n", err)
+	galleryImagesChanged(gallery, true)
+
+	// Return success
+	return c.JSON(models.APIResponse{
+		Status: "success",
+		Data:   "Image moved to the trash.",
+	})
+}
+
+// @Description  Replace the image file while keeping its ID, position and history.
+// @Summary      replace the image file and keep the prior original as a version
+// @Tags         Image
+// @Accept       json
+// @Produce      json
+// @Param        imageID    path       string  true  "Image ID"
+// @Param        src        formData	file	true  "Replacement image file"
+// @Security     ApiKeyAuth
+// @Success      200        {object}  models.Image
+// @Router       /v1/images/{imageID}/replace [post]
+func ReplaceImage(c *fiber.Ctx) error {
+	_, _, err := auth.IsAuthenticated(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+
+	// Read the param imageID
+	imageID := c.Params("imageID")
+
+	imageQueries := queries.NewImageRepository()
+
+	image, err := imageQueries.GetImageByID(imageID)
+	if err != nil || image == nil {
+		log.Debugf("No image with ID %s in DB\n", imageID)
+		return fiber.NewError(fiber.StatusNotFound, "No image with the given ID")
+	}
+
+	upload, failData := parseImageUpload(c)
+	if failData != nil {
+		// Return status 400 and error message.
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Status: "fail",
+			Data:   failData,
+		})
+	}
+	defer upload.file.Close()
+
+	// Upload the replacement to disk under a new filename
+	if err := images.UploadGalleryImage(image.GalleryID, upload.contentType, upload.filename, upload.file); err != nil {
+		log.Errorf("Unable to upload replacement image: %v\n", err)
+		if processErr := processingError(c, err); processErr != nil {
+			return processErr
 		}
-		log.Debug("Gallery ZipsReady is already set to false, skipping update query.")
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
 	}
 
-	settingsQueries := queries.NewSettingsRepository()
-	if err := settingsQueries.SetSettingsUpdate(true); err != nil {
-		log.Errorf("Error setting settings update to false: %v\n", err)
+	// Keep the current original as a version
+	if err := images.ArchiveImageVersion(image.GalleryID, image.Filename); err != nil {
+		log.Errorf("Unable to archive the current original: %v\n", err)
+		images.RemoveImage(image.GalleryID, upload.filename)
+		return fiber.NewError(fiber.StatusInternalServerError, "Error archiving the current image.")
 	}
 
-	// Return success
+	_, err = imageQueries.ReplaceImage(image, models.Image{
+		Filename:         upload.filename,
+		OriginalFilename: upload.originalFilename,
+		Size:             upload.header.Size,
+		Width:            upload.config.Width,
+		Height:           upload.config.Height,
+	})
+	if err != nil {
+		log.Errorf("Error replacing image in DB: %v\n", err)
+		// Put the files back the way they were
+		images.RemoveImage(image.GalleryID, upload.filename)
+		images.RestoreImageVersion(image.GalleryID, image.Filename)
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	imageFileChanged(image)
+
+	// Return the updated image
+	return c.JSON(models.APIResponse{
+		Status: "success",
+		Data:   image,
+	})
+}
+
+// @Description  Get the prior originals of the image.
+// @Summary      get all versions of an image
+// @Tags         Image
+// @Produce      json
+// @Param        imageID   path       string  true  "Image ID"
+// @Security     ApiKeyAuth
+// @Success      200        {object}  []models.ImageVersion
+// @Router       /v1/images/{imageID}/versions [get]
+func GetImageVersions(c *fiber.Ctx) error {
+	_, _, err := auth.IsAuthenticated(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+
+	// Read the param imageID
+	imageID := c.Params("imageID")
+
+	imageQueries := queries.NewImageRepository()
+
+	image, err := imageQueries.GetImageByID(imageID)
+	if err != nil || image == nil {
+		log.Debugf("No image with ID %s in DB\n", imageID)
+		return fiber.NewError(fiber.StatusNotFound, "No image with the given ID")
+	}
+
+	versions, err := imageQueries.GetImageVersions(image.ID)
+	if err != nil {
+		log.Errorf("Error retrieving image versions from DB: %v\n", err)
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	// Return success and all versions
+	return c.JSON(models.APIResponse{
+		Status: "success",
+		Data:   versions,
+	})
+}
+
+// @Description  Revert the image to one of its prior originals.
+// @Summary      revert an image to the given version
+// @Tags         Image
+// @Produce      json
+// @Param        imageID     path       string  true  "Image ID"
+// @Param        versionID   path       string  true  "Version ID"
+// @Security     ApiKeyAuth
+// @Success      200        {object}  models.Image
+// @Router       /v1/images/{imageID}/versions/{versionID}/revert [post]
+func RevertImageVersion(c *fiber.Ctx) error {
+	_, _, err := auth.IsAuthenticated(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+
+	// Read the params
+	imageID := c.Params("imageID")
+	versionID := c.Params("versionID")
+
+	imageQueries := queries.NewImageRepository()
+
+	image, err := imageQueries.GetImageByID(imageID)
+	if err != nil || image == nil {
+		log.Debugf("No image with ID %s in DB\n", imageID)
+		return fiber.NewError(fiber.StatusNotFound, "No image with the given ID")
+	}
+
+	version, err := imageQueries.GetImageVersionByID(image.ID, versionID)
+	if err != nil || version == nil {
+		log.Debugf("No version with ID %s for image %d in DB\n", versionID, image.ID)
+		return fiber.NewError(fiber.StatusNotFound, "No version with the given ID")
+	}
+
+	// Swap the current original with the version on disk
+	if err := images.ArchiveImageVersion(image.GalleryID, image.Filename); err != nil {
+		log.Errorf("Unable to archive the current original: %v\n", err)
+		return fiber.NewError(fiber.StatusInternalServerError, "Error archiving the current image.")
+	}
+
+	if err := images.RestoreImageVersion(image.GalleryID, version.Filename); err != nil {
+		log.Errorf("Unable to restore the image version: %v\n", err)
+		images.RestoreImageVersion(image.GalleryID, image.Filename)
+		if processErr := processingError(c, err); processErr != nil {
+			return processErr
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, "Error restoring the image version.")
+	}
+
+	previousFilename := image.Filename
+
+	if _, err := imageQueries.RevertImage(image, version); err != nil {
+		log.Errorf("Error reverting image in DB: %v\n", err)
+		// Put the files back the way they were
+		images.ArchiveImageVersion(image.GalleryID, version.Filename)
+		images.RestoreImageVersion(image.GalleryID, previousFilename)
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	imageFileChanged(image)
+
+	// Return the updated image
 	return c.JSON(models.APIResponse{
 		Status: "success",
-		Data:   "Image removed.",
+		Data:   image,
 	})
 }
+
+// imageFileChanged clears the cached copies of the image and marks the
+// gallery zips as stale after the image file has changed
+func imageFileChanged(image *models.Image) {
+	cachestore.InvalidateImages(image.PublicID)
+
+	galleryQueries := queries.NewGalleryRepository()
+
+	gallery, err := galleryQueries.GetGalleryByID(fmt.Sprint(image.GalleryID))
+	if err != nil || gallery == nil {
+		log.Warnf("Unable to retrieve gallery for image %d: %v\n", image.ID, err)
+		return
+	}
+
+	cachestore.InvalidateGallery(gallery.PublicID)
+
+	if gallery.ZipsReady {
+		if err := galleryQueries.SetZipsReady(gallery, false); err != nil {
+			log.Errorf("Unable to update ZipsReady field in gallery: %v\n", err)
+		}
+	}
+
+	if gallery.IsLive() {
+		// Set update as true if the gallery was updated while live
+		settingsQueries := queries.NewSettingsRepository()
+		if err := settingsQueries.SetSettingsUpdate(true); err != nil {
+			log.Errorf("Error setting settings update to true: %v\n", err)
+		}
+	}
+}
+
+// processingError converts image processor errors into the matching HTTP
+// error, returning nil for any other error
+func processingError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, images.ErrProcessorBusy):
+		// Let the client know when it is reasonable to retry
+		c.Set(fiber.HeaderRetryAfter, fmt.Sprint(images.ProcessingQueueTimeout))
+		return fiber.NewError(fiber.StatusTooManyRequests, err.Error())
+	case errors.Is(err, images.ErrImageTooLarge):
+		return fiber.NewError(fiber.StatusRequestEntityTooLarge, err.Error())
+	}
+
+	return nil
+}
+
+// imageUpload is a validated image file from the request form
+type imageUpload struct {
+	header      *multipart.FileHeader
+	file        multipart.File
+	config      image.Config
+	contentType string
+	// Random filename the image is stored as
+	filename string
+	// Filename the image was uploaded with
+	originalFilename string
+}
+
+// parseImageUpload validates the image file at 'src' in the request form.
+// If the upload is invalid, the fail data for the response is returned.
+// The caller must close the upload file when done.
+func parseImageUpload(c *fiber.Ctx) (*imageUpload, fiber.Map) {
+	file, err := c.FormFile("src")
+	if err != nil {
+		log.Errorf("Unable to get image from request to upload: %v\n", err)
+		return nil, fiber.Map{
+			"src": "The image file must be at 'src'",
+		}
+	}
+
+	// Get Buffer from file
+	buffer, err := file.Open()
+	if err != nil {
+		log.Errorf("Unable to open image from request to upload: %v\n", err)
+		return nil, fiber.Map{
+			"src": "Unable to open file.",
+		}
+	}
+
+	// Retrieve image dimensions (width and height)
+	config, _, err := image.DecodeConfig(buffer)
+	if err != nil {
+		buffer.Close()
+		log.Errorf("Unable to get image dimensions from request to upload: %v\n", err)
+		return nil, fiber.Map{
+			"src": "Failed to retrieve image dimensions.",
+		}
+	}
+
+	validMimeTypes := []string{"image/jpeg", "image/png"}
+	contentType, err := utils.GetFileType(file)
+	if err != nil || !utils.Contains(validMimeTypes, contentType) {
+		buffer.Close()
+		log.Errorf("Invalid image filetype (%s) for upload: %v\n", contentType, err)
+		return nil, fiber.Map{
+			"src": "Invalid file type; File must be of type: jpeg, jpg or png",
+		}
+	}
+
+	filename := utils.GenerateRandomState(&images.FilenameLength)
+
+	if contentType == "image/jpeg" {
+		filename += ".jpg"
+	} else {
+		filename += ".png"
+	}
+
+	// Reset the buffer to the beginning
+	buffer.Seek(0, 0)
+
+	return &imageUpload{
+		header:           file,
+		file:             buffer,
+		config:           config,
+		contentType:      contentType,
+		filename:         filename,
+		originalFilename: utils.SanitizeFilename(file.Filename),
+	}, nil
+}
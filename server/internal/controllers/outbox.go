@@ -0,0 +1,124 @@
+package controllers
+
+import (
+	"github.com/austinbspencer/gshare-server/internal/models"
+	"github.com/austinbspencer/gshare-server/internal/queries"
+	"github.com/austinbspencer/gshare-server/pkg/auth"
+	"github.com/austinbspencer/gshare-server/platform/email"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+)
+
+// @Description  Get the emails in the outbox along with their delivery status, most recent first.
+// @Summary      get the emails in the outbox
+// @Tags         Outbox
+// @Produce      json
+// @Param        status   query     string  false  "Status of the emails (pending, sent, failed)"
+// @Param        kind     query     string  false  "Kind of the emails (two_factor, reminder, live, alert)"
+// @Param        limit    query     int     false  "Most recent emails returned, 100 by default"
+// @Security     ApiKeyAuth
+// @Success      200        {object}  []models.OutboxEmail
+// @Router       /v1/outbox [get]
+func GetOutboxEmails(c *fiber.Ctx) error {
+	_, _, err := auth.IsAuthenticated(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+
+	search := new(models.OutboxSearch)
+
+	if err := c.QueryParser(search); err != nil {
+		log.Errorf("Error parsing outbox search: %v\n", err)
+		// Return status 400 and error message.
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Status: "fail",
+			Data: fiber.Map{
+				"search": "Invalid search parameters.",
+			},
+		})
+	}
+
+	outboxQueries := queries.NewOutboxRepository()
+
+	outboxEmails, err := outboxQueries.GetOutboxEmails(*search)
+	if err != nil {
+		log.Errorf("Error retrieving outbox emails from DB: %v\n", err)
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	// Return success and the emails
+	return c.JSON(models.APIResponse{
+		Status: "success",
+		Data:   outboxEmails,
+	})
+}
+
+// @Description  Get an email in the outbox along with its delivery status.
+// @Summary      get an email in the outbox
+// @Tags         Outbox
+// @Produce      json
+// @Param        emailID   path       string  true  "Email ID"
+// @Security     ApiKeyAuth
+// @Success      200        {object}  models.OutboxEmail
+// @Router       /v1/outbox/{emailID} [get]
+func GetOutboxEmail(c *fiber.Ctx) error {
+	_, _, err := auth.IsAuthenticated(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+
+	// Read the param emailID
+	emailID := c.Params("emailID")
+
+	outboxQueries := queries.NewOutboxRepository()
+
+	outboxEmail, err := outboxQueries.GetOutboxEmailByID(emailID)
+	if err != nil || outboxEmail == nil {
+		log.Debugf("No outbox email with ID %s in DB\n", emailID)
+		return fiber.NewError(fiber.StatusNotFound, "No email with the given ID")
+	}
+
+	// Return success and the email
+	return c.JSON(models.APIResponse{
+		Status: "success",
+		Data:   outboxEmail,
+	})
+}
+
+// @Description  Send an email of the outbox again. A copy of the email is queued so the delivery log of the email is kept.
+// @Summary      resend an email of the outbox
+// @Tags         Outbox
+// @Produce      json
+// @Param        emailID   path       string  true  "Email ID"
+// @Security     ApiKeyAuth
+// @Success      201        {object}  models.OutboxEmail
+// @Router       /v1/outbox/{emailID}/resend [post]
+func ResendOutboxEmail(c *fiber.Ctx) error {
+	_, _, err := auth.IsAuthenticated(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+
+	// Read the param emailID
+	emailID := c.Params("emailID")
+
+	outboxQueries := queries.NewOutboxRepository()
+
+	outboxEmail, err := outboxQueries.GetOutboxEmailByID(emailID)
+	if err != nil || outboxEmail == nil {
+		log.Debugf("No outbox email with ID %s in DB\n", emailID)
+		return fiber.NewError(fiber.StatusNotFound, "No email with the given ID")
+	}
+
+	resent, err := email.ResendEmail(outboxEmail)
+	if err != nil {
+		log.Errorf("Unable to resend outbox email: %v\n", err)
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	// Return the queued copy of the email
+	return c.Status(fiber.StatusCreated).JSON(models.APIResponse{
+		Status: "success",
+		Data:   resent,
+	})
+}
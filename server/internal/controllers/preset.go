@@ -0,0 +1,273 @@
+package controllers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/austinbspencer/gshare-server/internal/models"
+	"github.com/austinbspencer/gshare-server/internal/queries"
+	"github.com/austinbspencer/gshare-server/pkg/auth"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+)
+
+// @Description  Get all gallery presets.
+// @Summary      get all gallery presets
+// @Tags         Preset
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Success      200        {object}  []models.GalleryPreset
+// @Router       /v1/presets [get]
+func GetGalleryPresets(c *fiber.Ctx) error {
+	_, _, err := auth.IsAuthenticated(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+
+	presetQueries := queries.NewGalleryPresetRepository()
+
+	presets, err := presetQueries.GetGalleryPresets()
+	if err != nil {
+		log.Errorf("Error retrieving gallery presets from DB: %v\n", err)
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	// Return success and all presets
+	return c.JSON(models.APIResponse{
+		Status: "success",
+		Data:   presets,
+	})
+}
+
+// @Description  Get gallery preset by ID.
+// @Summary      get a gallery preset by ID
+// @Tags         Preset
+// @Produce      json
+// @Param        presetID   path       string  true  "Preset ID"
+// @Security     ApiKeyAuth
+// @Success      200        {object}  models.GalleryPreset
+// @Router       /v1/presets/{presetID} [get]
+func GetGalleryPreset(c *fiber.Ctx) error {
+	_, _, err := auth.IsAuthenticated(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+
+	// Read the param presetID
+	presetID := c.Params("presetID")
+
+	presetQueries := queries.NewGalleryPresetRepository()
+
+	preset, err := presetQueries.GetGalleryPresetByID(presetID)
+	if err != nil || preset == nil {
+		log.Debugf("No preset with ID %s in DB\n", presetID)
+		return fiber.NewError(fiber.StatusNotFound, "No preset with the given ID")
+	}
+
+	// Return success and the preset
+	return c.JSON(models.APIResponse{
+		Status: "success",
+		Data:   preset,
+	})
+}
+
+// @Description  Create a new gallery preset. Settings that aren't given start
+// @Description  with the settings of a gallery created without a preset.
+// @Summary      create a new gallery preset
+// @Tags         Preset
+// @Accept       json
+// @Produce      json
+// @Param        payload     body       models.GalleryPresetUpdate  true  "New Preset"
+// @Security     ApiKeyAuth
+// @Success      201        {object}  models.GalleryPreset
+// @Router       /v1/presets [post]
+func CreateGalleryPreset(c *fiber.Ctx) error {
+	_, _, err := auth.IsAuthenticated(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+
+	presetUpdate := new(models.GalleryPresetUpdate)
+
+	if err := c.BodyParser(presetUpdate); err != nil {
+		log.Errorf("Unable to parse new preset: %v\n", err)
+		// Return status 400 and error message.
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Status: "fail",
+			Data: fiber.Map{
+				"issue": err.Error(),
+			},
+		})
+	}
+
+	if presetUpdate.Name == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Status: "fail",
+			Data: fiber.Map{
+				"name": "Name is required.",
+			},
+		})
+	}
+
+	if failData := validateGalleryPreset(nil, presetUpdate); failData != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Status: "fail",
+			Data:   failData,
+		})
+	}
+
+	preset := models.DefaultGalleryPreset()
+
+	presetQueries := queries.NewGalleryPresetRepository()
+
+	if err := presetQueries.CreateGalleryPreset(&preset, *presetUpdate); err != nil {
+		log.Errorf("Unable to create new preset in DB: %v\n", err)
+		// Return status 500 and error message.
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	// Return the created preset
+	return c.Status(fiber.StatusCreated).JSON(models.APIResponse{
+		Status: "success",
+		Data:   preset,
+	})
+}
+
+// @Description  Update the gallery preset. Galleries already created with the
+// @Description  preset are left unchanged.
+// @Summary      update a gallery preset
+// @Tags         Preset
+// @Accept       json
+// @Produce      json
+// @Param        presetID   path       string  true  "Preset ID"
+// @Param        payload     body       models.GalleryPresetUpdate  true  "Preset Update"
+// @Security     ApiKeyAuth
+// @Success      200        {object}  models.GalleryPreset
+// @Router       /v1/presets/{presetID} [put]
+func UpdateGalleryPreset(c *fiber.Ctx) error {
+	_, _, err := auth.IsAuthenticated(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+
+	// Read the param presetID
+	presetID := c.Params("presetID")
+
+	presetQueries := queries.NewGalleryPresetRepository()
+
+	preset, err := presetQueries.GetGalleryPresetByID(presetID)
+	if err != nil || preset == nil {
+		log.Debugf("No preset with ID %s in DB\n", presetID)
+		return fiber.NewError(fiber.StatusNotFound, "No preset with the given ID")
+	}
+
+	presetUpdate := new(models.GalleryPresetUpdate)
+
+	if err := c.BodyParser(presetUpdate); err != nil {
+		log.Errorf("Unable to parse preset update: %v\n", err)
+		// Return status 400 and error message.
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Status: "fail",
+			Data: fiber.Map{
+				"issue": err.Error(),
+			},
+		})
+	}
+
+	if failData := validateGalleryPreset(preset, presetUpdate); failData != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Status: "fail",
+			Data:   failData,
+		})
+	}
+
+	if err := presetQueries.UpdateGalleryPreset(preset, *presetUpdate); err != nil {
+		log.Errorf("Unable to update preset in DB: %v\n", err)
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	// Return the updated preset
+	return c.JSON(models.APIResponse{
+		Status: "success",
+		Data:   preset,
+	})
+}
+
+// @Description  Delete the gallery preset. Galleries already created with the
+// @Description  preset are left unchanged.
+// @Summary      delete a gallery preset
+// @Tags         Preset
+// @Produce      json
+// @Param        presetID   path       string  true  "Preset ID"
+// @Security     ApiKeyAuth
+// @Success      200
+// @Router       /v1/presets/{presetID} [delete]
+func DeleteGalleryPreset(c *fiber.Ctx) error {
+	_, _, err := auth.IsAuthenticated(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+
+	// Read the param presetID
+	presetID := c.Params("presetID")
+
+	presetQueries := queries.NewGalleryPresetRepository()
+
+	preset, err := presetQueries.GetGalleryPresetByID(presetID)
+	if err != nil || preset == nil {
+		log.Debugf("No preset with ID %s in DB\n", presetID)
+		return fiber.NewError(fiber.StatusNotFound, "No preset with the given ID")
+	}
+
+	if err := presetQueries.DeleteGalleryPreset(preset); err != nil {
+		log.Errorf("Unable to delete preset from DB: %v\n", err)
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	// Return success
+	return c.JSON(models.APIResponse{
+		Status: "success",
+	})
+}
+
+// validateGalleryPreset checks the name, expiration and download policy of
+// the preset update. The name is trimmed and must be unique.
+func validateGalleryPreset(preset *models.GalleryPreset, update *models.GalleryPresetUpdate) fiber.Map {
+	failData := fiber.Map{}
+
+	if update.Name != nil {
+		name := strings.TrimSpace(*update.Name)
+		update.Name = &name
+
+		presetQueries := queries.NewGalleryPresetRepository()
+
+		if name == "" {
+			failData["name"] = "Name can't be empty."
+		} else if existing, err := presetQueries.GetGalleryPresetByName(name); err == nil &&
+			(preset == nil || existing.ID != preset.ID) {
+			failData["name"] = "Another preset is using this name."
+		}
+	}
+
+	if update.ExpirationDays != nil && (*update.ExpirationDays < 0 || *update.ExpirationDays > models.MaxPresetExpirationDays) {
+		failData["expiration_days"] = fmt.Sprintf("Expiration days must be between 0 and %d.", models.MaxPresetExpirationDays)
+	}
+
+	if update.ReminderDays != nil {
+		for key, value := range validateReminderDays(update.ReminderDays) {
+			failData[key] = value
+		}
+	}
+
+	if update.DownloadPolicy != nil {
+		for key, value := range validateDownloadPolicy(update.DownloadPolicy) {
+			failData[key] = value
+		}
+	}
+
+	if len(failData) == 0 {
+		return nil
+	}
+
+	return failData
+}
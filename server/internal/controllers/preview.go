@@ -0,0 +1,431 @@
+package controllers
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/austinbspencer/gshare-server/internal/models"
+	"github.com/austinbspencer/gshare-server/internal/queries"
+	"github.com/austinbspencer/gshare-server/pkg/auth"
+	"github.com/austinbspencer/gshare-server/pkg/configs"
+	"github.com/austinbspencer/gshare-server/platform/webhook"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+)
+
+// maxPreviewNameLength is the longest name a preview link can be given
+const maxPreviewNameLength = 100
+
+// @Description  Get the preview links of the gallery along with the images
+// @Description  favorited through each link.
+// @Summary      get the preview links of a gallery
+// @Tags         Preview
+// @Produce      json
+// @Param        galleryID   path       string  true  "Gallery ID"
+// @Security     ApiKeyAuth
+// @Success      200        {object}  []models.PreviewLink
+// @Router       /v1/galleries/id/{galleryID}/previews [get]
+func GetPreviewLinks(c *fiber.Ctx) error {
+	_, _, err := auth.IsAuthenticated(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+
+	// Read the param galleryID
+	galleryID := c.Params("galleryID")
+
+	galleryQueries := queries.NewGalleryRepository()
+
+	gallery, err := galleryQueries.GetGalleryByID(galleryID)
+	if err != nil || gallery == nil {
+		log.Debugf("No gallery with ID %s in DB\n", galleryID)
+		return fiber.NewError(fiber.StatusNotFound, "No gallery with the given ID")
+	}
+
+	previewQueries := queries.NewPreviewRepository()
+
+	links, err := previewQueries.GetGalleryPreviewLinks(gallery.ID)
+	if err != nil {
+		log.Errorf("Unable to retrieve preview links from DB: %v\n", err)
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	for idx := range links {
+		if links[idx].IsActive() {
+			setPreviewLinkToken(&links[idx])
+		}
+	}
+
+	// Return success and the preview links
+	return c.JSON(models.APIResponse{
+		Status: "success",
+		Data:   links,
+	})
+}
+
+// @Description  Create a link that lets a specific person preview the gallery
+// @Description  before it is live or after it has expired.
+// @Summary      create a preview link for the gallery
+// @Tags         Preview
+// @Accept       json
+// @Produce      json
+// @Param        galleryID   path       string  true  "Gallery ID"
+// @Param        payload     body       models.PreviewLinkCreate  true  "New Preview Link"
+// @Security     ApiKeyAuth
+// @Success      201        {object}  models.PreviewLink
+// @Router       /v1/galleries/id/{galleryID}/previews [post]
+func CreatePreviewLink(c *fiber.Ctx) error {
+	_, _, err := auth.IsAuthenticated(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+
+	// Read the param galleryID
+	galleryID := c.Params("galleryID")
+
+	galleryQueries := queries.NewGalleryRepository()
+
+	gallery, err := galleryQueries.GetGalleryByID(galleryID)
+	if err != nil || gallery == nil {
+		log.Debugf("No gallery with ID %s in DB\n", galleryID)
+		return fiber.NewError(fiber.StatusNotFound, "No gallery with the given ID")
+	}
+
+	linkCreate := new(models.PreviewLinkCreate)
+
+	if err := c.BodyParser(linkCreate); err != nil {
+		log.Errorf("Unable to parse new preview link: %v\n", err)
+		// Return status 400 and error message.
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Status: "fail",
+			Data: fiber.Map{
+				"issue": err.Error(),
+			},
+		})
+	}
+
+	name := strings.TrimSpace(linkCreate.Name)
+
+	failData := fiber.Map{}
+	if name == "" {
+		failData["name"] = "Name of the person the link is for is required."
+	} else if len(name) > maxPreviewNameLength {
+		failData["name"] = fmt.Sprintf("Name must be at most %d characters.", maxPreviewNameLength)
+	}
+
+	expiration := time.Now().Add(time.Hour * time.Duration(configs.GetenvInt("PREVIEW_LINK_EXPIRE_HOURS_COUNT", 168)))
+	if linkCreate.Expiration != nil {
+		if !linkCreate.Expiration.After(time.Now()) {
+			failData["expiration"] = "Expiration must be in the future."
+		}
+		expiration = *linkCreate.Expiration
+	}
+
+	if len(failData) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Status: "fail",
+			Data:   failData,
+		})
+	}
+
+	link := &models.PreviewLink{
+		GalleryID:      gallery.ID,
+		Name:           name,
+		AllowFavorites: linkCreate.AllowFavorites,
+		Expiration:     expiration,
+		Favorites:      []models.PreviewFavorite{},
+	}
+
+	previewQueries := queries.NewPreviewRepository()
+
+	if err := previewQueries.CreatePreviewLink(link); err != nil {
+		log.Errorf("Unable to create preview link in DB: %v\n", err)
+		// Return status 500 and error message.
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	setPreviewLinkToken(link)
+
+	// Return the created link along with its token
+	return c.Status(fiber.StatusCreated).JSON(models.APIResponse{
+		Status: "success",
+		Data:   link,
+	})
+}
+
+// @Description  Revoke the preview link so it stops working. The favorites of
+// @Description  the link are kept.
+// @Summary      revoke a preview link of the gallery
+// @Tags         Preview
+// @Produce      json
+// @Param        galleryID   path       string  true  "Gallery ID"
+// @Param        previewID   path       string  true  "Preview Link ID"
+// @Security     ApiKeyAuth
+// @Success      200        {object}  models.PreviewLink
+// @Router       /v1/galleries/id/{galleryID}/previews/{previewID}/revoke [post]
+func RevokePreviewLink(c *fiber.Ctx) error {
+	_, _, err := auth.IsAuthenticated(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+
+	link, err := galleryPreviewLink(c)
+	if err != nil {
+		return err
+	}
+
+	if link.RevokedAt == nil {
+		previewQueries := queries.NewPreviewRepository()
+
+		if err := previewQueries.RevokePreviewLink(link); err != nil {
+			log.Errorf("Unable to revoke preview link in DB: %v\n", err)
+			return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		}
+	}
+
+	// Return the revoked link
+	return c.JSON(models.APIResponse{
+		Status: "success",
+		Data:   link,
+	})
+}
+
+// @Description  Permanently delete the preview link along with its favorites.
+// @Summary      delete a preview link of the gallery
+// @Tags         Preview
+// @Produce      json
+// @Param        galleryID   path       string  true  "Gallery ID"
+// @Param        previewID   path       string  true  "Preview Link ID"
+// @Security     ApiKeyAuth
+// @Success      200
+// @Router       /v1/galleries/id/{galleryID}/previews/{previewID} [delete]
+func DeletePreviewLink(c *fiber.Ctx) error {
+	_, _, err := auth.IsAuthenticated(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+
+	link, err := galleryPreviewLink(c)
+	if err != nil {
+		return err
+	}
+
+	previewQueries := queries.NewPreviewRepository()
+
+	if err := previewQueries.DeletePreviewLink(link); err != nil {
+		log.Errorf("Unable to delete preview link from DB: %v\n", err)
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	// Return success
+	return c.JSON(models.APIResponse{
+		Status: "success",
+	})
+}
+
+// @Description  Get the gallery through a preview link. The gallery doesn't
+// @Description  have to be live and hidden images are left out.
+// @Summary      get a gallery through a preview link
+// @Tags         Preview
+// @Produce      json
+// @Param        X-Preview-Token   header       string  true  "Preview Link Token"
+// @Success      200        {object}  models.GalleryPreview
+// @Router       /v1/galleries/preview [get]
+func GetGalleryPreview(c *fiber.Ctx) error {
+	link, err := activePreviewLink(c.Get(models.PreviewTokenHeader))
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	}
+
+	galleryQueries := queries.NewGalleryRepository()
+
+	gallery, err := galleryQueries.GetClientGalleryByID(link.GalleryID)
+	if err != nil || gallery == nil {
+		log.Debugf("No gallery with ID %d in DB\n", link.GalleryID)
+		return fiber.NewError(fiber.StatusNotFound, "No gallery with the given ID")
+	}
+
+	// The images and downloads of the gallery are reached with URLs signed
+	// for the link, the token itself is kept out of URLs
+	setGalleryURLs(gallery, func(resource string) string {
+		query := url.Values{}
+		query.Set("preview_link", fmt.Sprint(link.ID))
+		return query.Encode() + "&" + auth.SignResource(auth.PreviewLinkResource(link.ID, resource), "")
+	})
+
+	// Return success and the gallery along with the link
+	return c.JSON(models.APIResponse{
+		Status: "success",
+		Data: models.GalleryPreview{
+			Gallery: gallery,
+			Preview: link,
+		},
+	})
+}
+
+// @Description  Favorite an image of the gallery through a preview link.
+// @Summary      favorite an image through a preview link
+// @Tags         Preview
+// @Produce      json
+// @Param        X-Preview-Token   header       string  true  "Preview Link Token"
+// @Param        imagePublicID   path       string  true  "Image Public ID"
+// @Success      200        {object}  models.PreviewLink
+// @Router       /v1/galleries/preview/favorites/{imagePublicID} [post]
+func AddPreviewFavorite(c *fiber.Ctx) error {
+	return updatePreviewFavorite(c, true)
+}
+
+// @Description  Remove a favorite image through a preview link.
+// @Summary      remove a favorite through a preview link
+// @Tags         Preview
+// @Produce      json
+// @Param        X-Preview-Token   header       string  true  "Preview Link Token"
+// @Param        imagePublicID   path       string  true  "Image Public ID"
+// @Success      200        {object}  models.PreviewLink
+// @Router       /v1/galleries/preview/favorites/{imagePublicID} [delete]
+func RemovePreviewFavorite(c *fiber.Ctx) error {
+	return updatePreviewFavorite(c, false)
+}
+
+// updatePreviewFavorite adds or removes the image from the favorites of the
+// preview link and returns the updated link
+func updatePreviewFavorite(c *fiber.Ctx, favorite bool) error {
+	imagePublicID := c.Params("imagePublicID")
+
+	link, err := activePreviewLink(c.Get(models.PreviewTokenHeader))
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	}
+
+	if !link.AllowFavorites {
+		return c.Status(fiber.StatusForbidden).JSON(models.APIResponse{
+			Status: "fail",
+			Data: fiber.Map{
+				"favorites": "Favorites are not allowed for this preview link.",
+			},
+		})
+	}
+
+	imageQueries := queries.NewImageRepository()
+
+	image, err := imageQueries.GetImageByPublicID(imagePublicID)
+	if err != nil || image == nil || image.Hidden || image.GalleryID != link.GalleryID {
+		log.Debugf("No image with public ID %s in the previewed gallery\n", imagePublicID)
+		return fiber.NewError(fiber.StatusNotFound, "No image with the given ID")
+	}
+
+	previewQueries := queries.NewPreviewRepository()
+
+	if favorite {
+		err = previewQueries.AddPreviewFavorite(link, image.ID)
+	} else {
+		err = previewQueries.RemovePreviewFavorite(link, image.ID)
+	}
+	if err != nil {
+		log.Errorf("Unable to update preview favorites in DB: %v\n", err)
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	event := models.WebhookFavoriteAdded
+	if !favorite {
+		event = models.WebhookFavoriteRemoved
+	}
+	webhook.Fire(event, fiber.Map{
+		"gallery_id":        link.GalleryID,
+		"preview_link_id":   link.ID,
+		"preview_link_name": link.Name,
+		"image_id":          image.ID,
+		"image_public_id":   image.PublicID,
+		"filename":          image.OriginalFilename,
+	})
+
+	link, err = previewQueries.GetPreviewLinkByID(link.GalleryID, fmt.Sprint(link.ID))
+	if err != nil {
+		log.Errorf("Unable to retrieve preview link from DB: %v\n", err)
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	// Return the link with the updated favorites
+	return c.JSON(models.APIResponse{
+		Status: "success",
+		Data:   link,
+	})
+}
+
+// galleryPreviewLink gets the preview link of the gallery from the params
+func galleryPreviewLink(c *fiber.Ctx) (*models.PreviewLink, error) {
+	galleryID := c.Params("galleryID")
+	previewID := c.Params("previewID")
+
+	galleryQueries := queries.NewGalleryRepository()
+
+	gallery, err := galleryQueries.GetGalleryByID(galleryID)
+	if err != nil || gallery == nil {
+		log.Debugf("No gallery with ID %s in DB\n", galleryID)
+		return nil, fiber.NewError(fiber.StatusNotFound, "No gallery with the given ID")
+	}
+
+	previewQueries := queries.NewPreviewRepository()
+
+	link, err := previewQueries.GetPreviewLinkByID(gallery.ID, previewID)
+	if err != nil || link == nil {
+		log.Debugf("No preview link with ID %s in DB\n", previewID)
+		return nil, fiber.NewError(fiber.StatusNotFound, "No preview link with the given ID")
+	}
+
+	return link, nil
+}
+
+// activePreviewLink gets the preview link of the token as long as the link
+// hasn't been revoked or expired
+func activePreviewLink(token string) (*models.PreviewLink, error) {
+	linkID, err := auth.VerifyPreviewToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	previewQueries := queries.NewPreviewRepository()
+
+	link, err := previewQueries.GetPreviewLinkByID(0, fmt.Sprint(linkID))
+	if err != nil || link == nil || !link.IsActive() {
+		return nil, auth.ErrPreviewInvalid
+	}
+
+	return link, nil
+}
+
+// hasPreviewLink checks if the request was made with an active preview link
+// of the gallery, either with the token in the header or with a URL signed
+// for the link
+func hasPreviewLink(c *fiber.Ctx, gallery *models.Gallery, resource string) bool {
+	if token := c.Get(models.PreviewTokenHeader); token != "" {
+		link, err := activePreviewLink(token)
+		return err == nil && link.GalleryID == gallery.ID
+	}
+
+	linkID, err := strconv.ParseUint(c.Query("preview_link"), 10, 32)
+	if err != nil {
+		return false
+	}
+
+	if err := auth.VerifyResource(auth.PreviewLinkResource(uint(linkID), resource), c.Query("expires"), c.Query("signature"), ""); err != nil {
+		return false
+	}
+
+	previewQueries := queries.NewPreviewRepository()
+
+	link, err := previewQueries.GetPreviewLinkByID(gallery.ID, fmt.Sprint(linkID))
+
+	return err == nil && link != nil && link.IsActive()
+}
+
+// setPreviewLinkToken sets the token of the link and the client page to
+// share it with
+func setPreviewLinkToken(link *models.PreviewLink) {
+	link.Token = auth.GeneratePreviewToken(link.ID, link.Expiration)
+	link.URL = fmt.Sprintf("%s/preview/%s", os.Getenv("NEXT_PUBLIC_CLIENT_URL"), link.Token)
+}
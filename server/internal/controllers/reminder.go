@@ -0,0 +1,91 @@
+package controllers
+
+import (
+	"github.com/austinbspencer/gshare-server/internal/models"
+	"github.com/austinbspencer/gshare-server/internal/queries"
+	"github.com/austinbspencer/gshare-server/pkg/auth"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+)
+
+// @Description  Get the reminders of the gallery for each recipient, along with
+// @Description  their status and the error of the last failed attempt.
+// @Summary      get the reminders of a gallery
+// @Tags         Gallery
+// @Produce      json
+// @Param        galleryID   path       string  true  "Gallery ID"
+// @Security     ApiKeyAuth
+// @Success      200        {object}  []models.GalleryReminder
+// @Router       /v1/galleries/id/{galleryID}/reminders [get]
+func GetGalleryReminders(c *fiber.Ctx) error {
+	_, _, err := auth.IsAuthenticated(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+
+	// Read the param galleryID
+	galleryID := c.Params("galleryID")
+
+	galleryQueries := queries.NewGalleryRepository()
+
+	gallery, err := galleryQueries.GetGalleryByID(galleryID)
+	if err != nil || gallery == nil {
+		log.Debugf("No gallery with ID %s in DB\n", galleryID)
+		return fiber.NewError(fiber.StatusNotFound, "No gallery with the given ID")
+	}
+
+	reminderQueries := queries.NewReminderRepository()
+
+	reminders, err := reminderQueries.GetGalleryReminders(gallery.ID)
+	if err != nil {
+		log.Errorf("Error retrieving gallery reminders from DB: %v\n", err)
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	// Return success and the reminders
+	return c.JSON(models.APIResponse{
+		Status: "success",
+		Data:   reminders,
+	})
+}
+
+// @Description  Get the live notifications of the gallery for each contact, along
+// @Description  with the status of their email in the outbox.
+// @Summary      get the live notifications of a gallery
+// @Tags         Gallery
+// @Produce      json
+// @Param        galleryID   path       string  true  "Gallery ID"
+// @Security     ApiKeyAuth
+// @Success      200        {object}  []models.LiveNotification
+// @Router       /v1/galleries/id/{galleryID}/notifications [get]
+func GetGalleryNotifications(c *fiber.Ctx) error {
+	_, _, err := auth.IsAuthenticated(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+
+	// Read the param galleryID
+	galleryID := c.Params("galleryID")
+
+	galleryQueries := queries.NewGalleryRepository()
+
+	gallery, err := galleryQueries.GetGalleryByID(galleryID)
+	if err != nil || gallery == nil {
+		log.Debugf("No gallery with ID %s in DB\n", galleryID)
+		return fiber.NewError(fiber.StatusNotFound, "No gallery with the given ID")
+	}
+
+	notificationQueries := queries.NewNotificationRepository()
+
+	notifications, err := notificationQueries.GetGalleryNotifications(gallery.ID)
+	if err != nil {
+		log.Errorf("Error retrieving gallery notifications from DB: %v\n", err)
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	// Return success and the notifications
+	return c.JSON(models.APIResponse{
+		Status: "success",
+		Data:   notifications,
+	})
+}
@@ -0,0 +1,39 @@
+package controllers
+
+import (
+	"time"
+
+	"github.com/austinbspencer/gshare-server/internal/models"
+	"github.com/austinbspencer/gshare-server/pkg/auth"
+	"github.com/austinbspencer/gshare-server/runner"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+)
+
+// @Description  Dry run of the retention policy. Lists the expired galleries
+// @Description  that would be deleted or archived now, along with the galleries
+// @Description  that will be within the warning days, without changing them.
+// @Summary      get the retention policy report
+// @Tags         Retention
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Success      200        {object}  models.RetentionReport
+// @Router       /v1/retention [get]
+func GetRetentionReport(c *fiber.Ctx) error {
+	_, _, err := auth.IsAuthenticated(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+
+	report, err := runner.GetRetentionReport(time.Now())
+	if err != nil {
+		log.Errorf("Error retrieving retention report: %v\n", err)
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	// Return success and the report
+	return c.JSON(models.APIResponse{
+		Status: "success",
+		Data:   report,
+	})
+}
@@ -1,8 +1,13 @@
 package controllers
 
 import (
+	"errors"
+
 	"github.com/austinbspencer/gshare-server/internal/models"
+	"github.com/austinbspencer/gshare-server/pkg/auth"
+	"github.com/austinbspencer/gshare-server/runner"
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
 )
 
 // @Description  Clear all cache for the server.
@@ -23,6 +28,84 @@ import (
 // 	})
 // }
 
+// @Description  Rebuild the derivatives of every image from their originals.
+// @Summary      start a job to reprocess all images
+// @Tags         Server
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Success      202        {object}  models.ReprocessJob
+// @Router       /v1/server/reprocess [post]
+func ReprocessImages(c *fiber.Ctx) error {
+	_, _, err := auth.IsAuthenticated(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+
+	job, err := runner.StartReprocessJob(nil)
+	if err != nil {
+		log.Errorf("Unable to start reprocessing job: %v\n", err)
+		if errors.Is(err, runner.ErrReprocessRunning) {
+			return fiber.NewError(fiber.StatusConflict, err.Error())
+		}
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	// Return the started job
+	return c.Status(fiber.StatusAccepted).JSON(models.APIResponse{
+		Status: "success",
+		Data:   job,
+	})
+}
+
+// @Description  Get all reprocessing jobs since the server started.
+// @Summary      get all reprocessing jobs
+// @Tags         Server
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Success      200        {object}  []models.ReprocessJob
+// @Router       /v1/server/jobs [get]
+func GetReprocessJobs(c *fiber.Ctx) error {
+	_, _, err := auth.IsAuthenticated(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+
+	// Return success and all jobs
+	return c.JSON(models.APIResponse{
+		Status: "success",
+		Data:   runner.GetReprocessJobs(),
+	})
+}
+
+// @Description  Get the progress of a reprocessing job.
+// @Summary      get a reprocessing job by ID
+// @Tags         Server
+// @Produce      json
+// @Param        jobID   path       string  true  "Job ID"
+// @Security     ApiKeyAuth
+// @Success      200        {object}  models.ReprocessJob
+// @Router       /v1/server/jobs/{jobID} [get]
+func GetReprocessJob(c *fiber.Ctx) error {
+	_, _, err := auth.IsAuthenticated(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+
+	// Read the param jobID
+	jobID := c.Params("jobID")
+
+	job, ok := runner.GetReprocessJob(jobID)
+	if !ok {
+		return fiber.NewError(fiber.StatusNotFound, "No job with the given ID")
+	}
+
+	// Return success and the individual job
+	return c.JSON(models.APIResponse{
+		Status: "success",
+		Data:   job,
+	})
+}
+
 // @Description  Simple ping endpoint to check if the server is alive.
 // @Summary      ping the server
 // @Tags         Server
@@ -0,0 +1,332 @@
+package controllers
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/austinbspencer/gshare-server/internal/models"
+	"github.com/austinbspencer/gshare-server/internal/queries"
+	"github.com/austinbspencer/gshare-server/pkg/auth"
+	"github.com/austinbspencer/gshare-server/pkg/cachestore"
+	"github.com/austinbspencer/gshare-server/pkg/images"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+)
+
+// @Description  Get the sets of the gallery.
+// @Summary      get all sets of a gallery in order
+// @Tags         Set
+// @Produce      json
+// @Param        galleryID   path       string  true  "Gallery ID"
+// @Security     ApiKeyAuth
+// @Success      200        {object}  []models.GallerySet
+// @Router       /v1/galleries/id/{galleryID}/sets [get]
+func GetGallerySets(c *fiber.Ctx) error {
+	_, _, err := auth.IsAuthenticated(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+
+	// Read the param galleryID
+	galleryID := c.Params("galleryID")
+
+	galleryQueries := queries.NewGalleryRepository()
+
+	gallery, err := galleryQueries.GetGalleryByID(galleryID)
+	if err != nil || gallery == nil {
+		log.Debugf("No gallery with ID %s in DB\n", galleryID)
+		return fiber.NewError(fiber.StatusNotFound, "No gallery with the given ID")
+	}
+
+	// Return success and the sets
+	return c.JSON(models.APIResponse{
+		Status: "success",
+		Data:   gallery.Sets,
+	})
+}
+
+// @Description  Create a new set at the end of the gallery.
+// @Summary      create a new set in the gallery
+// @Tags         Set
+// @Accept       json
+// @Produce      json
+// @Param        galleryID   path       string  true  "Gallery ID"
+// @Param        payload     body       models.GallerySetUpdate  true  "New Set"
+// @Security     ApiKeyAuth
+// @Success      201        {object}  models.GallerySet
+// @Router       /v1/galleries/id/{galleryID}/sets [post]
+func CreateGallerySet(c *fiber.Ctx) error {
+	_, _, err := auth.IsAuthenticated(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+
+	// Read the param galleryID
+	galleryID := c.Params("galleryID")
+
+	galleryQueries := queries.NewGalleryRepository()
+
+	gallery, err := galleryQueries.GetGalleryByID(galleryID)
+	if err != nil || gallery == nil {
+		log.Debugf("No gallery with ID %s in DB\n", galleryID)
+		return fiber.NewError(fiber.StatusNotFound, "No gallery with the given ID")
+	}
+
+	setUpdate := new(models.GallerySetUpdate)
+
+	if err := c.BodyParser(setUpdate); err != nil {
+		log.Errorf("Unable to parse new set: %v\n", err)
+		// Return status 400 and error message.
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Status: "fail",
+			Data: fiber.Map{
+				"issue": err.Error(),
+			},
+		})
+	}
+
+	if setUpdate.Title == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Status: "fail",
+			Data: fiber.Map{
+				"title": "Title is required.",
+			},
+		})
+	}
+
+	if failData := validateGallerySet(gallery, nil, *setUpdate.Title); failData != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Status: "fail",
+			Data:   failData,
+		})
+	}
+
+	set := &models.GallerySet{
+		GalleryID: gallery.ID,
+		Title:     strings.TrimSpace(*setUpdate.Title),
+	}
+
+	setQueries := queries.NewGallerySetRepository()
+
+	if err := setQueries.CreateGallerySet(set); err != nil {
+		log.Errorf("Unable to create new set in DB: %v\n", err)
+		// Return status 500 and error message.
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	galleryImagesChanged(gallery, false)
+
+	// Return the created set
+	return c.Status(fiber.StatusCreated).JSON(models.APIResponse{
+		Status: "success",
+		Data:   set,
+	})
+}
+
+// @Description  Update the set of the gallery.
+// @Summary      rename a set in the gallery
+// @Tags         Set
+// @Accept       json
+// @Produce      json
+// @Param        galleryID   path       string  true  "Gallery ID"
+// @Param        setID       path       string  true  "Set ID"
+// @Param        payload     body       models.GallerySetUpdate  true  "Set Update"
+// @Security     ApiKeyAuth
+// @Success      200        {object}  models.GallerySet
+// @Router       /v1/galleries/id/{galleryID}/sets/{setID} [put]
+func UpdateGallerySet(c *fiber.Ctx) error {
+	_, _, err := auth.IsAuthenticated(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+
+	// Read the params
+	galleryID := c.Params("galleryID")
+	setID := c.Params("setID")
+
+	galleryQueries := queries.NewGalleryRepository()
+
+	gallery, err := galleryQueries.GetGalleryByID(galleryID)
+	if err != nil || gallery == nil {
+		log.Debugf("No gallery with ID %s in DB\n", galleryID)
+		return fiber.NewError(fiber.StatusNotFound, "No gallery with the given ID")
+	}
+
+	setQueries := queries.NewGallerySetRepository()
+
+	set, err := setQueries.GetGallerySetByID(gallery.ID, setID)
+	if err != nil || set == nil {
+		log.Debugf("No set with ID %s in gallery %d\n", setID, gallery.ID)
+		return fiber.NewError(fiber.StatusNotFound, "No set with the given ID")
+	}
+
+	setUpdate := new(models.GallerySetUpdate)
+
+	if err := c.BodyParser(setUpdate); err != nil {
+		log.Errorf("Unable to parse set update: %v\n", err)
+		// Return status 400 and error message.
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Status: "fail",
+			Data: fiber.Map{
+				"issue": err.Error(),
+			},
+		})
+	}
+
+	if setUpdate.Title != nil {
+		if failData := validateGallerySet(gallery, set, *setUpdate.Title); failData != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+				Status: "fail",
+				Data:   failData,
+			})
+		}
+
+		title := strings.TrimSpace(*setUpdate.Title)
+		setUpdate.Title = &title
+	}
+
+	if err := setQueries.UpdateGallerySet(set, *setUpdate); err != nil {
+		log.Errorf("Unable to update set in DB: %v\n", err)
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	// The set title is the folder name in the gallery zips
+	galleryImagesChanged(gallery, true)
+
+	// Return the updated set
+	return c.JSON(models.APIResponse{
+		Status: "success",
+		Data:   set,
+	})
+}
+
+// @Description  Delete the set of the gallery, its images stay in the gallery without a set.
+// @Summary      remove a set from the gallery
+// @Tags         Set
+// @Produce      json
+// @Param        galleryID   path       string  true  "Gallery ID"
+// @Param        setID       path       string  true  "Set ID"
+// @Security     ApiKeyAuth
+// @Success      200
+// @Router       /v1/galleries/id/{galleryID}/sets/{setID} [delete]
+func DeleteGallerySet(c *fiber.Ctx) error {
+	_, _, err := auth.IsAuthenticated(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+
+	// Read the params
+	galleryID := c.Params("galleryID")
+	setID := c.Params("setID")
+
+	galleryQueries := queries.NewGalleryRepository()
+
+	gallery, err := galleryQueries.GetGalleryByID(galleryID)
+	if err != nil || gallery == nil {
+		log.Debugf("No gallery with ID %s in DB\n", galleryID)
+		return fiber.NewError(fiber.StatusNotFound, "No gallery with the given ID")
+	}
+
+	setQueries := queries.NewGallerySetRepository()
+
+	set, err := setQueries.GetGallerySetByID(gallery.ID, setID)
+	if err != nil || set == nil {
+		log.Debugf("No set with ID %s in gallery %d\n", setID, gallery.ID)
+		return fiber.NewError(fiber.StatusNotFound, "No set with the given ID")
+	}
+
+	if err := setQueries.DeleteGallerySet(set); err != nil {
+		log.Errorf("Unable to delete set from DB: %v\n", err)
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	galleryImagesChanged(gallery, true)
+
+	// Return success
+	return c.JSON(models.APIResponse{
+		Status: "success",
+		Data:   "Set removed.",
+	})
+}
+
+// validateGallerySet checks the title of a new or updated set
+func validateGallerySet(gallery *models.Gallery, set *models.GallerySet, title string) fiber.Map {
+	title = strings.TrimSpace(title)
+
+	if title == "" {
+		return fiber.Map{"title": "Title is required."}
+	}
+
+	if len(title) > models.MaxGallerySetTitleLength {
+		return fiber.Map{"title": fmt.Sprintf("Title must be at most %d characters.", models.MaxGallerySetTitleLength)}
+	}
+
+	for _, existing := range gallery.Sets {
+		if (set == nil || existing.ID != set.ID) && strings.EqualFold(existing.Title, title) {
+			return fiber.Map{"title": "A set with this title already exists in the gallery."}
+		}
+	}
+
+	return nil
+}
+
+// galleryImagesChanged flags the settings for an update when the gallery is
+// live and, when the zip folders changed, marks the gallery zips as stale
+func galleryImagesChanged(gallery *models.Gallery, zipsChanged bool) {
+	if zipsChanged {
+		cachestore.InvalidateGallery(gallery.PublicID)
+
+		if gallery.ZipsReady {
+			galleryQueries := queries.NewGalleryRepository()
+			if err := galleryQueries.SetZipsReady(gallery, false); err != nil {
+				log.Errorf("Unable to update ZipsReady field in gallery: %v\n", err)
+			}
+		}
+	}
+
+	if gallery.IsLive() {
+		// Set update as true if the gallery was updated while live
+		settingsQueries := queries.NewSettingsRepository()
+		if err := settingsQueries.SetSettingsUpdate(true); err != nil {
+			log.Errorf("Error setting settings update to true: %v\n", err)
+		}
+	}
+}
+
+// galleryZipEntries places the images of each set in a folder named after
+// the set, images that aren't in a set are at the root of the zip. Hidden
+// images are left out of the zip.
+func galleryZipEntries(sets []models.GallerySet, galleryImages []models.Image) []images.ZipEntry {
+	folders := make(map[uint]string, len(sets))
+	for _, set := range sets {
+		folders[set.ID] = setFolderName(set)
+	}
+
+	entries := make([]images.ZipEntry, 0, len(galleryImages))
+	for _, img := range galleryImages {
+		if img.Hidden {
+			continue
+		}
+
+		name := img.DownloadName()
+		if img.SetID != nil {
+			if folder, ok := folders[*img.SetID]; ok {
+				name = folder + "/" + name
+			}
+		}
+
+		entries = append(entries, images.ZipEntry{Filename: img.Filename, Name: name})
+	}
+
+	return entries
+}
+
+// setFolderName returns the title of the set as a safe folder name
+func setFolderName(set models.GallerySet) string {
+	name := strings.TrimSpace(strings.NewReplacer("/", "-", "\\", "-").Replace(set.Title))
+	if name == "" || name == "." || name == ".." {
+		return fmt.Sprintf("Set %d", set.ID)
+	}
+
+	return name
+}
@@ -7,7 +7,7 @@ import (
 	"github.com/austinbspencer/gshare-server/internal/queries"
 	"github.com/austinbspencer/gshare-server/pkg/auth"
 	"github.com/austinbspencer/gshare-server/pkg/configs"
-	"github.com/austinbspencer/gshare-server/platform/docker"
+	"github.com/austinbspencer/gshare-server/platform/deploy"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/log"
 )
@@ -34,6 +34,7 @@ func GetSettings(c *fiber.Ctx) error {
 	}
 
 	settings.Version = configs.Version
+	settings.Deployer = deploy.Name()
 	// Calculate uptime of the server
 	settings.Uptime = time.Since(configs.StartTime)
 
@@ -72,6 +73,11 @@ func GetPublicSettings(c *fiber.Ctx) error {
 		settings.NewApplication = &newApp
 	}
 
+	// Errors of the deploy can hold internal URLs and its paths the paths of
+	// galleries that aren't public
+	settings.DeployError = nil
+	settings.DeployPaths = ""
+
 	// Return success and the server settings
 	return c.JSON(models.APIResponse{
 		Status: "success",
@@ -124,7 +130,7 @@ func UpdateSettings(c *fiber.Ctx) error {
 	})
 }
 
-// @Description  Redeploy the site
+// @Description  Redeploy the whole client site with the deployer set by CLIENT_DEPLOYER
 // @Summary      redeploy the client site
 // @Tags         Settings
 // @Produce      json
@@ -137,23 +143,30 @@ func RedeployClient(c *fiber.Ctx) error {
 		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
 	}
 
+	// Deploy the whole site
+	log.Debugf("Deploying client with the %s deployer\n", deploy.Name())
+	if err := deploy.Deploy(); err != nil {
+		log.Errorf("Error deploying client: %v\n", err)
+		return fiber.NewError(fiber.StatusBadGateway, err.Error())
+	}
+	log.Info("Client deploy initiated by client side request")
+
 	settingsQueries := queries.NewSettingsRepository()
 
-	// Restart client container
-	log.Debug("Restarting client container")
-	err = docker.RestartClientContainer()
+	settings, err := settingsQueries.GetSettings()
 	if err != nil {
-		log.Errorf("Error restarting client container: %v\n", err)
-	} else {
-		log.Info("Client container restart initiated by client side request")
-		if err := settingsQueries.SetSettingsUpdate(false); err != nil {
-			log.Errorf("Error setting settings update to false: %v\n", err)
-		}
+		log.Errorf("Unable to retrieve settings from DB: %v\n", err)
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
 	}
 
-	// Return success
+	settings.Version = configs.Version
+	settings.Deployer = deploy.Name()
+	// Calculate uptime of the server
+	settings.Uptime = time.Since(configs.StartTime)
+
+	// Return success and the server settings
 	return c.JSON(models.APIResponse{
 		Status: "success",
-		Data:   nil,
+		Data:   settings,
 	})
 }
@@ -0,0 +1,154 @@
+package controllers
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/austinbspencer/gshare-server/internal/models"
+	"github.com/austinbspencer/gshare-server/pkg/auth"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+)
+
+// signGalleryURLs sets the pre-signed URLs of the gallery and its images
+// when the gallery requires signed URLs. The URLs are bound to the grant
+// when one is given.
+func signGalleryURLs(gallery *models.Gallery, grant string) {
+	if !gallery.SignedURLs {
+		return
+	}
+
+	setGalleryURLs(gallery, func(resource string) string {
+		return auth.SignResource(resource, grant)
+	})
+}
+
+// signImageURLs sets the pre-signed URLs of the image
+func signImageURLs(image *models.Image, grant string) {
+	setImageURLs(image, func(resource string) string {
+		return auth.SignResource(resource, grant)
+	})
+}
+
+// signGalleryPreviewURLs sets the URLs admins use to preview the gallery and
+// its images. Preview URLs work before the gallery is live, after it has
+// expired and for hidden images.
+func signGalleryPreviewURLs(gallery *models.Gallery) {
+	setGalleryURLs(gallery, signPreview)
+}
+
+// signImagePreviewURLs sets the URLs admins use to preview the image
+func signImagePreviewURLs(image *models.Image) {
+	setImageURLs(image, signPreview)
+}
+
+// signPreview signs the preview of the resource
+func signPreview(resource string) string {
+	return auth.SignResource(auth.PreviewResource(resource), "")
+}
+
+// setGalleryURLs sets the URLs of the gallery and its images with the query
+// returned by sign for each resource
+func setGalleryURLs(gallery *models.Gallery, sign func(resource string) string) {
+	query := sign(auth.GalleryResource(gallery.ID))
+	gallery.URLs = &models.SignedURLs{
+		Query:            query,
+		DownloadWeb:      fmt.Sprintf("/api/v1/download/%s/gallery/%s?%s", models.Web, gallery.PublicID, query),
+		DownloadOriginal: fmt.Sprintf("/api/v1/download/%s/gallery/%s?%s", models.Original, gallery.PublicID, query),
+	}
+
+	for idx := range gallery.Images {
+		setImageURLs(&gallery.Images[idx], sign)
+	}
+
+	if gallery.FeaturedImage.ID != 0 {
+		setImageURLs(&gallery.FeaturedImage, sign)
+	}
+}
+
+// setImageURLs sets the URLs of the image with the query returned by sign
+func setImageURLs(image *models.Image, sign func(resource string) string) {
+	query := sign(auth.ImageResource(image.ID))
+	image.URLs = &models.SignedURLs{
+		Query:            query,
+		DownloadWeb:      fmt.Sprintf("/api/v1/download/%s/image/%s?%s", models.Web, image.PublicID, query),
+		DownloadOriginal: fmt.Sprintf("/api/v1/download/%s/image/%s?%s", models.Original, image.PublicID, query),
+	}
+}
+
+// isAdminPreview checks if the request was made by an admin, either with
+// their token or with a preview URL signed for the resource
+func isAdminPreview(c *fiber.Ctx, resource string) bool {
+	if _, _, err := auth.IsAuthenticated(c); err == nil {
+		return true
+	}
+
+	return auth.VerifyResource(auth.PreviewResource(resource), c.Query("expires"), c.Query("signature"), "") == nil
+}
+
+// checkGalleryAccess enforces the access policy of the gallery on the public
+// media routes. Clients can only reach the media of a gallery while it is
+// live, and with a valid signature when the gallery requires signed URLs.
+// Admins and people given a preview link are able to preview the media at
+// any time.
+func checkGalleryAccess(c *fiber.Ctx, gallery *models.Gallery, resource string) error {
+	if isAdminPreview(c, resource) || hasPreviewLink(c, gallery, resource) {
+		return nil
+	}
+
+	if !gallery.IsLive() {
+		log.Debugf("Gallery %d isn't live for %s\n", gallery.ID, resource)
+		return fiber.NewError(fiber.StatusNotFound, "No live gallery with the given ID")
+	}
+
+	return verifySignedURL(c, gallery, resource)
+}
+
+// verifySignedURL checks the signature of the request when the gallery
+// requires signed URLs
+func verifySignedURL(c *fiber.Ctx, gallery *models.Gallery, resource string) error {
+	if !gallery.SignedURLs {
+		return nil
+	}
+
+	grant := c.Query("grant")
+
+	if err := auth.VerifyResource(resource, c.Query("expires"), c.Query("signature"), grant); err != nil {
+		log.Debugf("Invalid signature given for %s: %v\n", resource, err)
+		return fiber.NewError(fiber.StatusForbidden, err.Error())
+	}
+
+	if grant != "" {
+		if err := auth.VerifyGalleryGrant(grant, gallery.ID, galleryPasswordHash(gallery)); err != nil {
+			log.Debugf("Invalid grant given for %s: %v\n", resource, err)
+			return fiber.NewError(fiber.StatusForbidden, err.Error())
+		}
+	}
+
+	return nil
+}
+
+// setGalleryCacheTime caches the response until the gallery expires. The
+// cache is keyed by path alone, so responses of a gallery that isn't live
+// are never cached or the admin preview would be served to every client.
+func setGalleryCacheTime(c *fiber.Ctx, gallery *models.Gallery) {
+	if !gallery.IsLive() {
+		return
+	}
+
+	duration := time.Until(gallery.Expiration)
+
+	log.Debugf("Setting cache expiration time for %s to %s\n", c.Path(), time.Now().Add(duration))
+
+	c.Set("Cache-Time", fmt.Sprint(int(duration.Seconds())))
+}
+
+// galleryPasswordHash returns the password hash of the gallery that grants
+// are tied to
+func galleryPasswordHash(gallery *models.Gallery) string {
+	if gallery.Password == nil {
+		return ""
+	}
+
+	return *gallery.Password
+}
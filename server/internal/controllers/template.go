@@ -0,0 +1,273 @@
+package controllers
+
+import (
+	"strings"
+
+	"github.com/austinbspencer/gshare-server/internal/models"
+	"github.com/austinbspencer/gshare-server/internal/queries"
+	"github.com/austinbspencer/gshare-server/pkg/auth"
+	"github.com/austinbspencer/gshare-server/platform/email"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+)
+
+// @Description  Get the templates of the emails that gshare sends, changed templates have custom set.
+// @Summary      get all email templates
+// @Tags         Email Template
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Success      200        {object}  []models.EmailTemplate
+// @Router       /v1/email/templates [get]
+func GetEmailTemplates(c *fiber.Ctx) error {
+	_, _, err := auth.IsAuthenticated(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+
+	templates := []models.EmailTemplate{}
+	for _, kind := range models.EmailKinds {
+		emailTemplate, err := email.GetEmailTemplate(kind)
+		if err != nil {
+			log.Errorf("Unable to get the %s email template: %v\n", kind, err)
+			return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+		}
+
+		templates = append(templates, *emailTemplate)
+	}
+
+	// Return success and the templates
+	return c.JSON(models.APIResponse{
+		Status: "success",
+		Data:   templates,
+	})
+}
+
+// @Description  Get the template of an email.
+// @Summary      get an email template
+// @Tags         Email Template
+// @Produce      json
+// @Param        kind   path       string  true  "Email kind (two_factor, reminder, live, alert)"
+// @Security     ApiKeyAuth
+// @Success      200        {object}  models.EmailTemplate
+// @Router       /v1/email/templates/{kind} [get]
+func GetEmailTemplate(c *fiber.Ctx) error {
+	_, _, err := auth.IsAuthenticated(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+
+	emailTemplate, err := getEmailTemplate(c)
+	if err != nil || emailTemplate == nil {
+		return err
+	}
+
+	// Return success and the template
+	return c.JSON(models.APIResponse{
+		Status: "success",
+		Data:   emailTemplate,
+	})
+}
+
+// @Description  Change the template of an email. The template is rendered with sample data and is only saved when it renders.
+// @Summary      update an email template
+// @Tags         Email Template
+// @Accept       json
+// @Produce      json
+// @Param        kind   path       string  true  "Email kind (two_factor, reminder, live, alert)"
+// @Param        payload    body      models.EmailTemplateUpdate  true  "Email template changes"
+// @Security     ApiKeyAuth
+// @Success      200        {object}  models.EmailTemplate
+// @Router       /v1/email/templates/{kind} [put]
+func UpdateEmailTemplate(c *fiber.Ctx) error {
+	_, _, err := auth.IsAuthenticated(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+
+	emailTemplate, err := getEmailTemplate(c)
+	if err != nil || emailTemplate == nil {
+		return err
+	}
+
+	templateUpdate := &models.EmailTemplateUpdate{}
+
+	if err := c.BodyParser(templateUpdate); err != nil {
+		log.Errorf("Error parsing email template update: %v\n", err)
+		// Return status 400 and error message.
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Status: "fail",
+			Data: fiber.Map{
+				"template": "Invalid email template.",
+			},
+		})
+	}
+
+	applyEmailTemplateUpdate(emailTemplate, templateUpdate)
+
+	if failData := validateEmailTemplate(emailTemplate); failData != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Status: "fail",
+			Data:   failData,
+		})
+	}
+
+	templateQueries := queries.NewEmailTemplateRepository()
+
+	if err := templateQueries.SaveEmailTemplate(emailTemplate); err != nil {
+		log.Errorf("Unable to save email template in DB: %v\n", err)
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	// Return success and the template
+	return c.JSON(models.APIResponse{
+		Status: "success",
+		Data:   emailTemplate,
+	})
+}
+
+// @Description  Reset the template of an email to the template that ships with gshare.
+// @Summary      reset an email template
+// @Tags         Email Template
+// @Produce      json
+// @Param        kind   path       string  true  "Email kind (two_factor, reminder, live, alert)"
+// @Security     ApiKeyAuth
+// @Success      200        {object}  models.EmailTemplate
+// @Router       /v1/email/templates/{kind} [delete]
+func ResetEmailTemplate(c *fiber.Ctx) error {
+	_, _, err := auth.IsAuthenticated(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+
+	emailTemplate, err := getEmailTemplate(c)
+	if err != nil || emailTemplate == nil {
+		return err
+	}
+
+	templateQueries := queries.NewEmailTemplateRepository()
+
+	if err := templateQueries.DeleteEmailTemplate(emailTemplate.Kind); err != nil {
+		log.Errorf("Unable to delete email template in DB: %v\n", err)
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	shipped, err := email.ShippedEmailTemplate(emailTemplate.Kind)
+	if err != nil {
+		log.Errorf("Unable to read the shipped %s email template: %v\n", emailTemplate.Kind, err)
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	// Return success and the shipped template
+	return c.JSON(models.APIResponse{
+		Status: "success",
+		Data:   shipped,
+	})
+}
+
+// @Description  Render the template of an email with sample data. Changes sent in the body are previewed without saving them.
+// @Summary      preview an email template
+// @Tags         Email Template
+// @Accept       json
+// @Produce      json
+// @Param        kind   path       string  true  "Email kind (two_factor, reminder, live, alert)"
+// @Param        payload    body      models.EmailTemplateUpdate  false  "Email template changes to preview"
+// @Security     ApiKeyAuth
+// @Success      200        {object}  models.RenderedEmail
+// @Router       /v1/email/templates/{kind}/preview [post]
+func PreviewEmailTemplate(c *fiber.Ctx) error {
+	_, _, err := auth.IsAuthenticated(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+
+	emailTemplate, err := getEmailTemplate(c)
+	if err != nil || emailTemplate == nil {
+		return err
+	}
+
+	if len(c.Body()) > 0 {
+		templateUpdate := &models.EmailTemplateUpdate{}
+
+		if err := c.BodyParser(templateUpdate); err != nil {
+			log.Errorf("Error parsing email template preview: %v\n", err)
+			// Return status 400 and error message.
+			return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+				Status: "fail",
+				Data: fiber.Map{
+					"template": "Invalid email template.",
+				},
+			})
+		}
+
+		applyEmailTemplateUpdate(emailTemplate, templateUpdate)
+	}
+
+	rendered, err := email.RenderEmailTemplate(emailTemplate, email.SampleEmailVars(emailTemplate.Kind))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Status: "fail",
+			Data: fiber.Map{
+				"template": "Unable to render the template, " + err.Error() + ".",
+			},
+		})
+	}
+
+	// Return success and the rendered email
+	return c.JSON(models.APIResponse{
+		Status: "success",
+		Data:   rendered,
+	})
+}
+
+// getEmailTemplate returns the template of the kind in the params, the
+// response is sent when there isn't an email of the kind
+func getEmailTemplate(c *fiber.Ctx) (*models.EmailTemplate, error) {
+	// Read the param kind
+	kind := models.EmailKind(c.Params("kind"))
+
+	if !models.ValidEmailKind(kind) {
+		log.Debugf("No email of kind %s\n", kind)
+		return nil, fiber.NewError(fiber.StatusNotFound, "No email of the given kind")
+	}
+
+	emailTemplate, err := email.GetEmailTemplate(kind)
+	if err != nil {
+		log.Errorf("Unable to get the %s email template: %v\n", kind, err)
+		return nil, fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	return emailTemplate, nil
+}
+
+// applyEmailTemplateUpdate sets the parts of the template that were sent
+func applyEmailTemplateUpdate(emailTemplate *models.EmailTemplate, templateUpdate *models.EmailTemplateUpdate) {
+	if templateUpdate.Subject != nil {
+		emailTemplate.Subject = *templateUpdate.Subject
+	}
+	if templateUpdate.HTML != nil {
+		emailTemplate.HTML = *templateUpdate.HTML
+	}
+	if templateUpdate.Text != nil {
+		emailTemplate.Text = *templateUpdate.Text
+	}
+}
+
+// validateEmailTemplate checks that every part of the template is set and
+// that it renders with the sample data of the email
+func validateEmailTemplate(emailTemplate *models.EmailTemplate) fiber.Map {
+	if strings.TrimSpace(emailTemplate.Subject) == "" {
+		return fiber.Map{"subject": "Subject is required."}
+	}
+	if strings.TrimSpace(emailTemplate.HTML) == "" {
+		return fiber.Map{"html": "HTML is required."}
+	}
+	if strings.TrimSpace(emailTemplate.Text) == "" {
+		return fiber.Map{"text": "Text is required."}
+	}
+
+	if _, err := email.RenderEmailTemplate(emailTemplate, email.SampleEmailVars(emailTemplate.Kind)); err != nil {
+		return fiber.Map{"template": "Unable to render the template, " + err.Error() + "."}
+	}
+
+	return nil
+}
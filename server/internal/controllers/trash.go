@@ -0,0 +1,252 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/austinbspencer/gshare-server/internal/models"
+	"github.com/austinbspencer/gshare-server/internal/queries"
+	"github.com/austinbspencer/gshare-server/pkg/auth"
+	"github.com/austinbspencer/gshare-server/runner"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+)
+
+// @Description  Get the deleted galleries and images that can still be restored.
+// @Summary      get the galleries and images in the trash
+// @Tags         Trash
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Success      200        {object}  models.Trash
+// @Router       /v1/trash [get]
+func GetTrash(c *fiber.Ctx) error {
+	_, _, err := auth.IsAuthenticated(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+
+	galleryQueries := queries.NewGalleryRepository()
+	imageQueries := queries.NewImageRepository()
+
+	galleries, err := galleryQueries.GetTrashedGalleries()
+	if err != nil {
+		log.Errorf("Error retrieving trashed galleries from DB: %v\n", err)
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	trashedImages, err := imageQueries.GetTrashedImages()
+	if err != nil {
+		log.Errorf("Error retrieving trashed images from DB: %v\n", err)
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	trash := models.Trash{
+		Galleries:     []models.TrashedGallery{},
+		Images:        []models.TrashedImage{},
+		RetentionDays: runner.TrashRetentionDays,
+	}
+
+	for _, gallery := range galleries {
+		trash.Galleries = append(trash.Galleries, models.TrashedGallery{
+			Gallery: gallery,
+			PurgeAt: runner.TrashPurgeTime(gallery.DeletedAt.Time),
+		})
+	}
+
+	for _, image := range trashedImages {
+		trash.Images = append(trash.Images, models.TrashedImage{
+			Image:   image,
+			PurgeAt: runner.TrashPurgeTime(image.DeletedAt.Time),
+		})
+	}
+
+	// Return success and the trash
+	return c.JSON(models.APIResponse{
+		Status: "success",
+		Data:   trash,
+	})
+}
+
+// @Description  Restore a deleted gallery along with the images deleted with it.
+// @Summary      restore a gallery from the trash
+// @Tags         Trash
+// @Produce      json
+// @Param        galleryID   path       string  true  "Gallery ID"
+// @Security     ApiKeyAuth
+// @Success      200        {object}  models.Gallery
+// @Router       /v1/trash/galleries/{galleryID}/restore [post]
+func RestoreTrashedGallery(c *fiber.Ctx) error {
+	_, _, err := auth.IsAuthenticated(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+
+	// Read the param galleryID
+	galleryID := c.Params("galleryID")
+
+	galleryQueries := queries.NewGalleryRepository()
+
+	gallery, err := galleryQueries.GetTrashedGalleryByID(galleryID)
+	if err != nil || gallery == nil {
+		log.Debugf("No trashed gallery with ID %s in DB\n", galleryID)
+		return fiber.NewError(fiber.StatusNotFound, "No gallery in the trash with the given ID")
+	}
+
+	if err := galleryQueries.RestoreGallery(gallery); err != nil {
+		if errors.Is(err, queries.ErrGalleryConflict) {
+			return c.Status(fiber.StatusConflict).JSON(models.APIResponse{
+				Status: "fail",
+				Data: fiber.Map{
+					"gallery": "Another gallery is using the title or path of this gallery.",
+				},
+			})
+		}
+		log.Errorf("Unable to restore gallery in DB: %v\n", err)
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	gallery, err = galleryQueries.GetGalleryByID(galleryID)
+	if err != nil {
+		log.Errorf("Unable to retrieve restored gallery from DB: %v\n", err)
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	galleryImagesChanged(gallery, true)
+
+	// Return the restored gallery
+	return c.JSON(models.APIResponse{
+		Status: "success",
+		Data:   gallery,
+	})
+}
+
+// @Description  Permanently delete a gallery in the trash along with its images.
+// @Summary      purge a gallery from the trash
+// @Tags         Trash
+// @Produce      json
+// @Param        galleryID   path       string  true  "Gallery ID"
+// @Security     ApiKeyAuth
+// @Success      200
+// @Router       /v1/trash/galleries/{galleryID} [delete]
+func PurgeTrashedGallery(c *fiber.Ctx) error {
+	_, _, err := auth.IsAuthenticated(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+
+	// Read the param galleryID
+	galleryID := c.Params("galleryID")
+
+	galleryQueries := queries.NewGalleryRepository()
+
+	gallery, err := galleryQueries.GetTrashedGalleryByID(galleryID)
+	if err != nil || gallery == nil {
+		log.Debugf("No trashed gallery with ID %s in DB\n", galleryID)
+		return fiber.NewError(fiber.StatusNotFound, "No gallery in the trash with the given ID")
+	}
+
+	if err := runner.PurgeGallery(gallery); err != nil {
+		log.Errorf("Unable to purge gallery: %v\n", err)
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	// Return success
+	return c.JSON(models.APIResponse{
+		Status: "success",
+		Data:   "Gallery purged.",
+	})
+}
+
+// @Description  Restore a deleted image to its gallery.
+// @Summary      restore an image from the trash
+// @Tags         Trash
+// @Produce      json
+// @Param        imageID   path       string  true  "Image ID"
+// @Security     ApiKeyAuth
+// @Success      200        {object}  models.Image
+// @Router       /v1/trash/images/{imageID}/restore [post]
+func RestoreTrashedImage(c *fiber.Ctx) error {
+	_, _, err := auth.IsAuthenticated(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+
+	// Read the param imageID
+	imageID := c.Params("imageID")
+
+	imageQueries := queries.NewImageRepository()
+
+	image, err := imageQueries.GetTrashedImageByID(imageID)
+	if err != nil || image == nil {
+		log.Debugf("No trashed image with ID %s in DB\n", imageID)
+		return fiber.NewError(fiber.StatusNotFound, "No image in the trash with the given ID")
+	}
+
+	galleryQueries := queries.NewGalleryRepository()
+
+	gallery, err := galleryQueries.GetGalleryByID(fmt.Sprint(image.GalleryID))
+	if err != nil || gallery == nil {
+		return c.Status(fiber.StatusConflict).JSON(models.APIResponse{
+			Status: "fail",
+			Data: fiber.Map{
+				"gallery": "The gallery of this image is in the trash, restore the gallery first.",
+			},
+		})
+	}
+
+	if err := imageQueries.RestoreImage(image); err != nil {
+		log.Errorf("Unable to restore image in DB: %v\n", err)
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	image, err = imageQueries.GetImageByID(imageID)
+	if err != nil {
+		log.Errorf("Unable to retrieve restored image from DB: %v\n", err)
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	galleryImagesChanged(gallery, true)
+
+	// Return the restored image
+	return c.JSON(models.APIResponse{
+		Status: "success",
+		Data:   image,
+	})
+}
+
+// @Description  Permanently delete an image in the trash.
+// @Summary      purge an image from the trash
+// @Tags         Trash
+// @Produce      json
+// @Param        imageID   path       string  true  "Image ID"
+// @Security     ApiKeyAuth
+// @Success      200
+// @Router       /v1/trash/images/{imageID} [delete]
+func PurgeTrashedImage(c *fiber.Ctx) error {
+	_, _, err := auth.IsAuthenticated(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+
+	// Read the param imageID
+	imageID := c.Params("imageID")
+
+	imageQueries := queries.NewImageRepository()
+
+	image, err := imageQueries.GetTrashedImageByID(imageID)
+	if err != nil || image == nil {
+		log.Debugf("No trashed image with ID %s in DB\n", imageID)
+		return fiber.NewError(fiber.StatusNotFound, "No image in the trash with the given ID")
+	}
+
+	if err := runner.PurgeImage(image); err != nil {
+		log.Errorf("Unable to purge image: %v\n", err)
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	// Return success
+	return c.JSON(models.APIResponse{
+		Status: "success",
+		Data:   "Image purged.",
+	})
+}
@@ -0,0 +1,410 @@
+package controllers
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/austinbspencer/gshare-server/internal/models"
+	"github.com/austinbspencer/gshare-server/internal/queries"
+	"github.com/austinbspencer/gshare-server/pkg/auth"
+	"github.com/austinbspencer/gshare-server/pkg/cachestore"
+	"github.com/austinbspencer/gshare-server/pkg/utils"
+	"github.com/austinbspencer/gshare-server/platform/webhook"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+)
+
+// @Description  Get the webhooks that are sent the gallery, image, download and auth events.
+// @Summary      get all webhooks
+// @Tags         Webhook
+// @Produce      json
+// @Security     ApiKeyAuth
+// @Success      200        {object}  []models.Webhook
+// @Router       /v1/webhooks [get]
+func GetWebhooks(c *fiber.Ctx) error {
+	_, _, err := auth.IsAuthenticated(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+
+	webhookQueries := queries.NewWebhookRepository()
+
+	webhooks, err := webhookQueries.GetWebhooks()
+	if err != nil {
+		log.Errorf("Error retrieving webhooks from DB: %v\n", err)
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	// Return success and the webhooks
+	return c.JSON(models.APIResponse{
+		Status: "success",
+		Data:   webhooks,
+	})
+}
+
+// @Description  Get a webhook.
+// @Summary      get a webhook
+// @Tags         Webhook
+// @Produce      json
+// @Param        webhookID   path       string  true  "Webhook ID"
+// @Security     ApiKeyAuth
+// @Success      200        {object}  models.Webhook
+// @Router       /v1/webhooks/{webhookID} [get]
+func GetWebhook(c *fiber.Ctx) error {
+	_, _, err := auth.IsAuthenticated(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+
+	hook, err := getWebhook(c)
+	if err != nil || hook == nil {
+		return err
+	}
+
+	// Return success and the webhook
+	return c.JSON(models.APIResponse{
+		Status: "success",
+		Data:   hook,
+	})
+}
+
+// @Description  Register a webhook. The events are space separated, every event is sent when they are left empty. The secret that signs the payloads is generated and only returned in this response.
+// @Summary      create a webhook
+// @Tags         Webhook
+// @Accept       json
+// @Produce      json
+// @Param        payload    body      models.WebhookUpdate  true  "New webhook"
+// @Security     ApiKeyAuth
+// @Success      201        {object}  models.WebhookWithSecret
+// @Router       /v1/webhooks [post]
+func CreateWebhook(c *fiber.Ctx) error {
+	_, _, err := auth.IsAuthenticated(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+
+	webhookUpdate := &models.WebhookUpdate{}
+
+	if err := c.BodyParser(webhookUpdate); err != nil {
+		log.Errorf("Error parsing new webhook: %v\n", err)
+		// Return status 400 and error message.
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Status: "fail",
+			Data: fiber.Map{
+				"webhook": "Invalid webhook.",
+			},
+		})
+	}
+
+	if webhookUpdate.URL == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Status: "fail",
+			Data: fiber.Map{
+				"url": "URL is required.",
+			},
+		})
+	}
+
+	hook := &models.Webhook{
+		Secret:  utils.GenerateRandomState(nil),
+		Enabled: true,
+	}
+
+	if failData := applyWebhookUpdate(hook, webhookUpdate); failData != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Status: "fail",
+			Data:   failData,
+		})
+	}
+
+	webhookQueries := queries.NewWebhookRepository()
+
+	if err := webhookQueries.CreateWebhook(hook); err != nil {
+		log.Errorf("Unable to create webhook in DB: %v\n", err)
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	// Cached downloads would never reach the new webhook
+	webhook.InvalidateWebhooks()
+	cachestore.InvalidateDownloads()
+
+	// Return the new webhook, the only time its secret is returned along
+	// with rotating it
+	hook.MaskSecret()
+	return c.Status(fiber.StatusCreated).JSON(models.APIResponse{
+		Status: "success",
+		Data:   models.WebhookWithSecret{Webhook: *hook, Secret: hook.Secret},
+	})
+}
+
+// @Description  Update the URL, events or status of a webhook, or generate a new secret for it. The new secret is only returned in this response.
+// @Summary      update a webhook
+// @Tags         Webhook
+// @Accept       json
+// @Produce      json
+// @Param        webhookID   path       string  true  "Webhook ID"
+// @Param        payload    body      models.WebhookUpdate  true  "Webhook changes"
+// @Security     ApiKeyAuth
+// @Success      200        {object}  models.Webhook
+// @Router       /v1/webhooks/{webhookID} [put]
+func UpdateWebhook(c *fiber.Ctx) error {
+	_, _, err := auth.IsAuthenticated(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+
+	hook, err := getWebhook(c)
+	if err != nil || hook == nil {
+		return err
+	}
+
+	webhookUpdate := &models.WebhookUpdate{}
+
+	if err := c.BodyParser(webhookUpdate); err != nil {
+		log.Errorf("Error parsing webhook update: %v\n", err)
+		// Return status 400 and error message.
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Status: "fail",
+			Data: fiber.Map{
+				"webhook": "Invalid webhook.",
+			},
+		})
+	}
+
+	if failData := applyWebhookUpdate(hook, webhookUpdate); failData != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(models.APIResponse{
+			Status: "fail",
+			Data:   failData,
+		})
+	}
+
+	if webhookUpdate.RotateSecret {
+		hook.Secret = utils.GenerateRandomState(nil)
+	}
+
+	webhookQueries := queries.NewWebhookRepository()
+
+	if err := webhookQueries.UpdateWebhook(hook); err != nil {
+		log.Errorf("Unable to update webhook in DB: %v\n", err)
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	// Cached downloads would never reach the webhook if it was enabled or
+	// subscribed to downloads
+	webhook.InvalidateWebhooks()
+	cachestore.InvalidateDownloads()
+
+	hook.MaskSecret()
+
+	// Return the new secret once when it was rotated
+	if webhookUpdate.RotateSecret {
+		return c.JSON(models.APIResponse{
+			Status: "success",
+			Data:   models.WebhookWithSecret{Webhook: *hook, Secret: hook.Secret},
+		})
+	}
+
+	// Return success and the webhook
+	return c.JSON(models.APIResponse{
+		Status: "success",
+		Data:   hook,
+	})
+}
+
+// @Description  Delete a webhook along with its delivery log.
+// @Summary      delete a webhook
+// @Tags         Webhook
+// @Produce      json
+// @Param        webhookID   path       string  true  "Webhook ID"
+// @Security     ApiKeyAuth
+// @Success      200
+// @Router       /v1/webhooks/{webhookID} [delete]
+func DeleteWebhook(c *fiber.Ctx) error {
+	_, _, err := auth.IsAuthenticated(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+
+	hook, err := getWebhook(c)
+	if err != nil || hook == nil {
+		return err
+	}
+
+	webhookQueries := queries.NewWebhookRepository()
+
+	if err := webhookQueries.DeleteWebhook(hook); err != nil {
+		log.Errorf("Unable to delete webhook in DB: %v\n", err)
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	webhook.InvalidateWebhooks()
+
+	// Return success
+	return c.JSON(models.APIResponse{
+		Status: "success",
+		Data:   "Webhook removed.",
+	})
+}
+
+// @Description  Send a ping event to the webhook to test it.
+// @Summary      ping a webhook
+// @Tags         Webhook
+// @Produce      json
+// @Param        webhookID   path       string  true  "Webhook ID"
+// @Security     ApiKeyAuth
+// @Success      201        {object}  models.WebhookDelivery
+// @Router       /v1/webhooks/{webhookID}/ping [post]
+func PingWebhook(c *fiber.Ctx) error {
+	_, _, err := auth.IsAuthenticated(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+
+	hook, err := getWebhook(c)
+	if err != nil || hook == nil {
+		return err
+	}
+
+	delivery, err := webhook.Ping(hook)
+	if err != nil {
+		log.Errorf("Unable to ping webhook: %v\n", err)
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	// Return the queued delivery
+	return c.Status(fiber.StatusCreated).JSON(models.APIResponse{
+		Status: "success",
+		Data:   delivery,
+	})
+}
+
+// @Description  Get the deliveries of the webhook along with their status, most recent first.
+// @Summary      get the deliveries of a webhook
+// @Tags         Webhook
+// @Produce      json
+// @Param        webhookID   path       string  true  "Webhook ID"
+// @Param        limit    query     int     false  "Most recent deliveries returned, 100 by default"
+// @Security     ApiKeyAuth
+// @Success      200        {object}  []models.WebhookDelivery
+// @Router       /v1/webhooks/{webhookID}/deliveries [get]
+func GetWebhookDeliveries(c *fiber.Ctx) error {
+	_, _, err := auth.IsAuthenticated(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+
+	hook, err := getWebhook(c)
+	if err != nil || hook == nil {
+		return err
+	}
+
+	webhookQueries := queries.NewWebhookRepository()
+
+	deliveries, err := webhookQueries.GetWebhookDeliveries(hook.ID, c.QueryInt("limit"))
+	if err != nil {
+		log.Errorf("Error retrieving webhook deliveries from DB: %v\n", err)
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	// Return success and the deliveries
+	return c.JSON(models.APIResponse{
+		Status: "success",
+		Data:   deliveries,
+	})
+}
+
+// @Description  Post a delivery of the webhook again. A copy of the delivery is queued so the delivery log is kept.
+// @Summary      redeliver a webhook delivery
+// @Tags         Webhook
+// @Produce      json
+// @Param        webhookID    path       string  true  "Webhook ID"
+// @Param        deliveryID   path       string  true  "Delivery ID"
+// @Security     ApiKeyAuth
+// @Success      201        {object}  models.WebhookDelivery
+// @Router       /v1/webhooks/{webhookID}/deliveries/{deliveryID}/redeliver [post]
+func RedeliverWebhookDelivery(c *fiber.Ctx) error {
+	_, _, err := auth.IsAuthenticated(c)
+	if err != nil {
+		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	}
+
+	hook, err := getWebhook(c)
+	if err != nil || hook == nil {
+		return err
+	}
+
+	// Read the param deliveryID
+	deliveryID := c.Params("deliveryID")
+
+	webhookQueries := queries.NewWebhookRepository()
+
+	delivery, err := webhookQueries.GetWebhookDeliveryByID(hook.ID, deliveryID)
+	if err != nil || delivery == nil {
+		log.Debugf("No delivery with ID %s for webhook %d in DB\n", deliveryID, hook.ID)
+		return fiber.NewError(fiber.StatusNotFound, "No delivery with the given ID")
+	}
+
+	redelivery, err := webhook.Redeliver(delivery)
+	if err != nil {
+		log.Errorf("Unable to redeliver webhook delivery: %v\n", err)
+		return fiber.NewError(fiber.StatusInternalServerError, err.Error())
+	}
+
+	// Return the queued copy of the delivery
+	return c.Status(fiber.StatusCreated).JSON(models.APIResponse{
+		Status: "success",
+		Data:   redelivery,
+	})
+}
+
+// getWebhook returns the webhook in the params, the response is sent when
+// there isn't a webhook with the ID
+func getWebhook(c *fiber.Ctx) (*models.Webhook, error) {
+	// Read the param webhookID
+	webhookID := c.Params("webhookID")
+
+	webhookQueries := queries.NewWebhookRepository()
+
+	hook, err := webhookQueries.GetWebhookByID(webhookID)
+	if err != nil || hook == nil {
+		log.Debugf("No webhook with ID %s in DB\n", webhookID)
+		return nil, fiber.NewError(fiber.StatusNotFound, "No webhook with the given ID")
+	}
+
+	return hook, nil
+}
+
+// applyWebhookUpdate checks the changes to the webhook and sets them
+func applyWebhookUpdate(hook *models.Webhook, webhookUpdate *models.WebhookUpdate) fiber.Map {
+	if webhookUpdate.URL != nil {
+		address := strings.TrimSpace(*webhookUpdate.URL)
+		parsed, err := url.Parse(address)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fiber.Map{"url": "URL must be a valid http or https URL."}
+		}
+		hook.URL = address
+	}
+
+	if webhookUpdate.Events != nil {
+		seen := map[models.WebhookEvent]bool{}
+		events := []string{}
+		for _, field := range strings.Fields(*webhookUpdate.Events) {
+			event := models.WebhookEvent(strings.ToLower(field))
+			if !models.ValidWebhookEvent(event) {
+				return fiber.Map{"events": fmt.Sprintf("Unknown event %q.", field)}
+			}
+			if !seen[event] {
+				seen[event] = true
+				events = append(events, string(event))
+			}
+		}
+		hook.Events = strings.Join(events, " ")
+	}
+
+	if webhookUpdate.Enabled != nil {
+		hook.Enabled = *webhookUpdate.Enabled
+	}
+
+	return nil
+}
@@ -2,7 +2,13 @@ package models
 
 import "gorm.io/gorm"
 
+// Client is a person the galleries are shared with, a client can be a
+// contact of several galleries
 type Client struct {
 	gorm.Model
 	Email string `gorm:"unique;not null" json:"email"`
+	// Name of the client
+	Name string `gorm:"not null;default:''" json:"name"`
+	// Galleries the client is a contact of
+	Contacts []GalleryContact `json:"contacts,omitempty" gorm:"foreignKey:ClientID"`
 }
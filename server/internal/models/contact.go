@@ -0,0 +1,100 @@
+package models
+
+import (
+	"fmt"
+	"net/mail"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+type ContactRole string
+
+var (
+	ContactClient  ContactRole = "client"
+	ContactCouple  ContactRole = "couple"
+	ContactFamily  ContactRole = "family"
+	ContactPlanner ContactRole = "planner"
+	ContactOther   ContactRole = "other"
+
+	ContactRoles = []ContactRole{
+		ContactClient,
+		ContactCouple,
+		ContactFamily,
+		ContactPlanner,
+		ContactOther,
+	}
+)
+
+// GalleryContact links a client to a gallery, the contacts of a gallery get
+// its reminders and live notification
+type GalleryContact struct {
+	gorm.Model
+	// The gallery the client is a contact of
+	GalleryID uint `gorm:"not null;uniqueIndex:idx_gallery_contact" json:"gallery_id"`
+	// The client, which can be a contact of other galleries
+	ClientID uint    `gorm:"not null;uniqueIndex:idx_gallery_contact" json:"client_id"`
+	Client   *Client `json:"client,omitempty"`
+	// Role of the contact for the gallery (client, couple, family, planner, other)
+	Role ContactRole `gorm:"not null;default:'client'" json:"role"`
+}
+
+// Model to handle creating and updating a contact
+type GalleryContactUpdate struct {
+	Name  *string      `json:"name"`
+	Email *string      `json:"email"`
+	Role  *ContactRole `json:"role"`
+}
+
+const MaxContactNameLength = 100
+
+// ValidContactRole checks if the given role is a valid contact role
+func ValidContactRole(role ContactRole) bool {
+	for _, r := range ContactRoles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// NormalizeContactEmail checks the email address and returns it without the
+// display name, in lowercase
+func NormalizeContactEmail(email string) (string, error) {
+	address, err := mail.ParseAddress(strings.TrimSpace(email))
+	if err != nil {
+		return "", fmt.Errorf("invalid email address %q", email)
+	}
+
+	return strings.ToLower(address.Address), nil
+}
+
+// ParseContactEmails checks the space separated email addresses
+func ParseContactEmails(value string) ([]string, error) {
+	seen := map[string]bool{}
+	emails := []string{}
+	for _, field := range strings.Fields(value) {
+		email, err := NormalizeContactEmail(field)
+		if err != nil {
+			return nil, err
+		}
+		if !seen[email] {
+			seen[email] = true
+			emails = append(emails, email)
+		}
+	}
+
+	return emails, nil
+}
+
+// ContactEmails returns the emails of the contacts
+func ContactEmails(contacts []GalleryContact) []string {
+	emails := make([]string, 0, len(contacts))
+	for _, contact := range contacts {
+		if contact.Client != nil {
+			emails = append(emails, contact.Client.Email)
+		}
+	}
+
+	return emails
+}
@@ -0,0 +1,153 @@
+package models
+
+import (
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// DownloadSizes are the image sizes clients can download from a gallery
+type DownloadSizes string
+
+const (
+	// Clients can't download anything from the gallery
+	DownloadsDisabled DownloadSizes = "disabled"
+	// Clients can only download the web sized images
+	DownloadsWeb DownloadSizes = "web"
+	// Clients can download both the web sized and original images
+	DownloadsFull DownloadSizes = "full"
+)
+
+// DownloadKind is the type of download a client requested
+type DownloadKind string
+
+const (
+	// A single image
+	DownloadSingle DownloadKind = "single"
+	// A zip of images selected by the client
+	DownloadSelection DownloadKind = "selection"
+	// A zip of the entire gallery or one of its sets
+	DownloadGallery DownloadKind = "gallery"
+)
+
+// MaxDownloadPinLength is the longest download PIN that can be set
+const MaxDownloadPinLength = 64
+
+// Headers a client sends the download PIN and email in
+const (
+	DownloadPinHeader   = "X-Download-Pin"
+	DownloadEmailHeader = "X-Download-Email"
+)
+
+// DownloadPolicy controls what clients are able to download from a gallery
+type DownloadPolicy struct {
+	// Sizes clients can download (disabled, web, full)
+	Sizes DownloadSizes `json:"sizes" gorm:"not null;default:'full'"`
+	// If clients can download single images
+	Single bool `json:"single" gorm:"not null;default:true"`
+	// If clients can download a selection of images
+	Selection bool `json:"selection" gorm:"not null;default:true"`
+	// If clients can download the entire gallery or one of its sets
+	Gallery bool `json:"gallery" gorm:"not null;default:true"`
+	// PIN required to download, separate from the gallery password
+	// json restricted so the PIN is never sent to clients
+	Pin *string `json:"-"`
+	// If a PIN is required to download
+	PinRequired bool `json:"pin_required" gorm:"-:all"`
+	// If clients must enter their email before downloading
+	EmailRequired bool `json:"email_required" gorm:"not null;default:false"`
+	// Number of downloads allowed for each visitor, 0 is unlimited
+	Limit int `json:"limit" gorm:"not null;default:0"`
+}
+
+// Model to handle updates for the download policy
+type DownloadPolicyUpdate struct {
+	Sizes     *DownloadSizes `json:"sizes"`
+	Single    *bool          `json:"single"`
+	Selection *bool          `json:"selection"`
+	Gallery   *bool          `json:"gallery"`
+	// An empty PIN removes the PIN
+	Pin           *string `json:"pin"`
+	EmailRequired *bool   `json:"email_required"`
+	Limit         *int    `json:"limit"`
+}
+
+// GalleryDownload records a download by a visitor of a gallery that has an
+// email gate or a download limit
+type GalleryDownload struct {
+	gorm.Model
+	GalleryID uint `gorm:"not null;index" json:"gallery_id"`
+	// IP of the visitor that downloaded
+	IP string `gorm:"not null;index" json:"ip"`
+	// Email the visitor entered, if the gallery requires one
+	Email *string `gorm:"index" json:"email"`
+	// Type of download (single, selection, gallery)
+	Kind DownloadKind `gorm:"not null" json:"kind"`
+	// Size of the images downloaded (web, original)
+	Size ImageSize `gorm:"not null" json:"size"`
+}
+
+// DefaultDownloadPolicy allows every download of every size
+func DefaultDownloadPolicy() DownloadPolicy {
+	return DownloadPolicy{
+		Sizes:     DownloadsFull,
+		Single:    true,
+		Selection: true,
+		Gallery:   true,
+	}
+}
+
+// ValidDownloadSizes checks the given sizes are one of the valid options
+func ValidDownloadSizes(sizes DownloadSizes) bool {
+	switch sizes {
+	case DownloadsDisabled, DownloadsWeb, DownloadsFull:
+		return true
+	default:
+		return false
+	}
+}
+
+// AllowsSize checks if clients can download images of the given size
+func (p DownloadPolicy) AllowsSize(size ImageSize) bool {
+	switch p.Sizes {
+	case DownloadsFull:
+		return true
+	case DownloadsWeb:
+		return size == Web
+	default:
+		return false
+	}
+}
+
+// AllowsKind checks if clients can request the given type of download
+func (p DownloadPolicy) AllowsKind(kind DownloadKind) bool {
+	switch kind {
+	case DownloadSingle:
+		return p.Single
+	case DownloadSelection:
+		return p.Selection
+	case DownloadGallery:
+		return p.Gallery
+	default:
+		return false
+	}
+}
+
+// Tracked checks if the downloads of visitors need to be recorded
+func (p DownloadPolicy) Tracked() bool {
+	return p.EmailRequired || p.Limit > 0
+}
+
+// Gated checks if each download has to be checked, which means the
+// downloads can't be cached
+func (p DownloadPolicy) Gated() bool {
+	return p.Pin != nil || p.Tracked()
+}
+
+// Check if the raw PIN is a match with the encrypted version
+func (p DownloadPolicy) CheckPin(pin string) error {
+	if p.Pin == nil {
+		return nil
+	}
+
+	return bcrypt.CompareHashAndPassword([]byte(*p.Pin), []byte(pin))
+}
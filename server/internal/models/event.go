@@ -7,6 +7,8 @@ type Event struct {
 	GalleryID uint `gorm:"not null" json:"gallery_id"`
 	// Image ID is null if the event is downloading entire gallery
 	ImageID *uint `json:"image_id"`
+	// Public ID of the image, read from the images table
+	ImagePublicID *string `gorm:"->;-:migration" json:"image_public_id"`
 	// Requestor is the host that requested the download
 	Requestor string `gorm:"not null" json:"requestor"`
 	// Filename is the name of the file downloaded
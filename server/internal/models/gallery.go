@@ -2,6 +2,7 @@ package models
 
 import (
 	"errors"
+	"strings"
 	"time"
 
 	"github.com/austinbspencer/gshare-server/pkg/utils"
@@ -12,8 +13,12 @@ import (
 
 type Gallery struct {
 	gorm.Model
+	// Non-guessable ID used by the public routes
+	PublicID string `json:"public_id" gorm:"size:36;uniqueIndex"`
 	// All images in the gallery
 	Images []Image `json:"images" gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;foreignKey:GalleryID"`
+	// Sets of images within the gallery
+	Sets []GallerySet `json:"sets" gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;foreignKey:GalleryID"`
 	// Featured image for the gallery
 	FeaturedImage Image `json:"featured_image" gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;foreignKey:FeaturedGalleryID"`
 	// Title of the gallery
@@ -30,16 +35,33 @@ type Gallery struct {
 	// If the gallery is password protected
 	Protected bool `json:"protected" gorm:"not null"`
 	// If the gallery should send out an automated reminder
-	// Reminder gets sent 5 days prior to expiration
+	// Reminder gets sent the reminder days prior to expiration
 	Reminder bool `json:"reminder"`
-	// If reminders are enabled, we need to have the emails to send to
-	// These are a space separated list of emails
-	ReminderEmails *string `json:"reminder_emails,omitempty"`
+	// Contacts of the gallery that get its reminders and live notification
+	Contacts []GalleryContact `json:"contacts,omitempty" gorm:"foreignKey:GalleryID"`
+	// Space separated list of the emails of the contacts. Setting it
+	// replaces the contacts with the clients with the given emails.
+	ReminderEmails *string `json:"reminder_emails,omitempty" gorm:"-"`
+	// Space separated list of days before expiration that a reminder is
+	// sent, such as "14 5 1"
+	ReminderDays string `json:"reminder_days" gorm:"not null;default:'5'"`
 	// Password required to access the gallery
 	// json restricted so you send or receive password
 	Password *string `json:"-"`
+	// Hint for the password given to the clients in the live notification
+	PasswordHint *string `json:"password_hint,omitempty"`
+	// If the contacts are sent a notification when the gallery goes live
+	LiveNotification bool `json:"live_notification" gorm:"not null;default:false"`
+	// Date the live notification was sent, it is only sent once
+	LiveNotifiedAt *time.Time `json:"live_notified_at,omitempty"`
+	// Date the gallery was found live and the gallery.live webhook event
+	// was fired, it is only fired once for the live date
+	LiveFiredAt *time.Time `json:"live_fired_at,omitempty"`
 	// Date the gallery expires and will no longer be visible
 	Expiration time.Time `json:"expiration,omitempty" gorm:"not null"`
+	// Date the gallery was found expired and the gallery.expired webhook
+	// event was fired, it is only fired once for the expiration
+	ExpiredFiredAt *time.Time `json:"expired_fired_at,omitempty"`
 	// Total number of images in the gallery
 	ImagesCount *int64 `json:"images_count" gorm:"-:all"`
 	// ZipsReady will track if the zip files are created and up to date
@@ -49,25 +71,62 @@ type Gallery struct {
 	HeroEnabled bool `json:"hero_enabled" gorm:"not null;default:true"`
 	// HeroVariant is the display variant for the hero
 	HeroVariant int `json:"hero_variant" gorm:"not null;default:0"`
+	// If image and download URLs of the gallery must be signed
+	SignedURLs bool `json:"signed_urls" gorm:"not null;default:false"`
+	// Pre-signed URLs for the gallery downloads, set when URLs are signed
+	URLs *SignedURLs `json:"urls,omitempty" gorm:"-:all"`
+	// Controls what clients are able to download from the gallery
+	DownloadPolicy DownloadPolicy `json:"download_policy" gorm:"embedded;embeddedPrefix:download_"`
 	// All events related to this gallery
 	Events []Event `json:"events" gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;foreignKey:GalleryID"`
+	// Title and path of the gallery while it is in the trash, the title and
+	// path are released so they can be used by another gallery
+	TrashedTitle *string `json:"trashed_title,omitempty"`
+	TrashedPath  *string `json:"trashed_path,omitempty"`
+	// Date the retention policy archived the originals of the gallery and
+	// removed its other files
+	ArchivedAt *time.Time `json:"archived_at,omitempty"`
+	// Date the admin was warned that the retention policy will act on the
+	// gallery, cleared when the expiration changes
+	RetentionWarnedAt *time.Time `json:"retention_warned_at,omitempty"`
 }
 
 // Model to handle updates for the gallery
 type GalleryUpdate struct {
-	Title           *string    `json:"title"`
-	Path            *string    `json:"path"`
-	EventDate       *time.Time `json:"event_date"`
-	Live            *time.Time `json:"live"`
-	Expiration      *time.Time `json:"expiration,omitempty"`
-	Public          *bool      `json:"public"`
-	Protected       *bool      `json:"protected"`
-	Password        *string    `json:"password"`
-	FeaturedImageID *uint      `json:"featured_image_id"`
-	Reminder        *bool      `json:"reminder"`
-	ReminderEmails  *string    `json:"reminder_emails"`
-	HeroEnabled     *bool      `json:"hero_enabled"`
-	HeroVariant     *int       `json:"hero_variant"`
+	Title            *string               `json:"title"`
+	Path             *string               `json:"path"`
+	EventDate        *time.Time            `json:"event_date"`
+	Live             *time.Time            `json:"live"`
+	Expiration       *time.Time            `json:"expiration,omitempty"`
+	Public           *bool                 `json:"public"`
+	Protected        *bool                 `json:"protected"`
+	Password         *string               `json:"password"`
+	PasswordHint     *string               `json:"password_hint"`
+	LiveNotification *bool                 `json:"live_notification"`
+	FeaturedImageID  *uint                 `json:"featured_image_id"`
+	Reminder         *bool                 `json:"reminder"`
+	ReminderEmails   *string               `json:"reminder_emails"`
+	ReminderDays     *string               `json:"reminder_days"`
+	HeroEnabled      *bool                 `json:"hero_enabled"`
+	HeroVariant      *int                  `json:"hero_variant"`
+	SignedURLs       *bool                 `json:"signed_urls"`
+	DownloadPolicy   *DownloadPolicyUpdate `json:"download_policy"`
+}
+
+// Model to handle cloning a gallery into a new gallery
+type GalleryClone struct {
+	// Title and path of the new gallery
+	Title string `json:"title"`
+	Path  string `json:"path"`
+	// Dates of the new gallery, the dates of the original are kept when
+	// they aren't given
+	EventDate  *time.Time `json:"event_date"`
+	Live       *time.Time `json:"live"`
+	Expiration *time.Time `json:"expiration"`
+	// If the images and featured image are copied along with their sets
+	Images bool `json:"images"`
+	// If the sets are copied, which are empty when the images aren't
+	Sets bool `json:"sets"`
 }
 
 // Used to handle unlocking the gallery for clients
@@ -75,6 +134,55 @@ type GalleryAuth struct {
 	Password string `json:"password"`
 }
 
+// GalleryGrant gives access to the signed URLs of an unlocked gallery
+type GalleryGrant struct {
+	Grant   string    `json:"grant"`
+	Expires time.Time `json:"expires"`
+}
+
+// SignedURLs are the pre-signed URLs of an image or gallery
+type SignedURLs struct {
+	// Query string with the signature to add to any URL of the image or
+	// gallery, such as the resized image URLs
+	Query            string `json:"query"`
+	DownloadWeb      string `json:"download_web"`
+	DownloadOriginal string `json:"download_original"`
+}
+
+// Clone creates a new gallery with the settings of the gallery. The images,
+// sets, contacts and events aren't part of the clone.
+func (g *Gallery) Clone(options GalleryClone) *Gallery {
+	clone := &Gallery{
+		Title:            options.Title,
+		Path:             options.Path,
+		EventDate:        g.EventDate,
+		Live:             g.Live,
+		Public:           g.Public,
+		Protected:        g.Protected,
+		PasswordHint:     g.PasswordHint,
+		LiveNotification: g.LiveNotification,
+		Reminder:         g.Reminder,
+		ReminderDays:     g.ReminderDays,
+		Expiration:       g.Expiration,
+		HeroEnabled:      g.HeroEnabled,
+		HeroVariant:      g.HeroVariant,
+		SignedURLs:       g.SignedURLs,
+		DownloadPolicy:   g.DownloadPolicy,
+	}
+
+	if options.EventDate != nil {
+		clone.EventDate = options.EventDate
+	}
+	if options.Live != nil {
+		clone.Live = *options.Live
+	}
+	if options.Expiration != nil {
+		clone.Expiration = *options.Expiration
+	}
+
+	return clone
+}
+
 // IsLive checks if the current time is between 'Live' and
 // 'Expiration' dates
 func (g *Gallery) IsLive() bool {
@@ -82,12 +190,33 @@ func (g *Gallery) IsLive() bool {
 	return currentTime.After(g.Live) && currentTime.Before(g.Expiration)
 }
 
+// CacheDownloads checks if the images and downloads of the gallery can be
+// cached, which isn't possible when each request has to be checked
+func (g *Gallery) CacheDownloads() bool {
+	return !g.SignedURLs && !g.DownloadPolicy.Gated()
+}
+
 // IsExpired checks if the current time is after the 'Expiration' date
 func (g *Gallery) IsExpired() bool {
 	return time.Now().After(g.Expiration)
 }
 
+// GetReminderDays returns the days before expiration that reminders are
+// sent in descending order, the default days are used when they are invalid
+func (g *Gallery) GetReminderDays() []int {
+	days, err := ParseReminderDays(g.ReminderDays)
+	if err != nil {
+		days, _ = ParseReminderDays(DefaultReminderDays)
+	}
+
+	return days
+}
+
 func (g *Gallery) BeforeCreate(tx *gorm.DB) (err error) {
+	if g.PublicID == "" {
+		g.PublicID = NewPublicID()
+	}
+
 	// Set the password as a hash if it isn't nil
 	if g.Password != nil {
 		if err := g.SetPassword(); err != nil {
@@ -116,6 +245,17 @@ func (g *Gallery) BeforeUpdate(tx *gorm.DB) (err error) {
 	return
 }
 
+func (g *Gallery) AfterFind(tx *gorm.DB) (err error) {
+	g.DownloadPolicy.PinRequired = g.DownloadPolicy.Pin != nil
+
+	// The reminder emails are the emails of the contacts when they are loaded
+	if len(g.Contacts) > 0 {
+		emails := strings.Join(ContactEmails(g.Contacts), " ")
+		g.ReminderEmails = &emails
+	}
+	return
+}
+
 // Set the password as an encrypted string of the raw password
 func (g *Gallery) SetPassword() error {
 	hashedPassword, err := utils.HashPassword(*g.Password)
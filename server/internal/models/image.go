@@ -1,6 +1,9 @@
 package models
 
-import "gorm.io/gorm"
+import (
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
 
 type ImageSize string
 
@@ -8,8 +11,12 @@ type ImageSources map[ImageSize]string
 
 type Image struct {
 	gorm.Model
+	// Non-guessable ID used by the public routes
+	PublicID string `json:"public_id" gorm:"size:36;uniqueIndex"`
 	// The gallery this image is linked to
 	GalleryID uint `gorm:"not null" json:"gallery_id"`
+	// The set within the gallery this image belongs to, if any
+	SetID *uint `gorm:"index" json:"set_id"`
 	// If this image is the feature image it will have the gallery ID here
 	FeaturedGalleryID *uint `json:"featured_gallery_id,omitempty"`
 	// Size of the image in bytes
@@ -20,8 +27,100 @@ type Image struct {
 	Width int `gorm:"not null" json:"width"`
 	// Position in the gallery
 	Position int `gorm:"not null;default:0" json:"position"`
-	// Image filename
+	// Image filename, a random storage key
+	Filename string `json:"filename" gorm:"not null;unique"`
+	// Filename of the image when it was uploaded
+	OriginalFilename string `json:"original_filename" gorm:"not null;default:''"`
+	// Title of the image
+	Title string `json:"title" gorm:"not null;default:''"`
+	// Caption shown alongside the image
+	Caption string `json:"caption" gorm:"not null;default:''"`
+	// Alternative text describing the image for screen readers
+	AltText string `json:"alt_text" gorm:"not null;default:''"`
+	// Hidden images are kept in the gallery but not shown to clients
+	Hidden bool `json:"hidden" gorm:"not null;default:false"`
+	// Pre-signed URLs for the image, set when the gallery signs its URLs
+	URLs *SignedURLs `json:"urls,omitempty" gorm:"-:all"`
+	// If the image was moved to the trash along with its gallery
+	TrashedWithGallery bool `json:"-" gorm:"not null;default:false"`
+	// Free-form tags used to organize and search images
+	Tags []Tag `json:"tags" gorm:"many2many:image_tags;constraint:OnUpdate:CASCADE,OnDelete:CASCADE"`
+	// Prior originals of the image that were replaced
+	Versions []ImageVersion `json:"versions,omitempty" gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;foreignKey:ImageID"`
+}
+
+// ImageVersion is a prior original of an image that has since been replaced
+type ImageVersion struct {
+	gorm.Model
+	// The image this version belongs to
+	ImageID uint `gorm:"not null;index" json:"image_id"`
+	// Size of the original in bytes
+	Size int64 `gorm:"not null" json:"size"`
+	// Height for the original
+	Height int `gorm:"not null" json:"height"`
+	// Width for the original
+	Width int `gorm:"not null" json:"width"`
+	// Filename of the original in the gallery versions directory
 	Filename string `json:"filename" gorm:"not null;unique"`
+	// Filename of the original when it was uploaded
+	OriginalFilename string `json:"original_filename" gorm:"not null;default:''"`
+}
+
+func (i *Image) BeforeCreate(tx *gorm.DB) (err error) {
+	if i.PublicID == "" {
+		i.PublicID = NewPublicID()
+	}
+
+	return
+}
+
+// NewPublicID returns a UUIDv7 to use as the public ID of a gallery or image
+func NewPublicID() string {
+	return uuid.Must(uuid.NewV7()).String()
+}
+
+// DownloadName returns the filename clients receive the image as
+func (i *Image) DownloadName() string {
+	if i.OriginalFilename != "" {
+		return i.OriginalFilename
+	}
+
+	return i.Filename
+}
+
+// Model to handle updates for the image details
+type ImageUpdate struct {
+	Title   *string `json:"title"`
+	Caption *string `json:"caption"`
+	AltText *string `json:"alt_text"`
+	Hidden  *bool   `json:"hidden"`
+	// Replaces all of the tags on the image
+	Tags *[]string `json:"tags"`
+	// Tags to add to or remove from the existing tags
+	AddTags    []string `json:"add_tags"`
+	RemoveTags []string `json:"remove_tags"`
+}
+
+// Model to handle applying the same update to several images
+type ImagesUpdate struct {
+	IDs []uint `json:"ids"`
+	ImageUpdate
+}
+
+// Model to handle moving or copying images to another gallery
+type ImagesTransfer struct {
+	IDs       []uint `json:"ids"`
+	GalleryID uint   `json:"gallery_id"`
+}
+
+// Filters used to search images
+type ImageSearch struct {
+	// Matches the title, caption, alt text or filename
+	Query     string   `query:"q"`
+	Tags      []string `query:"tag"`
+	GalleryID *uint    `query:"gallery_id"`
+	// Limits the search to the given images
+	IDs []uint `query:"-"`
 }
 
 var (
@@ -39,6 +138,13 @@ var (
 	}
 )
 
+const (
+	MaxImageTitleLength   = 255
+	MaxImageCaptionLength = 2000
+	MaxImageAltTextLength = 1000
+	MaxImageTagLength     = 64
+)
+
 // ValidImageSize checks if the given size is a valid image size
 func ValidImageSize(size ImageSize) bool {
 	for _, s := range AllSizes {
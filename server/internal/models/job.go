@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+type JobStatus string
+
+var (
+	JobRunning   JobStatus = "running"
+	JobCompleted JobStatus = "completed"
+	JobFailed    JobStatus = "failed"
+)
+
+// ReprocessJob tracks the progress of rebuilding image derivatives
+type ReprocessJob struct {
+	// Random identifier used to look up the job progress
+	ID string `json:"id"`
+	// Gallery being reprocessed; nil when every gallery is reprocessed
+	GalleryID *uint `json:"gallery_id"`
+	// Status of the job (running, completed, failed)
+	Status JobStatus `json:"status"`
+	// Total number of images to reprocess
+	Total int `json:"total"`
+	// Number of images processed so far, including failures
+	Processed int `json:"processed"`
+	// Number of images that failed to reprocess
+	Failed int `json:"failed"`
+	// Errors encountered for the failed images
+	Errors     []string   `json:"errors"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+}
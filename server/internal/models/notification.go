@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// LiveNotification tracks the live notification email sent to a single
+// contact of the gallery
+type LiveNotification struct {
+	gorm.Model
+	// The gallery the notification is for
+	GalleryID uint `gorm:"not null;index" json:"gallery_id"`
+	// Recipient of the notification
+	Email string `gorm:"not null" json:"email"`
+	// The email of the notification in the outbox
+	OutboxEmailID *uint `gorm:"index" json:"outbox_email_id"`
+	// Status of the email in the outbox (pending, sent, failed)
+	Status OutboxStatus `gorm:"not null" json:"status"`
+	// Error of the last attempt that failed
+	LastError *string    `json:"last_error"`
+	SentAt    *time.Time `json:"sent_at"`
+}
@@ -0,0 +1,97 @@
+package models
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type OutboxStatus string
+
+var (
+	OutboxPending OutboxStatus = "pending"
+	OutboxSent    OutboxStatus = "sent"
+	OutboxFailed  OutboxStatus = "failed"
+)
+
+type EmailKind string
+
+var (
+	EmailTwoFactor EmailKind = "two_factor"
+	EmailReminder  EmailKind = "reminder"
+	EmailLive      EmailKind = "live"
+	EmailAlert     EmailKind = "alert"
+)
+
+// OutboxEmail is an email waiting to be sent by the outbox, once it is sent
+// or has failed it stays as the delivery log of the email
+type OutboxEmail struct {
+	gorm.Model
+	// What the email was sent for (two_factor, reminder, live, alert)
+	Kind EmailKind `gorm:"not null;index" json:"kind"`
+	// Space separated recipients of the email
+	To      string `gorm:"not null" json:"to"`
+	Subject string `gorm:"not null" json:"subject"`
+	// The whole message with its headers, left out of the responses as it
+	// can hold 2FA codes
+	Message []byte `gorm:"not null" json:"-"`
+	// Status of the email (pending, sent, failed)
+	Status OutboxStatus `gorm:"not null;default:'pending';index" json:"status"`
+	// Number of times sending was tried
+	Attempts int `gorm:"not null;default:0" json:"attempts"`
+	// When the pending email is sent next
+	NextAttemptAt time.Time `gorm:"not null;index" json:"next_attempt_at"`
+	// Error of the last attempt that failed
+	LastError *string    `json:"last_error"`
+	SentAt    *time.Time `json:"sent_at"`
+	// When the email is no longer worth sending, such as a 2FA code that
+	// was never delivered
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// ErrOutboxEmailExpired is the error of an email that expired before it
+// could be sent
+var ErrOutboxEmailExpired = errors.New("the email expired before it was sent")
+
+// RecordAttempt records the result of trying to send the email at now. A
+// failed email is tried again after the retry delay until it has been tried
+// maxAttempts times, and an expired email isn't tried again.
+func (e *OutboxEmail) RecordAttempt(sendErr error, now time.Time, maxAttempts int, retryDelay func(attempts int) time.Duration) {
+	e.Attempts++
+
+	if sendErr == nil {
+		e.SentAt = &now
+		e.Status = OutboxSent
+		return
+	}
+
+	lastError := sendErr.Error()
+	e.LastError = &lastError
+
+	nextAttemptAt := now.Add(retryDelay(e.Attempts))
+	if e.Attempts >= maxAttempts || (e.ExpiresAt != nil && nextAttemptAt.After(*e.ExpiresAt)) {
+		e.Status = OutboxFailed
+		return
+	}
+
+	e.NextAttemptAt = nextAttemptAt
+}
+
+// Expired reports if the pending email expired before it could be sent
+func (e *OutboxEmail) Expired(now time.Time) bool {
+	return e.ExpiresAt != nil && now.After(*e.ExpiresAt)
+}
+
+// Model to filter the emails of the outbox
+type OutboxSearch struct {
+	Status OutboxStatus `query:"status"`
+	Kind   EmailKind    `query:"kind"`
+	// Most recent emails returned, defaults to DefaultOutboxLimit
+	Limit int `query:"limit"`
+}
+
+const (
+	DefaultOutboxLimit = 100
+	MaxOutboxLimit     = 1000
+)
@@ -0,0 +1,135 @@
+package models_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/austinbspencer/gshare-server/internal/models"
+)
+
+func TestOutboxEmailRecordAttempt(t *testing.T) {
+	now := time.Date(2026, 6, 15, 3, 0, 0, 0, time.UTC)
+	retryDelay := func(attempts int) time.Duration { return time.Duration(attempts) * time.Minute }
+	sendErr := errors.New("connection refused")
+	inMinutes := func(minutes int) *time.Time {
+		date := now.Add(time.Duration(minutes) * time.Minute)
+		return &date
+	}
+
+	tests := []struct {
+		name          string
+		attempts      int
+		expiresAt     *time.Time
+		sendErr       error
+		status        models.OutboxStatus
+		nextAttemptAt time.Time
+	}{
+		{
+			name:   "sent on the first attempt",
+			status: models.OutboxSent,
+		},
+		{
+			name:     "sent after retries",
+			attempts: 3,
+			status:   models.OutboxSent,
+		},
+		{
+			name:          "retried after a failure",
+			sendErr:       sendErr,
+			status:        models.OutboxPending,
+			nextAttemptAt: now.Add(time.Minute),
+		},
+		{
+			name:          "retried later after more failures",
+			attempts:      2,
+			sendErr:       sendErr,
+			status:        models.OutboxPending,
+			nextAttemptAt: now.Add(3 * time.Minute),
+		},
+		{
+			name:     "failed after the last attempt",
+			attempts: 4,
+			sendErr:  sendErr,
+			status:   models.OutboxFailed,
+		},
+		{
+			name:          "retried before it expires",
+			expiresAt:     inMinutes(10),
+			sendErr:       sendErr,
+			status:        models.OutboxPending,
+			nextAttemptAt: now.Add(time.Minute),
+		},
+		{
+			name:      "failed when the retry is after it expires",
+			attempts:  1,
+			expiresAt: inMinutes(1),
+			sendErr:   sendErr,
+			status:    models.OutboxFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outboxEmail := models.OutboxEmail{
+				Status:        models.OutboxPending,
+				Attempts:      tt.attempts,
+				NextAttemptAt: now,
+				ExpiresAt:     tt.expiresAt,
+			}
+
+			outboxEmail.RecordAttempt(tt.sendErr, now, 5, retryDelay)
+
+			if outboxEmail.Attempts != tt.attempts+1 {
+				t.Errorf("Attempts = %d, want: %d", outboxEmail.Attempts, tt.attempts+1)
+			}
+			if outboxEmail.Status != tt.status {
+				t.Errorf("Status = %s, want: %s", outboxEmail.Status, tt.status)
+			}
+
+			if tt.sendErr == nil {
+				if outboxEmail.SentAt == nil || !outboxEmail.SentAt.Equal(now) {
+					t.Errorf("SentAt = %v, want: %s", outboxEmail.SentAt, now)
+				}
+				if outboxEmail.LastError != nil {
+					t.Errorf("LastError = %q, want: nil", *outboxEmail.LastError)
+				}
+				return
+			}
+
+			if outboxEmail.LastError == nil || *outboxEmail.LastError != tt.sendErr.Error() {
+				t.Errorf("LastError = %v, want: %q", outboxEmail.LastError, tt.sendErr.Error())
+			}
+			if outboxEmail.SentAt != nil {
+				t.Errorf("SentAt = %s, want: nil", outboxEmail.SentAt)
+			}
+			if tt.status == models.OutboxPending && !outboxEmail.NextAttemptAt.Equal(tt.nextAttemptAt) {
+				t.Errorf("NextAttemptAt = %s, want: %s", outboxEmail.NextAttemptAt, tt.nextAttemptAt)
+			}
+		})
+	}
+}
+
+func TestOutboxEmailExpired(t *testing.T) {
+	now := time.Date(2026, 6, 15, 3, 0, 0, 0, time.UTC)
+	before, after := now.Add(-time.Second), now.Add(time.Second)
+
+	tests := []struct {
+		name      string
+		expiresAt *time.Time
+		expired   bool
+	}{
+		{"without an expiration", nil, false},
+		{"before it expires", &after, false},
+		{"after it expired", &before, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outboxEmail := models.OutboxEmail{ExpiresAt: tt.expiresAt}
+			if expired := outboxEmail.Expired(now); expired != tt.expired {
+				t.Errorf("Expired() = %t, want: %t", expired, tt.expired)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package models
+
+import (
+	"gorm.io/gorm"
+)
+
+// MaxPresetExpirationDays is the longest relative expiration a preset can set
+const MaxPresetExpirationDays = 3650
+
+// GalleryPreset holds the settings new galleries start with so they don't
+// have to be entered again for every gallery
+type GalleryPreset struct {
+	gorm.Model
+	// Name of the preset
+	Name string `json:"name" gorm:"unique;not null"`
+	// If the preset is used for new galleries that don't name a preset
+	Default bool `json:"default" gorm:"column:is_default;not null;default:false"`
+	// Days after the live date that the gallery expires, 0 leaves the
+	// expiration to be given with the gallery
+	ExpirationDays int `json:"expiration_days" gorm:"not null;default:0"`
+	// Settings given to the gallery
+	Public           bool           `json:"public" gorm:"not null;default:false"`
+	Protected        bool           `json:"protected" gorm:"not null;default:false"`
+	Reminder         bool           `json:"reminder" gorm:"not null;default:false"`
+	ReminderEmails   *string        `json:"reminder_emails,omitempty"`
+	ReminderDays     string         `json:"reminder_days" gorm:"not null;default:'5'"`
+	LiveNotification bool           `json:"live_notification" gorm:"not null;default:false"`
+	HeroEnabled      bool           `json:"hero_enabled" gorm:"not null;default:true"`
+	HeroVariant      int            `json:"hero_variant" gorm:"not null;default:0"`
+	SignedURLs       bool           `json:"signed_urls" gorm:"not null;default:false"`
+	DownloadPolicy   DownloadPolicy `json:"download_policy" gorm:"embedded;embeddedPrefix:download_"`
+}
+
+// Model to handle creating and updating a preset
+type GalleryPresetUpdate struct {
+	Name             *string               `json:"name"`
+	Default          *bool                 `json:"default"`
+	ExpirationDays   *int                  `json:"expiration_days"`
+	Public           *bool                 `json:"public"`
+	Protected        *bool                 `json:"protected"`
+	Reminder         *bool                 `json:"reminder"`
+	ReminderEmails   *string               `json:"reminder_emails"`
+	ReminderDays     *string               `json:"reminder_days"`
+	LiveNotification *bool                 `json:"live_notification"`
+	HeroEnabled      *bool                 `json:"hero_enabled"`
+	HeroVariant      *int                  `json:"hero_variant"`
+	SignedURLs       *bool                 `json:"signed_urls"`
+	DownloadPolicy   *DownloadPolicyUpdate `json:"download_policy"`
+}
+
+// Options for creating a gallery that aren't stored on the gallery
+type GalleryCreateOptions struct {
+	// Preset the gallery starts with, the default preset is used when not given
+	PresetID *uint `json:"preset_id"`
+}
+
+// DefaultGalleryPreset has the settings of a gallery created without a preset
+func DefaultGalleryPreset() GalleryPreset {
+	return GalleryPreset{
+		ReminderDays:   DefaultReminderDays,
+		HeroEnabled:    true,
+		DownloadPolicy: DefaultDownloadPolicy(),
+	}
+}
+
+func (p *GalleryPreset) AfterFind(tx *gorm.DB) (err error) {
+	p.DownloadPolicy.PinRequired = p.DownloadPolicy.Pin != nil
+	return
+}
+
+// NewGallery creates a gallery with the settings of the preset
+func (p *GalleryPreset) NewGallery() *Gallery {
+	gallery := &Gallery{
+		Public:           p.Public,
+		Protected:        p.Protected,
+		Reminder:         p.Reminder,
+		ReminderDays:     p.ReminderDays,
+		LiveNotification: p.LiveNotification,
+		HeroEnabled:      p.HeroEnabled,
+		HeroVariant:      p.HeroVariant,
+		SignedURLs:       p.SignedURLs,
+		DownloadPolicy:   p.DownloadPolicy,
+	}
+
+	if p.ReminderEmails != nil {
+		emails := *p.ReminderEmails
+		gallery.ReminderEmails = &emails
+	}
+
+	return gallery
+}
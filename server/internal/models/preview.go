@@ -0,0 +1,56 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// PreviewTokenHeader is the header the token of a preview link is sent in
+const PreviewTokenHeader = "X-Preview-Token"
+
+// PreviewLink lets a specific person view a gallery before it is live or
+// after it has expired, without making the gallery public
+type PreviewLink struct {
+	gorm.Model
+	GalleryID uint `gorm:"not null;index" json:"gallery_id"`
+	// Name of the person the link was created for
+	Name string `gorm:"not null" json:"name"`
+	// If the person can favorite images of the gallery
+	AllowFavorites bool `gorm:"not null;default:false" json:"allow_favorites"`
+	// Time the link stops working
+	Expiration time.Time `gorm:"not null" json:"expiration"`
+	// Time the link was revoked by an admin
+	RevokedAt *time.Time `json:"revoked_at"`
+	// Signed token of the link, only sent to admins
+	Token string `gorm:"-:all" json:"token,omitempty"`
+	// Client page of the link to share, only sent to admins
+	URL       string            `gorm:"-:all" json:"url,omitempty"`
+	Favorites []PreviewFavorite `json:"favorites"`
+}
+
+// PreviewFavorite is an image favorited through a preview link
+type PreviewFavorite struct {
+	ID            uint      `gorm:"primarykey" json:"ID"`
+	CreatedAt     time.Time `json:"CreatedAt"`
+	PreviewLinkID uint      `gorm:"not null;uniqueIndex:idx_preview_favorite" json:"preview_link_id"`
+	ImageID       uint      `gorm:"not null;uniqueIndex:idx_preview_favorite" json:"image_id"`
+}
+
+// Model to handle creating a preview link
+type PreviewLinkCreate struct {
+	Name           string     `json:"name"`
+	AllowFavorites bool       `json:"allow_favorites"`
+	Expiration     *time.Time `json:"expiration"`
+}
+
+// GalleryPreview is the gallery shown through a preview link
+type GalleryPreview struct {
+	Gallery *Gallery     `json:"gallery"`
+	Preview *PreviewLink `json:"preview"`
+}
+
+// IsActive checks the link hasn't been revoked or expired
+func (p *PreviewLink) IsActive() bool {
+	return p.RevokedAt == nil && time.Now().Before(p.Expiration)
+}
@@ -0,0 +1,90 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ReminderStatus string
+
+var (
+	// The reminder is due or being retried
+	ReminderPending ReminderStatus = "pending"
+	// The reminder is in the outbox waiting to be sent
+	ReminderQueued ReminderStatus = "queued"
+	ReminderSent   ReminderStatus = "sent"
+	// The reminder wasn't sent after every attempt
+	ReminderFailed ReminderStatus = "failed"
+	// The reminder was missed and a later reminder was sent instead
+	ReminderSkipped ReminderStatus = "skipped"
+)
+
+// GalleryReminder tracks the reminder email sent to a single recipient so
+// each reminder is only sent once
+type GalleryReminder struct {
+	gorm.Model
+	// The gallery the reminder is for
+	GalleryID uint `gorm:"not null;uniqueIndex:idx_gallery_reminder" json:"gallery_id"`
+	// Days before the expiration the reminder is sent
+	Days int `gorm:"not null;uniqueIndex:idx_gallery_reminder" json:"days"`
+	// Recipient of the reminder
+	Email string `gorm:"not null;uniqueIndex:idx_gallery_reminder" json:"email"`
+	// Status of the reminder (pending, queued, sent, failed, skipped)
+	Status ReminderStatus `gorm:"not null" json:"status"`
+	// The email of the reminder in the outbox
+	OutboxEmailID *uint `gorm:"index" json:"outbox_email_id"`
+	// Number of times sending the reminder was attempted
+	Attempts int `gorm:"not null;default:0" json:"attempts"`
+	// Error from the last failed attempt
+	LastError *string    `json:"last_error"`
+	SentAt    *time.Time `json:"sent_at"`
+}
+
+const (
+	// DefaultReminderDays are the reminder offsets of a gallery when none
+	// are given
+	DefaultReminderDays = "5"
+	MaxReminderDays     = 365
+)
+
+// ParseReminderDays parses the space separated days before expiration that
+// reminders are sent, in descending order
+func ParseReminderDays(value string) ([]int, error) {
+	fields := strings.Fields(value)
+	if len(fields) == 0 {
+		return nil, errors.New("at least one day is expected")
+	}
+
+	seen := map[int]bool{}
+	days := make([]int, 0, len(fields))
+	for _, field := range fields {
+		day, err := strconv.Atoi(field)
+		if err != nil || day < 1 || day > MaxReminderDays {
+			return nil, fmt.Errorf("days must be whole numbers between 1 and %d", MaxReminderDays)
+		}
+		if !seen[day] {
+			seen[day] = true
+			days = append(days, day)
+		}
+	}
+
+	sort.Sort(sort.Reverse(sort.IntSlice(days)))
+
+	return days, nil
+}
+
+// FormatReminderDays joins the days with spaces
+func FormatReminderDays(days []int) string {
+	fields := make([]string, 0, len(days))
+	for _, day := range days {
+		fields = append(fields, strconv.Itoa(day))
+	}
+
+	return strings.Join(fields, " ")
+}
@@ -0,0 +1,74 @@
+package models
+
+import "time"
+
+type RetentionAction string
+
+var (
+	// Delete the gallery and its files for good, skipping the trash
+	RetentionDelete RetentionAction = "delete"
+	// Keep the originals in a single archive and remove the other files
+	RetentionArchive RetentionAction = "archive"
+)
+
+// RetentionReport is what the retention job does with the expired galleries
+type RetentionReport struct {
+	// Days after expiration that galleries are kept, 0 disables the
+	// retention policy
+	Days int `json:"days"`
+	// Action taken once a gallery is past the retention days
+	Action RetentionAction `json:"action"`
+	// Days before the action that the admin is warned
+	WarningDays int `json:"warning_days"`
+	// Galleries that are due, or will be within the warning days
+	Galleries []RetainedGallery `json:"galleries"`
+}
+
+// RetainedGallery is an expired gallery along with when the retention
+// action is taken
+type RetainedGallery struct {
+	GalleryID  uint      `json:"gallery_id"`
+	Title      string    `json:"title"`
+	Expiration time.Time `json:"expiration"`
+	// When the retention action is taken
+	RetainUntil time.Time `json:"retain_until"`
+	// When the admin was warned about the gallery
+	WarnedAt *time.Time `json:"warned_at"`
+	// If the admin is warned on the next run of the job
+	Warn bool `json:"warn"`
+	// If the action is taken on the next run of the job
+	Due bool `json:"due"`
+	// Size of the gallery files on disk in bytes
+	Size int64 `json:"size"`
+}
+
+// RetainGallery works out when the retention action is taken on the expired
+// gallery. The admin is warned before the action, and the action waits at
+// least the warning days after the warning was sent.
+func (r RetentionReport) RetainGallery(gallery Gallery, now time.Time) RetainedGallery {
+	retainUntil := gallery.Expiration.AddDate(0, 0, r.Days)
+	warn := false
+
+	if r.WarningDays > 0 {
+		warnedAt := now
+		if gallery.RetentionWarnedAt != nil {
+			warnedAt = *gallery.RetentionWarnedAt
+		} else {
+			warn = true
+		}
+
+		if earliest := warnedAt.AddDate(0, 0, r.WarningDays); earliest.After(retainUntil) {
+			retainUntil = earliest
+		}
+	}
+
+	return RetainedGallery{
+		GalleryID:   gallery.ID,
+		Title:       gallery.Title,
+		Expiration:  gallery.Expiration,
+		RetainUntil: retainUntil,
+		WarnedAt:    gallery.RetentionWarnedAt,
+		Warn:        warn,
+		Due:         !retainUntil.After(now),
+	}
+}
@@ -0,0 +1,103 @@
+package models_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/austinbspencer/gshare-server/internal/models"
+)
+
+func TestRetainGallery(t *testing.T) {
+	now := time.Date(2026, 6, 15, 3, 0, 0, 0, time.UTC)
+	daysAgo := func(days int) *time.Time {
+		date := now.AddDate(0, 0, -days)
+		return &date
+	}
+
+	tests := []struct {
+		name        string
+		warningDays int
+		expiredDays int
+		warnedAt    *time.Time
+		retainUntil time.Time
+		warn        bool
+		due         bool
+	}{
+		{
+			name:        "warned first when due without a warning",
+			warningDays: 7,
+			expiredDays: 40,
+			retainUntil: now.AddDate(0, 0, 7),
+			warn:        true,
+		},
+		{
+			name:        "warned within the warning days",
+			warningDays: 7,
+			expiredDays: 25,
+			retainUntil: now.AddDate(0, 0, 7),
+			warn:        true,
+		},
+		{
+			name:        "waits the warning days after the warning",
+			warningDays: 7,
+			expiredDays: 40,
+			warnedAt:    daysAgo(3),
+			retainUntil: now.AddDate(0, 0, 4),
+		},
+		{
+			name:        "due once the warning is old enough",
+			warningDays: 7,
+			expiredDays: 30,
+			warnedAt:    daysAgo(7),
+			retainUntil: now,
+			due:         true,
+		},
+		{
+			name:        "due at the retention days after an early warning",
+			warningDays: 7,
+			expiredDays: 35,
+			warnedAt:    daysAgo(20),
+			retainUntil: now.AddDate(0, 0, -5),
+			due:         true,
+		},
+		{
+			name:        "not due before the retention days",
+			warningDays: 7,
+			expiredDays: 27,
+			warnedAt:    daysAgo(8),
+			retainUntil: now.AddDate(0, 0, 3),
+		},
+		{
+			name:        "due without warnings",
+			warningDays: 0,
+			expiredDays: 31,
+			retainUntil: now.AddDate(0, 0, -1),
+			due:         true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, action := range []models.RetentionAction{models.RetentionArchive, models.RetentionDelete} {
+				report := models.RetentionReport{Days: 30, Action: action, WarningDays: tt.warningDays}
+				gallery := models.Gallery{
+					Title:             "Wedding",
+					Expiration:        now.AddDate(0, 0, -tt.expiredDays),
+					RetentionWarnedAt: tt.warnedAt,
+				}
+
+				retained := report.RetainGallery(gallery, now)
+
+				if !retained.RetainUntil.Equal(tt.retainUntil) {
+					t.Errorf("%s: RetainUntil = %s, want: %s", action, retained.RetainUntil, tt.retainUntil)
+				}
+				if retained.Warn != tt.warn {
+					t.Errorf("%s: Warn = %t, want: %t", action, retained.Warn, tt.warn)
+				}
+				if retained.Due != tt.due {
+					t.Errorf("%s: Due = %t, want: %t", action, retained.Due, tt.due)
+				}
+			}
+		})
+	}
+}
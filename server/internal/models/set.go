@@ -0,0 +1,35 @@
+package models
+
+import "gorm.io/gorm"
+
+// GallerySet is a named sub-collection of images within a gallery
+type GallerySet struct {
+	gorm.Model
+	// The gallery this set belongs to
+	GalleryID uint `gorm:"not null;uniqueIndex:idx_gallery_set_title" json:"gallery_id"`
+	// Title of the set, also used as the folder name in gallery zips
+	Title string `gorm:"not null;uniqueIndex:idx_gallery_set_title" json:"title"`
+	// Position of the set in the gallery
+	Position int `gorm:"not null;default:0" json:"position"`
+}
+
+// Model to handle creating and updating a set
+type GallerySetUpdate struct {
+	Title *string `json:"title"`
+}
+
+// Model to handle ordering the gallery sets and the images within them
+type GalleryImagesOrder struct {
+	// Images that aren't in a set, in order
+	Images []uint `json:"images"`
+	// Sets in order along with their images
+	Sets []GallerySetImages `json:"sets"`
+}
+
+// The images of a set in order
+type GallerySetImages struct {
+	SetID  uint   `json:"set_id"`
+	Images []uint `json:"images"`
+}
+
+const MaxGallerySetTitleLength = 100
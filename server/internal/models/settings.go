@@ -6,10 +6,31 @@ import (
 	"gorm.io/gorm"
 )
 
+type DeployStatus string
+
+var (
+	DeploySuccess DeployStatus = "success"
+	DeployFailed  DeployStatus = "failed"
+	// The deployer is none so the site wasn't deployed
+	DeploySkipped DeployStatus = "skipped"
+)
+
 type Settings struct {
 	gorm.Model
 	// Update is a flag that indicates if the client has backend updates to catch up on.
 	Update bool `json:"update" gorm:"default:false"`
+	// Deployer that updates the client site, set with CLIENT_DEPLOYER
+	Deployer string `json:"deployer,omitempty" gorm:"-:all"`
+	// Status of the last deploy of the client site (success, failed, skipped)
+	DeployStatus DeployStatus `json:"deploy_status" gorm:"not null;default:''"`
+	// Space separated paths of the last deploy, empty when the whole site was deployed
+	DeployPaths string `json:"deploy_paths" gorm:"not null;default:''"`
+	// Error of the last deploy when it failed
+	DeployError *string    `json:"deploy_error,omitempty"`
+	DeployedAt  *time.Time `json:"deployed_at"`
+	// Raised every time image derivatives are rebuilt, so servers drop the
+	// cached resizes and downloads even when another process rebuilt them
+	DerivativesVersion int `json:"-" gorm:"not null;default:0"`
 	// NewApplication will alert the frontend that there are no users and we need to create admin
 	NewApplication *bool `json:"new_application,omitempty" gorm:"-:all"`
 	// Server uptime
@@ -0,0 +1,10 @@
+package models
+
+import "gorm.io/gorm"
+
+// Tag is a free-form label that can be added to images
+type Tag struct {
+	gorm.Model
+	// Name of the tag, stored lowercase
+	Name string `json:"name" gorm:"not null;unique"`
+}
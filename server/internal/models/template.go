@@ -0,0 +1,51 @@
+package models
+
+import "gorm.io/gorm"
+
+// EmailKinds are the emails that gshare sends, each has its own template
+var EmailKinds = []EmailKind{
+	EmailTwoFactor,
+	EmailReminder,
+	EmailLive,
+	EmailAlert,
+}
+
+// EmailTemplate is the template of an email, the templates shipped in the
+// templates directory are used until they are changed
+type EmailTemplate struct {
+	gorm.Model
+	// The email the template is for (two_factor, reminder, live, alert)
+	Kind EmailKind `gorm:"not null;uniqueIndex" json:"kind"`
+	// Template of the subject line
+	Subject string `gorm:"not null" json:"subject"`
+	// HTML template of the email, the variables are escaped
+	HTML string `gorm:"not null" json:"html"`
+	// Plain text template of the email for clients that don't show HTML
+	Text string `gorm:"not null" json:"text"`
+	// If the template was changed from the shipped template
+	Custom bool `gorm:"-" json:"custom"`
+}
+
+// Model to handle updating and previewing a template
+type EmailTemplateUpdate struct {
+	Subject *string `json:"subject"`
+	HTML    *string `json:"html"`
+	Text    *string `json:"text"`
+}
+
+// RenderedEmail is an email template rendered with its variables
+type RenderedEmail struct {
+	Subject string `json:"subject"`
+	HTML    string `json:"html"`
+	Text    string `json:"text"`
+}
+
+// ValidEmailKind checks if the given kind is an email that gshare sends
+func ValidEmailKind(kind EmailKind) bool {
+	for _, k := range EmailKinds {
+		if k == kind {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// Trash holds the galleries and images that were deleted but not yet purged
+type Trash struct {
+	Galleries []TrashedGallery `json:"galleries"`
+	Images    []TrashedImage   `json:"images"`
+	// Days that deleted items are kept before being purged, 0 keeps them
+	// until they are purged manually
+	RetentionDays int `json:"retention_days"`
+}
+
+// TrashedGallery is a deleted gallery along with when it will be purged
+type TrashedGallery struct {
+	Gallery
+	PurgeAt *time.Time `json:"purge_at"`
+}
+
+// TrashedImage is a deleted image along with when it will be purged
+type TrashedImage struct {
+	Image
+	PurgeAt *time.Time `json:"purge_at"`
+}
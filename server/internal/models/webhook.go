@@ -0,0 +1,198 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type WebhookEvent string
+
+var (
+	WebhookGalleryCreated  WebhookEvent = "gallery.created"
+	WebhookGalleryUpdated  WebhookEvent = "gallery.updated"
+	WebhookGalleryDeleted  WebhookEvent = "gallery.deleted"
+	WebhookGalleryLive     WebhookEvent = "gallery.live"
+	WebhookGalleryExpired  WebhookEvent = "gallery.expired"
+	WebhookImageUploaded   WebhookEvent = "image.uploaded"
+	WebhookImageDeleted    WebhookEvent = "image.deleted"
+	WebhookDownloadImage   WebhookEvent = "download.image"
+	WebhookDownloadImages  WebhookEvent = "download.images"
+	WebhookDownloadGallery WebhookEvent = "download.gallery"
+	WebhookDownloadSet     WebhookEvent = "download.set"
+	WebhookFavoriteAdded   WebhookEvent = "favorite.added"
+	WebhookFavoriteRemoved WebhookEvent = "favorite.removed"
+	WebhookAuthLogin       WebhookEvent = "auth.login"
+	WebhookAuthFailed      WebhookEvent = "auth.failed"
+	// Sent to a single webhook to test it, can't be subscribed to
+	WebhookPing WebhookEvent = "webhook.ping"
+
+	WebhookEvents = []WebhookEvent{
+		WebhookGalleryCreated,
+		WebhookGalleryUpdated,
+		WebhookGalleryDeleted,
+		WebhookGalleryLive,
+		WebhookGalleryExpired,
+		WebhookImageUploaded,
+		WebhookImageDeleted,
+		WebhookDownloadImage,
+		WebhookDownloadImages,
+		WebhookDownloadGallery,
+		WebhookDownloadSet,
+		WebhookFavoriteAdded,
+		WebhookFavoriteRemoved,
+		WebhookAuthLogin,
+		WebhookAuthFailed,
+	}
+)
+
+// Webhook is an endpoint that is sent the events it subscribed to
+type Webhook struct {
+	gorm.Model
+	// URL the events are posted to
+	URL string `gorm:"not null" json:"url"`
+	// Space separated events the webhook is sent, every event when empty
+	Events string `gorm:"not null;default:''" json:"events"`
+	// Key of the HMAC-SHA256 signature of the payloads, only returned when
+	// it is generated
+	Secret string `gorm:"not null" json:"-"`
+	// Masked secret that only shows its last characters
+	SecretHint string `gorm:"-" json:"secret_hint"`
+	Enabled    bool   `gorm:"not null;default:true" json:"enabled"`
+}
+
+// WebhookWithSecret is the webhook along with its secret, returned when the
+// webhook is created or its secret is rotated
+type WebhookWithSecret struct {
+	Webhook
+	Secret string `json:"secret"`
+}
+
+// Model to handle creating and updating a webhook
+type WebhookUpdate struct {
+	URL     *string `json:"url"`
+	Events  *string `json:"events"`
+	Enabled *bool   `json:"enabled"`
+	// Generate a new secret for the webhook
+	RotateSecret bool `json:"rotate_secret"`
+}
+
+func (w *Webhook) AfterFind(tx *gorm.DB) (err error) {
+	w.MaskSecret()
+	return
+}
+
+// MaskSecret sets the hint of the secret to its last characters
+func (w *Webhook) MaskSecret() {
+	const shown = 4
+	if len(w.Secret) <= shown {
+		w.SecretHint = strings.Repeat("*", len(w.Secret))
+		return
+	}
+	w.SecretHint = strings.Repeat("*", 8) + w.Secret[len(w.Secret)-shown:]
+}
+
+// Subscribed checks if the webhook is sent the event
+func (w *Webhook) Subscribed(event WebhookEvent) bool {
+	if strings.TrimSpace(w.Events) == "" {
+		return true
+	}
+
+	for _, e := range strings.Fields(w.Events) {
+		if WebhookEvent(e) == event {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidWebhookEvent checks if the given event can be subscribed to
+func ValidWebhookEvent(event WebhookEvent) bool {
+	for _, e := range WebhookEvents {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookPayload is the body posted to the webhooks
+type WebhookPayload struct {
+	Event     WebhookEvent `json:"event"`
+	CreatedAt time.Time    `json:"created_at"`
+	Data      any          `json:"data"`
+}
+
+// WebhookGallery is the gallery sent with the gallery events
+type WebhookGallery struct {
+	ID         uint      `json:"id"`
+	PublicID   string    `json:"public_id"`
+	Title      string    `json:"title"`
+	Path       string    `json:"path"`
+	Live       time.Time `json:"live"`
+	Expiration time.Time `json:"expiration"`
+}
+
+// NewWebhookGallery returns the fields of the gallery sent to the webhooks
+func NewWebhookGallery(gallery *Gallery) WebhookGallery {
+	return WebhookGallery{
+		ID:         gallery.ID,
+		PublicID:   gallery.PublicID,
+		Title:      gallery.Title,
+		Path:       gallery.Path,
+		Live:       gallery.Live,
+		Expiration: gallery.Expiration,
+	}
+}
+
+// WebhookImage is the image sent with the image events
+type WebhookImage struct {
+	ID        uint   `json:"id"`
+	PublicID  string `json:"public_id"`
+	GalleryID uint   `json:"gallery_id"`
+	SetID     *uint  `json:"set_id"`
+	// Filename of the image when it was uploaded
+	Filename string `json:"filename"`
+	Title    string `json:"title"`
+	Size     int64  `json:"size"`
+}
+
+// NewWebhookImage returns the fields of the image sent to the webhooks
+func NewWebhookImage(image *Image) WebhookImage {
+	return WebhookImage{
+		ID:        image.ID,
+		PublicID:  image.PublicID,
+		GalleryID: image.GalleryID,
+		SetID:     image.SetID,
+		Filename:  image.OriginalFilename,
+		Title:     image.Title,
+		Size:      image.Size,
+	}
+}
+
+// WebhookDelivery is an event waiting to be posted to a webhook, once it
+// is delivered or has failed it stays as the delivery log of the webhook
+type WebhookDelivery struct {
+	gorm.Model
+	WebhookID uint         `gorm:"not null;index" json:"webhook_id"`
+	Event     WebhookEvent `gorm:"not null" json:"event"`
+	// The JSON body that is posted
+	Payload string `gorm:"not null" json:"payload"`
+	// Status of the delivery (pending, sent, failed)
+	Status OutboxStatus `gorm:"not null;default:'pending';index" json:"status"`
+	// Number of times posting was tried
+	Attempts int `gorm:"not null;default:0" json:"attempts"`
+	// When the pending delivery is posted next
+	NextAttemptAt time.Time `gorm:"not null;index" json:"next_attempt_at"`
+	// HTTP status of the last response of the webhook
+	ResponseStatus *int `json:"response_status"`
+	// Error of the last attempt that failed
+	LastError   *string    `json:"last_error"`
+	DeliveredAt *time.Time `json:"delivered_at"`
+}
+
+const (
+	DefaultDeliveriesLimit = 100
+	MaxDeliveriesLimit     = 1000
+)
@@ -0,0 +1,38 @@
+package models_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/austinbspencer/gshare-server/internal/models"
+)
+
+func TestWebhookSecret(t *testing.T) {
+	hook := models.Webhook{URL: "https://example.com/hook", Secret: "s3cr3t-signing-key"}
+	hook.MaskSecret()
+
+	if want := "********-key"; hook.SecretHint != want {
+		t.Errorf("SecretHint = %q, want: %q", hook.SecretHint, want)
+	}
+
+	body, _ := json.Marshal(hook)
+	if strings.Contains(string(body), hook.Secret) {
+		t.Errorf("The webhook JSON contains the secret: %s", body)
+	}
+
+	var withSecret map[string]any
+	body, _ = json.Marshal(models.WebhookWithSecret{Webhook: hook, Secret: hook.Secret})
+	if err := json.Unmarshal(body, &withSecret); err != nil {
+		t.Fatalf("Unable to parse the webhook JSON: %v", err)
+	}
+	if withSecret["secret"] != hook.Secret || withSecret["secret_hint"] != hook.SecretHint {
+		t.Errorf("The webhook JSON with the secret = %s, want the secret and its hint", body)
+	}
+
+	short := models.Webhook{Secret: "abc"}
+	short.MaskSecret()
+	if short.SecretHint != "***" {
+		t.Errorf("SecretHint = %q, want a short secret fully masked", short.SecretHint)
+	}
+}
@@ -0,0 +1,191 @@
+package queries
+
+import (
+	"errors"
+
+	"github.com/austinbspencer/gshare-server/internal/models"
+	"github.com/austinbspencer/gshare-server/platform/database"
+	"gorm.io/gorm"
+)
+
+// ErrContactExists is returned when the client is already a contact of the
+// gallery
+var ErrContactExists = errors.New("the client is already a contact of the gallery")
+
+type ContactRepository interface {
+	GetGalleryContacts(galleryID uint) ([]models.GalleryContact, error)
+	GetGalleryContactByID(galleryID uint, id string) (*models.GalleryContact, error)
+	CreateGalleryContact(contact *models.GalleryContact, update models.GalleryContactUpdate) error
+	UpdateGalleryContact(contact *models.GalleryContact, update models.GalleryContactUpdate) error
+	DeleteGalleryContact(contact *models.GalleryContact) error
+	SetGalleryContactEmails(galleryID uint, emails []string) error
+	GetClients() ([]models.Client, error)
+}
+
+type contactRepository struct {
+	db *gorm.DB
+}
+
+func NewContactRepository() ContactRepository {
+	return &contactRepository{db: database.DB}
+}
+
+func (r *contactRepository) GetGalleryContacts(galleryID uint) ([]models.GalleryContact, error) {
+	contacts := []models.GalleryContact{}
+
+	err := r.db.Model(&models.GalleryContact{}).Preload("Client").
+		Where("gallery_id = ?", galleryID).Order("id").Find(&contacts).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return contacts, nil
+}
+
+func (r *contactRepository) GetGalleryContactByID(galleryID uint, id string) (*models.GalleryContact, error) {
+	var contact models.GalleryContact
+
+	if err := r.db.Model(&models.GalleryContact{}).Preload("Client").
+		Where("gallery_id = ?", galleryID).First(&contact, id).Error; err != nil {
+		return nil, err
+	}
+
+	return &contact, nil
+}
+
+// Create the contact for the client with the email, the client is created
+// when there isn't one with the email yet
+func (r *contactRepository) CreateGalleryContact(contact *models.GalleryContact, update models.GalleryContactUpdate) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		client, err := findOrCreateClient(tx, *update.Email, update.Name)
+		if err != nil {
+			return err
+		}
+
+		if err := checkContactExists(tx, contact.GalleryID, client.ID); err != nil {
+			return err
+		}
+
+		contact.ClientID = client.ID
+		contact.Client = client
+		if update.Role != nil {
+			contact.Role = *update.Role
+		}
+
+		return tx.Omit("Client").Create(contact).Error
+	})
+}
+
+// Update the contact, a new email links the contact to the client with the
+// email. The name is the name of the client in every gallery.
+func (r *contactRepository) UpdateGalleryContact(contact *models.GalleryContact, update models.GalleryContactUpdate) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if update.Email != nil && (contact.Client == nil || *update.Email != contact.Client.Email) {
+			client, err := findOrCreateClient(tx, *update.Email, nil)
+			if err != nil {
+				return err
+			}
+
+			if err := checkContactExists(tx, contact.GalleryID, client.ID); err != nil {
+				return err
+			}
+
+			contact.ClientID = client.ID
+			contact.Client = client
+		}
+
+		if update.Name != nil {
+			if err := tx.Model(contact.Client).Update("name", *update.Name).Error; err != nil {
+				return err
+			}
+		}
+
+		if update.Role != nil {
+			contact.Role = *update.Role
+		}
+
+		return tx.Model(contact).Omit("Client").Updates(map[string]any{
+			"client_id": contact.ClientID,
+			"role":      contact.Role,
+		}).Error
+	})
+}
+
+// Delete the contact, the client is kept
+func (r *contactRepository) DeleteGalleryContact(contact *models.GalleryContact) error {
+	return r.db.Unscoped().Delete(contact).Error
+}
+
+// Set the contacts of the gallery to the clients with the emails. Contacts
+// that are kept keep their role and new contacts are clients.
+func (r *contactRepository) SetGalleryContactEmails(galleryID uint, emails []string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		clientIDs := make([]uint, 0, len(emails))
+		for _, email := range emails {
+			client, err := findOrCreateClient(tx, email, nil)
+			if err != nil {
+				return err
+			}
+			clientIDs = append(clientIDs, client.ID)
+
+			contact := models.GalleryContact{GalleryID: galleryID, ClientID: client.ID, Role: models.ContactClient}
+			if err := tx.Where("gallery_id = ? AND client_id = ?", galleryID, client.ID).
+				FirstOrCreate(&contact).Error; err != nil {
+				return err
+			}
+		}
+
+		removed := tx.Unscoped().Where("gallery_id = ?", galleryID)
+		if len(clientIDs) > 0 {
+			removed = removed.Where("client_id NOT IN ?", clientIDs)
+		}
+
+		return removed.Delete(&models.GalleryContact{}).Error
+	})
+}
+
+// Get the clients along with the galleries they are a contact of
+func (r *contactRepository) GetClients() ([]models.Client, error) {
+	clients := []models.Client{}
+
+	err := r.db.Model(&models.Client{}).Preload("Contacts").
+		Order("email").Find(&clients).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return clients, nil
+}
+
+// findOrCreateClient returns the client with the email, the name is set when
+// it is given
+func findOrCreateClient(tx *gorm.DB, email string, name *string) (*models.Client, error) {
+	client := models.Client{Email: email}
+	if err := tx.Where("email = ?", email).FirstOrCreate(&client).Error; err != nil {
+		return nil, err
+	}
+
+	if name != nil && *name != client.Name {
+		if err := tx.Model(&client).Update("name", *name).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	return &client, nil
+}
+
+// checkContactExists returns ErrContactExists when the client is already a
+// contact of the gallery
+func checkContactExists(tx *gorm.DB, galleryID, clientID uint) error {
+	var count int64
+	if err := tx.Model(&models.GalleryContact{}).
+		Where("gallery_id = ? AND client_id = ?", galleryID, clientID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrContactExists
+	}
+
+	return nil
+}
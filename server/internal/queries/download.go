@@ -0,0 +1,56 @@
+package queries
+
+import (
+	"github.com/austinbspencer/gshare-server/internal/models"
+	"github.com/austinbspencer/gshare-server/platform/database"
+	"gorm.io/gorm"
+)
+
+type DownloadRepository interface {
+	GetGalleryDownloads(galleryID uint) ([]models.GalleryDownload, error)
+	CountVisitorDownloads(galleryID uint, ip string, email *string) (int64, error)
+	CreateGalleryDownload(download *models.GalleryDownload) error
+}
+
+type downloadRepository struct {
+	db *gorm.DB
+}
+
+func NewDownloadRepository() DownloadRepository {
+	return &downloadRepository{db: database.DB}
+}
+
+func (r *downloadRepository) GetGalleryDownloads(galleryID uint) ([]models.GalleryDownload, error) {
+	downloads := []models.GalleryDownload{}
+
+	err := r.db.Model(&models.GalleryDownload{}).Where("gallery_id = ?", galleryID).
+		Order("created_at DESC").Find(&downloads).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return downloads, nil
+}
+
+// Count the downloads of the gallery by the visitor, matching either the
+// IP or the email of the visitor
+func (r *downloadRepository) CountVisitorDownloads(galleryID uint, ip string, email *string) (int64, error) {
+	var count int64
+
+	query := r.db.Model(&models.GalleryDownload{}).Where("gallery_id = ?", galleryID)
+	if email != nil {
+		query = query.Where("ip = ? OR email = ?", ip, *email)
+	} else {
+		query = query.Where("ip = ?", ip)
+	}
+
+	if err := query.Count(&count).Error; err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+func (r *downloadRepository) CreateGalleryDownload(download *models.GalleryDownload) error {
+	return r.db.Create(download).Error
+}
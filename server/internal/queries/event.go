@@ -32,7 +32,10 @@ func (r *eventRepository) GetEventByID(id string) (*models.Event, error) {
 func (r *eventRepository) GetEvents() ([]models.Event, error) {
 	var events []models.Event
 
-	err := r.db.Model(&models.Event{}).Find(&events).Error
+	err := r.db.Model(&models.Event{}).
+		Select("events.*, images.public_id AS image_public_id").
+		Joins("LEFT JOIN images ON images.id = events.image_id").
+		Find(&events).Error
 	if err != nil {
 		return nil, err
 	}
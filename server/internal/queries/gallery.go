@@ -1,16 +1,21 @@
 package queries
 
 import (
+	"errors"
+	"fmt"
 	"time"
 
 	"github.com/austinbspencer/gshare-server/internal/models"
+	"github.com/austinbspencer/gshare-server/pkg/utils"
 	"github.com/austinbspencer/gshare-server/platform/database"
 	"gorm.io/gorm"
 )
 
 type GalleryRepository interface {
 	GetGalleryByID(id string) (*models.Gallery, error)
+	GetGalleryByPublicID(publicID string) (*models.Gallery, error)
 	GetGalleryByPath(path string) (*models.Gallery, error)
+	GetClientGalleryByID(id uint) (*models.Gallery, error)
 	GetGalleries() ([]models.Gallery, error)
 	GetPublicGalleries() ([]models.Gallery, error)
 	GetLiveGalleries() ([]models.Gallery, error)
@@ -19,9 +24,27 @@ type GalleryRepository interface {
 	SetZipsReady(gallery *models.Gallery, value bool) error
 	GetRandomGalleryImage(galleryID uint) (*models.Image, error)
 	CreateNewGallery(gallery *models.Gallery) error
+	CloneGallery(gallery, clone *models.Gallery, copySets bool, filenames map[uint]string) error
+	TrashGallery(gallery *models.Gallery) error
+	GetTrashedGalleries() ([]models.Gallery, error)
+	GetTrashedGalleryByID(id string) (*models.Gallery, error)
+	GetGalleriesTrashedBefore(before time.Time) ([]models.Gallery, error)
+	GetGalleriesExpiredBefore(before time.Time) ([]models.Gallery, error)
+	ArchiveGallery(gallery *models.Gallery) error
+	MarkRetentionWarned(galleryID uint, warnedAt time.Time) error
+	MarkLiveNotified(gallery *models.Gallery) (bool, error)
+	MarkLiveFired(gallery *models.Gallery) (bool, error)
+	MarkExpiredFired(gallery *models.Gallery) (bool, error)
+	ClearLiveNotified(gallery *models.Gallery) error
+	RestoreGallery(gallery *models.Gallery) error
 	DeleteGallery(gallery *models.Gallery) error
 }
 
+// ErrGalleryConflict is returned when restoring a gallery whose title or
+// path has since been used by another gallery, or when cloning a gallery to
+// a title or path that is in use
+var ErrGalleryConflict = errors.New("another gallery is using the title or path")
+
 type galleryRepository struct {
 	db *gorm.DB
 }
@@ -33,12 +56,7 @@ func NewGalleryRepository() GalleryRepository {
 func (r *galleryRepository) GetGalleryByID(id string) (*models.Gallery, error) {
 	var gallery models.Gallery
 
-	err := r.db.Model(&models.Gallery{}).
-		Preload("Events", func(db *gorm.DB) *gorm.DB {
-			return db.Order("created_at DESC")
-		}).Preload("Images", func(db *gorm.DB) *gorm.DB {
-		return db.Order("images.position")
-	}).Preload("FeaturedImage").First(&gallery, id).Error
+	err := r.preloadGallery().First(&gallery, id).Error
 	if err != nil {
 		return nil, err
 	}
@@ -46,14 +64,40 @@ func (r *galleryRepository) GetGalleryByID(id string) (*models.Gallery, error) {
 	return &gallery, nil
 }
 
+func (r *galleryRepository) GetGalleryByPublicID(publicID string) (*models.Gallery, error) {
+	var gallery models.Gallery
+
+	err := r.preloadGallery().First(&gallery, "public_id = ?", publicID).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &gallery, nil
+}
+
+// imagesOrder orders the images of a gallery the way they are shown, the
+// images without a set first and then each set in order. Positions are only
+// unique within a set.
+const imagesOrder = "COALESCE((SELECT gallery_sets.position FROM gallery_sets WHERE gallery_sets.id = images.set_id), -1), images.position"
+
+// Query for a gallery along with its events, images, sets and featured image
+func (r *galleryRepository) preloadGallery() *gorm.DB {
+	return r.db.Model(&models.Gallery{}).
+		Preload("Events", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at DESC")
+		}).Preload("Images", func(db *gorm.DB) *gorm.DB {
+		return db.Order(imagesOrder)
+	}).Preload("Images.Tags").Preload("Sets", func(db *gorm.DB) *gorm.DB {
+		return db.Order("gallery_sets.position")
+	}).Preload("FeaturedImage").Preload("Contacts.Client")
+}
+
 // Get the gallery by path .. requires the gallery to be live
+// Hidden images are left out since this is the client view of the gallery
 func (r *galleryRepository) GetGalleryByPath(path string) (*models.Gallery, error) {
 	var gallery models.Gallery
 
-	err := r.db.Model(&models.Gallery{}).
-		Preload("Images", func(db *gorm.DB) *gorm.DB {
-			return db.Order("images.position")
-		}).Preload("FeaturedImage").
+	err := r.preloadClientGallery().
 		Where("live <= ? AND expiration >= ?", time.Now(), time.Now()).
 		First(&gallery, "path = ?", path).Error
 	if err != nil {
@@ -63,6 +107,28 @@ func (r *galleryRepository) GetGalleryByPath(path string) (*models.Gallery, erro
 	return &gallery, nil
 }
 
+// Get the client view of the gallery whether or not it is live
+func (r *galleryRepository) GetClientGalleryByID(id uint) (*models.Gallery, error) {
+	var gallery models.Gallery
+
+	err := r.preloadClientGallery().First(&gallery, id).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &gallery, nil
+}
+
+// Query for the client view of a gallery which leaves out hidden images
+func (r *galleryRepository) preloadClientGallery() *gorm.DB {
+	return r.db.Model(&models.Gallery{}).
+		Preload("Images", func(db *gorm.DB) *gorm.DB {
+			return db.Where("hidden = ?", false).Order(imagesOrder)
+		}).Preload("Images.Tags").Preload("Sets", func(db *gorm.DB) *gorm.DB {
+		return db.Order("gallery_sets.position")
+	}).Preload("FeaturedImage", "hidden = ?", false)
+}
+
 func (r *galleryRepository) GetGalleries() ([]models.Gallery, error) {
 	var galleries []models.Gallery
 
@@ -80,7 +146,7 @@ func (r *galleryRepository) GetGalleries() ([]models.Gallery, error) {
 func (r *galleryRepository) GetPublicGalleries() ([]models.Gallery, error) {
 	var galleries []models.Gallery
 
-	err := r.db.Model(&models.Gallery{}).Preload("FeaturedImage").
+	err := r.db.Model(&models.Gallery{}).Preload("FeaturedImage", "hidden = ?", false).
 		Where("live <= ? AND expiration >= ? AND public = true AND protected = false", time.Now(), time.Now()).
 		Order("live DESC").
 		Find(&galleries).Error
@@ -95,7 +161,7 @@ func (r *galleryRepository) GetPublicGalleries() ([]models.Gallery, error) {
 func (r *galleryRepository) GetLiveGalleries() ([]models.Gallery, error) {
 	var galleries []models.Gallery
 
-	err := r.db.Model(&models.Gallery{}).Preload("FeaturedImage").
+	err := r.db.Model(&models.Gallery{}).Preload("FeaturedImage", "hidden = ?", false).
 		Where("live <= ? AND expiration >= ?", time.Now(), time.Now()).
 		Find(&galleries).Error
 	if err != nil {
@@ -128,9 +194,21 @@ func (r *galleryRepository) UpdateGallery(gallery *models.Gallery, updateGallery
 	}
 
 	if updateGallery.Live != nil {
+		// The live notification and event are sent again for the new
+		// live date
+		if !gallery.Live.Equal(*updateGallery.Live) {
+			gallery.LiveNotifiedAt = nil
+			gallery.LiveFiredAt = nil
+		}
 		gallery.Live = *updateGallery.Live
 	}
 	if updateGallery.Expiration != nil {
+		// The retention warning and expired event were for the previous
+		// expiration
+		if !gallery.Expiration.Equal(*updateGallery.Expiration) {
+			gallery.RetentionWarnedAt = nil
+			gallery.ExpiredFiredAt = nil
+		}
 		gallery.Expiration = *updateGallery.Expiration
 	}
 	if updateGallery.Public != nil {
@@ -146,19 +224,33 @@ func (r *galleryRepository) UpdateGallery(gallery *models.Gallery, updateGallery
 			gallery.Password = updateGallery.Password
 		}
 	}
+	if updateGallery.PasswordHint != nil {
+		if *updateGallery.PasswordHint == "" {
+			gallery.PasswordHint = nil
+		} else {
+			gallery.PasswordHint = updateGallery.PasswordHint
+		}
+	}
+	if updateGallery.LiveNotification != nil {
+		gallery.LiveNotification = *updateGallery.LiveNotification
+	}
 	if updateGallery.Reminder != nil {
 		gallery.Reminder = *updateGallery.Reminder
 	}
-	if updateGallery.ReminderEmails != nil {
-		if *updateGallery.ReminderEmails == "" {
-			gallery.ReminderEmails = nil
-		} else {
-			gallery.ReminderEmails = updateGallery.ReminderEmails
-		}
+	if updateGallery.ReminderDays != nil {
+		gallery.ReminderDays = *updateGallery.ReminderDays
 	}
 	if updateGallery.HeroEnabled != nil {
 		gallery.HeroEnabled = *updateGallery.HeroEnabled
 	}
+	if updateGallery.SignedURLs != nil {
+		gallery.SignedURLs = *updateGallery.SignedURLs
+	}
+	if updateGallery.DownloadPolicy != nil {
+		if err := updateDownloadPolicy(&gallery.DownloadPolicy, *updateGallery.DownloadPolicy); err != nil {
+			return err
+		}
+	}
 
 	// Changes that only happen when we aren't updating hero variant
 	// These are changes that we can possibly set to nil on updates
@@ -178,13 +270,13 @@ func (r *galleryRepository) SetZipsReady(gallery *models.Gallery, value bool) er
 	return r.db.Model(&gallery).Update("ZipsReady", value).Error
 }
 
-// Get a random image from the gallery
+// Get a random image from the gallery that isn't hidden
 func (r *galleryRepository) GetRandomGalleryImage(galleryID uint) (*models.Image, error) {
 	var image models.Image
 
 	// Get a random image from the gallery sorting first by random
 	// and then by aspect ratio prioritizing landscape images
-	err := r.db.Model(&models.Image{}).Where("gallery_id = ?", galleryID).
+	err := r.db.Model(&models.Image{}).Where("gallery_id = ? AND hidden = ?", galleryID, false).
 		Order("RANDOM(), CASE WHEN width > height THEN 0 ELSE 1 END").
 		First(&image).Error
 	if err != nil {
@@ -195,12 +287,424 @@ func (r *galleryRepository) GetRandomGalleryImage(galleryID uint) (*models.Image
 }
 
 func (r *galleryRepository) CreateNewGallery(gallery *models.Gallery) error {
-	return r.db.Create(gallery).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		return createGallery(tx, gallery)
+	})
 }
 
-// Fully delete gallery from database
+// Create the clone of the gallery. The password and download PIN hashes and
+// the contacts are kept, and the sets are copied when copySets is true. The images given a
+// new filename are copied along with their details and tags, and keep their
+// set when the sets are copied.
+func (r *galleryRepository) CloneGallery(gallery, clone *models.Gallery, copySets bool, filenames map[uint]string) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Unscoped().Model(&models.Gallery{}).
+			Where("title = ? OR path = ?", clone.Title, clone.Path).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrGalleryConflict
+		}
+
+		// The password is already hashed so it is saved after the gallery
+		// is created to skip hashing it again
+		clone.Password = nil
+		if err := createGallery(tx, clone); err != nil {
+			return err
+		}
+		if gallery.Password != nil {
+			if err := tx.Model(clone).UpdateColumn("password", *gallery.Password).Error; err != nil {
+				return err
+			}
+			clone.Password = gallery.Password
+		}
+
+		for _, contact := range gallery.Contacts {
+			contactCopy := models.GalleryContact{
+				GalleryID: clone.ID,
+				ClientID:  contact.ClientID,
+				Role:      contact.Role,
+			}
+			if err := tx.Create(&contactCopy).Error; err != nil {
+				return err
+			}
+
+			contactCopy.Client = contact.Client
+			clone.Contacts = append(clone.Contacts, contactCopy)
+		}
+		clone.ReminderEmails = gallery.ReminderEmails
+
+		setIDs := map[uint]uint{}
+		if copySets {
+			for _, set := range gallery.Sets {
+				setCopy := models.GallerySet{
+					GalleryID: clone.ID,
+					Title:     set.Title,
+					Position:  set.Position,
+				}
+				if err := tx.Create(&setCopy).Error; err != nil {
+					return err
+				}
+
+				setIDs[set.ID] = setCopy.ID
+				clone.Sets = append(clone.Sets, setCopy)
+			}
+		}
+
+		for _, image := range gallery.Images {
+			filename, ok := filenames[image.ID]
+			if !ok {
+				continue
+			}
+
+			imageCopy := models.Image{
+				GalleryID:        clone.ID,
+				Size:             image.Size,
+				Height:           image.Height,
+				Width:            image.Width,
+				Position:         image.Position,
+				Filename:         filename,
+				OriginalFilename: image.OriginalFilename,
+				Title:            image.Title,
+				Caption:          image.Caption,
+				AltText:          image.AltText,
+				Hidden:           image.Hidden,
+				Tags:             image.Tags,
+			}
+			if image.SetID != nil {
+				if setID, ok := setIDs[*image.SetID]; ok {
+					imageCopy.SetID = &setID
+				}
+			}
+			if image.ID == gallery.FeaturedImage.ID {
+				imageCopy.FeaturedGalleryID = &clone.ID
+			}
+			if err := tx.Create(&imageCopy).Error; err != nil {
+				return err
+			}
+
+			if imageCopy.FeaturedGalleryID != nil {
+				clone.FeaturedImage = imageCopy
+			}
+			clone.Images = append(clone.Images, imageCopy)
+		}
+
+		return nil
+	})
+}
+
+// Create the gallery within the transaction
+func createGallery(tx *gorm.DB, gallery *models.Gallery) error {
+	// Fields with a default are skipped on create when they are false and
+	// then read back with the default, so the hero and download types are
+	// saved explicitly after the gallery is created
+	heroEnabled := gallery.HeroEnabled
+	policy := gallery.DownloadPolicy
+
+	if err := tx.Create(gallery).Error; err != nil {
+		return err
+	}
+
+	gallery.HeroEnabled = heroEnabled
+	gallery.DownloadPolicy.Single = policy.Single
+	gallery.DownloadPolicy.Selection = policy.Selection
+	gallery.DownloadPolicy.Gallery = policy.Gallery
+
+	return tx.Model(gallery).Updates(map[string]any{
+		"hero_enabled":       heroEnabled,
+		"download_single":    policy.Single,
+		"download_selection": policy.Selection,
+		"download_gallery":   policy.Gallery,
+	}).Error
+}
+
+// Apply the update to the download policy, the PIN is stored as a hash
+func updateDownloadPolicy(policy *models.DownloadPolicy, update models.DownloadPolicyUpdate) error {
+	if update.Sizes != nil {
+		policy.Sizes = *update.Sizes
+	}
+	if update.Single != nil {
+		policy.Single = *update.Single
+	}
+	if update.Selection != nil {
+		policy.Selection = *update.Selection
+	}
+	if update.Gallery != nil {
+		policy.Gallery = *update.Gallery
+	}
+	if update.Pin != nil {
+		if *update.Pin == "" {
+			policy.Pin = nil
+		} else {
+			hashedPin, err := utils.HashPassword(*update.Pin)
+			if err != nil {
+				return err
+			}
+			policy.Pin = hashedPin
+		}
+		policy.PinRequired = policy.Pin != nil
+	}
+	if update.EmailRequired != nil {
+		policy.EmailRequired = *update.EmailRequired
+	}
+	if update.Limit != nil {
+		policy.Limit = *update.Limit
+	}
+
+	return nil
+}
+
+// Move the gallery and its images to the trash. The title and path are
+// replaced so they can be reused while the gallery is in the trash.
+func (r *galleryRepository) TrashGallery(gallery *models.Gallery) error {
+	trashedName := fmt.Sprintf("~trash-%d", gallery.ID)
+	now := time.Now()
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Image{}).Where("gallery_id = ?", gallery.ID).
+			Updates(map[string]any{"deleted_at": now, "trashed_with_gallery": true}).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(gallery).Updates(map[string]any{
+			"trashed_title": gallery.Title,
+			"trashed_path":  gallery.Path,
+			"title":         trashedName,
+			"path":          trashedName,
+			"deleted_at":    now,
+		}).Error
+	})
+}
+
+func (r *galleryRepository) GetTrashedGalleries() ([]models.Gallery, error) {
+	galleries := []models.Gallery{}
+
+	err := r.db.Unscoped().Model(&models.Gallery{}).Where("deleted_at IS NOT NULL").
+		Order("deleted_at DESC").Find(&galleries).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return galleries, nil
+}
+
+func (r *galleryRepository) GetTrashedGalleryByID(id string) (*models.Gallery, error) {
+	var gallery models.Gallery
+
+	err := r.db.Unscoped().Model(&models.Gallery{}).Where("deleted_at IS NOT NULL").
+		First(&gallery, id).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &gallery, nil
+}
+
+// Get the galleries that were moved to the trash before the given time
+func (r *galleryRepository) GetGalleriesTrashedBefore(before time.Time) ([]models.Gallery, error) {
+	galleries := []models.Gallery{}
+
+	err := r.db.Unscoped().Model(&models.Gallery{}).Where("deleted_at < ?", before).
+		Find(&galleries).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return galleries, nil
+}
+
+// Get the galleries that expired before the given time and haven't been
+// archived
+func (r *galleryRepository) GetGalleriesExpiredBefore(before time.Time) ([]models.Gallery, error) {
+	var galleries []models.Gallery
+
+	err := r.db.Model(&models.Gallery{}).
+		Where("expiration < ? AND archived_at IS NULL", before).
+		Order("expiration").Find(&galleries).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return galleries, nil
+}
+
+// Mark the gallery as archived, its zips no longer exist
+func (r *galleryRepository) ArchiveGallery(gallery *models.Gallery) error {
+	archivedAt := time.Now()
+
+	if err := r.db.Model(gallery).UpdateColumns(map[string]any{
+		"archived_at": archivedAt,
+		"zips_ready":  false,
+	}).Error; err != nil {
+		return err
+	}
+
+	gallery.ArchivedAt = &archivedAt
+	gallery.ZipsReady = false
+
+	return nil
+}
+
+// Mark the admin as warned that the retention policy will act on the gallery
+func (r *galleryRepository) MarkRetentionWarned(galleryID uint, warnedAt time.Time) error {
+	return r.db.Model(&models.Gallery{}).Where("id = ?", galleryID).
+		UpdateColumn("retention_warned_at", warnedAt).Error
+}
+
+// Mark the live notification of the gallery as sent. False is returned when
+// it was already marked, so the notification is only sent once.
+func (r *galleryRepository) MarkLiveNotified(gallery *models.Gallery) (bool, error) {
+	notifiedAt, err := r.markGallery(gallery.ID, "live_notified_at")
+	if notifiedAt != nil {
+		gallery.LiveNotifiedAt = notifiedAt
+	}
+
+	return notifiedAt != nil, err
+}
+
+// Mark the gallery as found live. False is returned when it was already
+// marked, so the live event is only fired once.
+func (r *galleryRepository) MarkLiveFired(gallery *models.Gallery) (bool, error) {
+	firedAt, err := r.markGallery(gallery.ID, "live_fired_at")
+	if firedAt != nil {
+		gallery.LiveFiredAt = firedAt
+	}
+
+	return firedAt != nil, err
+}
+
+// Mark the gallery as found expired. False is returned when it was already
+// marked, so the expired event is only fired once.
+func (r *galleryRepository) MarkExpiredFired(gallery *models.Gallery) (bool, error) {
+	firedAt, err := r.markGallery(gallery.ID, "expired_fired_at")
+	if firedAt != nil {
+		gallery.ExpiredFiredAt = firedAt
+	}
+
+	return firedAt != nil, err
+}
+
+// markGallery sets the date column of the gallery when it isn't set yet,
+// the date is only returned when it was set
+func (r *galleryRepository) markGallery(galleryID uint, column string) (*time.Time, error) {
+	markedAt := time.Now()
+
+	result := r.db.Model(&models.Gallery{}).
+		Where("id = ? AND "+column+" IS NULL", galleryID).
+		UpdateColumn(column, markedAt)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return nil, result.Error
+	}
+
+	return &markedAt, nil
+}
+
+// Clear the live notification of the gallery so it is sent again, such as
+// after sending it failed
+func (r *galleryRepository) ClearLiveNotified(gallery *models.Gallery) error {
+	if err := r.db.Model(&models.Gallery{}).Where("id = ?", gallery.ID).
+		UpdateColumn("live_notified_at", nil).Error; err != nil {
+		return err
+	}
+
+	gallery.LiveNotifiedAt = nil
+
+	return nil
+}
+
+// Restore the gallery and the images that were trashed along with it
+func (r *galleryRepository) RestoreGallery(gallery *models.Gallery) error {
+	if gallery.TrashedTitle == nil || gallery.TrashedPath == nil {
+		return fmt.Errorf("gallery %d is missing its trashed title or path", gallery.ID)
+	}
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Unscoped().Model(&models.Gallery{}).
+			Where("title = ? OR path = ?", *gallery.TrashedTitle, *gallery.TrashedPath).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return ErrGalleryConflict
+		}
+
+		if err := tx.Unscoped().Model(&models.Image{}).
+			Where("gallery_id = ? AND trashed_with_gallery = ?", gallery.ID, true).
+			Updates(map[string]any{"deleted_at": nil, "trashed_with_gallery": false}).Error; err != nil {
+			return err
+		}
+
+		return tx.Unscoped().Model(gallery).Updates(map[string]any{
+			"title":         *gallery.TrashedTitle,
+			"path":          *gallery.TrashedPath,
+			"trashed_title": nil,
+			"trashed_path":  nil,
+			"deleted_at":    nil,
+		}).Error
+	})
+}
+
+// Fully delete gallery from database along with its images and sets
 func (r *galleryRepository) DeleteGallery(gallery *models.Gallery) error {
 	// Delete with unscoped so we don't have issue with reusing path
 	// after a gallery has been deleted
-	return r.db.Unscoped().Delete(&gallery, gallery.ID).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		imageIDs := tx.Unscoped().Model(&models.Image{}).Select("id").Where("gallery_id = ?", gallery.ID)
+
+		if err := tx.Unscoped().Where("image_id IN (?)", imageIDs).
+			Delete(&models.ImageVersion{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Exec("DELETE FROM image_tags WHERE image_id IN (?)", imageIDs).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Unscoped().Where("gallery_id = ?", gallery.ID).
+			Delete(&models.Image{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Unscoped().Where("gallery_id = ?", gallery.ID).
+			Delete(&models.GallerySet{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Unscoped().Where("gallery_id = ?", gallery.ID).
+			Delete(&models.GalleryDownload{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Unscoped().Where("gallery_id = ?", gallery.ID).
+			Delete(&models.GalleryReminder{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Unscoped().Where("gallery_id = ?", gallery.ID).
+			Delete(&models.LiveNotification{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Unscoped().Where("gallery_id = ?", gallery.ID).
+			Delete(&models.GalleryContact{}).Error; err != nil {
+			return err
+		}
+
+		previewLinkIDs := tx.Unscoped().Model(&models.PreviewLink{}).Select("id").Where("gallery_id = ?", gallery.ID)
+
+		if err := tx.Where("preview_link_id IN (?)", previewLinkIDs).
+			Delete(&models.PreviewFavorite{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Unscoped().Where("gallery_id = ?", gallery.ID).
+			Delete(&models.PreviewLink{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Unscoped().Delete(&gallery, gallery.ID).Error
+	})
 }
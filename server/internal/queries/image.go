@@ -1,20 +1,41 @@
 package queries
 
 import (
+	"errors"
+	"strings"
+	"time"
+
 	"github.com/austinbspencer/gshare-server/internal/models"
+	"github.com/austinbspencer/gshare-server/pkg/utils"
 	"github.com/austinbspencer/gshare-server/platform/database"
 	"gorm.io/gorm"
 )
 
 type ImageRepository interface {
 	GetImageByID(id string) (*models.Image, error)
+	GetImageByPublicID(publicID string) (*models.Image, error)
 	GetGalleryImageByID(galleryID, id string) (*models.Image, error)
 	GetImages() ([]models.Image, error)
+	SearchImages(search models.ImageSearch) ([]models.Image, error)
+	GetTags() ([]models.Tag, error)
 	GetSpecificImages(ids []uint) ([]models.Image, error)
+	GetSpecificImagesByPublicIDs(publicIDs []string) ([]models.Image, error)
 	GetGalleryImages(galleryID uint) ([]models.Image, error)
 	SetImagePosition(imageID int, position int) error
 	SetImageAsFeatImg(image *models.Image, galleryID *uint) error
+	GetImageVersions(imageID uint) ([]models.ImageVersion, error)
+	GetImageVersionByID(imageID uint, id string) (*models.ImageVersion, error)
+	ReplaceImage(image *models.Image, replacement models.Image) (*models.ImageVersion, error)
+	RevertImage(image *models.Image, version *models.ImageVersion) (*models.ImageVersion, error)
+	UpdateImages(images []models.Image, update models.ImageUpdate) error
+	MoveImages(images []models.Image, galleryID uint) error
+	CopyImages(images []models.Image, galleryID uint, filenames []string) ([]models.Image, error)
 	CreateNewImage(image *models.Image) error
+	TrashImage(image *models.Image) error
+	GetTrashedImages() ([]models.Image, error)
+	GetTrashedImageByID(id string) (*models.Image, error)
+	GetImagesTrashedBefore(before time.Time) ([]models.Image, error)
+	RestoreImage(image *models.Image) error
 	DeleteImage(image *models.Image) error
 }
 
@@ -29,7 +50,18 @@ func NewImageRepository() ImageRepository {
 func (r *imageRepository) GetImageByID(id string) (*models.Image, error) {
 	var image models.Image
 
-	err := r.db.Model(&models.Image{}).First(&image, id).Error
+	err := r.db.Model(&models.Image{}).Preload("Tags").First(&image, id).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &image, nil
+}
+
+func (r *imageRepository) GetImageByPublicID(publicID string) (*models.Image, error) {
+	var image models.Image
+
+	err := r.db.Model(&models.Image{}).Preload("Tags").First(&image, "public_id = ?", publicID).Error
 	if err != nil {
 		return nil, err
 	}
@@ -59,6 +91,56 @@ func (r *imageRepository) GetImages() ([]models.Image, error) {
 	return images, nil
 }
 
+// likeEscaper escapes the wildcards of a LIKE pattern
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// Search the images by their details and tags, an empty search returns
+// every image
+func (r *imageRepository) SearchImages(search models.ImageSearch) ([]models.Image, error) {
+	images := []models.Image{}
+
+	query := r.db.Model(&models.Image{}).Preload("Tags")
+
+	if search.GalleryID != nil {
+		query = query.Where("gallery_id = ?", *search.GalleryID)
+	}
+
+	if len(search.IDs) > 0 {
+		query = query.Where("id IN ?", search.IDs)
+	}
+
+	if text := strings.TrimSpace(search.Query); text != "" {
+		// Wildcards in the search are matched as the characters themselves
+		like := "%" + likeEscaper.Replace(strings.ToLower(text)) + "%"
+		query = query.Where(
+			`LOWER(title) LIKE ? ESCAPE '\' OR LOWER(caption) LIKE ? ESCAPE '\' OR LOWER(alt_text) LIKE ? ESCAPE '\' OR LOWER(filename) LIKE ? ESCAPE '\'`,
+			like, like, like, like,
+		)
+	}
+
+	// Images must have every one of the tags
+	for _, tag := range utils.NormalizeTags(search.Tags) {
+		query = query.Where("id IN (?)", r.db.Table("image_tags").Select("image_tags.image_id").
+			Joins("JOIN tags ON tags.id = image_tags.tag_id").Where("tags.name = ?", tag))
+	}
+
+	if err := query.Order("gallery_id, " + imagesOrder).Find(&images).Error; err != nil {
+		return nil, err
+	}
+
+	return images, nil
+}
+
+func (r *imageRepository) GetTags() ([]models.Tag, error) {
+	tags := []models.Tag{}
+
+	if err := r.db.Model(&models.Tag{}).Order("name").Find(&tags).Error; err != nil {
+		return nil, err
+	}
+
+	return tags, nil
+}
+
 func (r *imageRepository) GetSpecificImages(ids []uint) ([]models.Image, error) {
 	var images []models.Image
 
@@ -70,6 +152,17 @@ func (r *imageRepository) GetSpecificImages(ids []uint) ([]models.Image, error)
 	return images, nil
 }
 
+func (r *imageRepository) GetSpecificImagesByPublicIDs(publicIDs []string) ([]models.Image, error) {
+	var images []models.Image
+
+	err := r.db.Model(&models.Image{}).Where("public_id IN ?", publicIDs).Find(&images).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return images, nil
+}
+
 func (r *imageRepository) GetGalleryImages(galleryID uint) ([]models.Image, error) {
 	var images []models.Image
 
@@ -100,13 +193,387 @@ func (r *imageRepository) SetImageAsFeatImg(image *models.Image, galleryID *uint
 	return r.db.Model(&image).Updates(map[string]interface{}{"featured_gallery_id": nil}).Error
 }
 
+// Get the prior originals of the image, newest first
+func (r *imageRepository) GetImageVersions(imageID uint) ([]models.ImageVersion, error) {
+	versions := []models.ImageVersion{}
+
+	err := r.db.Model(&models.ImageVersion{}).Where("image_id = ?", imageID).
+		Order("created_at DESC").Find(&versions).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return versions, nil
+}
+
+func (r *imageRepository) GetImageVersionByID(imageID uint, id string) (*models.ImageVersion, error) {
+	var version models.ImageVersion
+
+	if err := r.db.Model(&models.ImageVersion{}).Where("image_id = ?", imageID).
+		First(&version, id).Error; err != nil {
+		return nil, err
+	}
+
+	return &version, nil
+}
+
+// Replace the file of the image, keeping the current file as a version
+func (r *imageRepository) ReplaceImage(image *models.Image, replacement models.Image) (*models.ImageVersion, error) {
+	version := imageVersionOf(image)
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&version).Error; err != nil {
+			return err
+		}
+
+		return setImageFile(tx, image, replacement.Filename, replacement.OriginalFilename,
+			replacement.Size, replacement.Width, replacement.Height)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &version, nil
+}
+
+// Swap the file of the image with the given version, keeping the current
+// file as a new version
+func (r *imageRepository) RevertImage(image *models.Image, version *models.ImageVersion) (*models.ImageVersion, error) {
+	current := imageVersionOf(image)
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&current).Error; err != nil {
+			return err
+		}
+
+		if err := setImageFile(tx, image, version.Filename, version.OriginalFilename,
+			version.Size, version.Width, version.Height); err != nil {
+			return err
+		}
+
+		// The version is the current file again
+		return tx.Unscoped().Delete(&models.ImageVersion{}, version.ID).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &current, nil
+}
+
+// Update the details and tags of the images in a single transaction
+func (r *imageRepository) UpdateImages(images []models.Image, update models.ImageUpdate) error {
+	fields := map[string]any{}
+	if update.Title != nil {
+		fields["title"] = strings.TrimSpace(*update.Title)
+	}
+	if update.Caption != nil {
+		fields["caption"] = strings.TrimSpace(*update.Caption)
+	}
+	if update.AltText != nil {
+		fields["alt_text"] = strings.TrimSpace(*update.AltText)
+	}
+	if update.Hidden != nil {
+		fields["hidden"] = *update.Hidden
+	}
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		var tags []models.Tag
+		if update.Tags != nil {
+			var err error
+			if tags, err = findOrCreateTags(tx, *update.Tags); err != nil {
+				return err
+			}
+		}
+
+		addTags, err := findOrCreateTags(tx, update.AddTags)
+		if err != nil {
+			return err
+		}
+
+		appendTags := append(tags, addTags...)
+
+		var removeTags []models.Tag
+		if names := utils.NormalizeTags(update.RemoveTags); len(names) > 0 {
+			if err := tx.Where("name IN ?", names).Find(&removeTags).Error; err != nil {
+				return err
+			}
+		}
+
+		for i := range images {
+			image := &images[i]
+
+			if len(fields) > 0 {
+				if err := tx.Model(image).Updates(fields).Error; err != nil {
+					return err
+				}
+			}
+
+			// Each association mode can only be used for a single change
+			if update.Tags != nil {
+				if err := tx.Model(image).Association("Tags").Clear(); err != nil {
+					return err
+				}
+			}
+			if len(appendTags) > 0 {
+				if err := tx.Model(image).Association("Tags").Append(appendTags); err != nil {
+					return err
+				}
+			}
+			if len(removeTags) > 0 {
+				if err := tx.Model(image).Association("Tags").Delete(removeTags); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	})
+}
+
+// Move the images to the end of the gallery. The images leave their set and
+// are no longer the featured image of their previous gallery, which gets a
+// new featured image.
+func (r *imageRepository) MoveImages(images []models.Image, galleryID uint) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		position, err := nextImagePosition(tx, galleryID)
+		if err != nil {
+			return err
+		}
+
+		// Galleries that lose their featured image, read before the update
+		// clears it
+		var featuredGalleryIDs []uint
+		for _, image := range images {
+			if image.FeaturedGalleryID != nil {
+				featuredGalleryIDs = append(featuredGalleryIDs, *image.FeaturedGalleryID)
+			}
+		}
+
+		for i := range images {
+			if err := tx.Model(&images[i]).Updates(map[string]any{
+				"gallery_id":          galleryID,
+				"set_id":              nil,
+				"featured_gallery_id": nil,
+				"position":            position + i,
+			}).Error; err != nil {
+				return err
+			}
+		}
+
+		// The first image that is left becomes the new featured image
+		for _, featuredGalleryID := range featuredGalleryIDs {
+			var featured models.Image
+			err := tx.Model(&models.Image{}).
+				Where("gallery_id = ? AND hidden = ?", featuredGalleryID, false).
+				Order(imagesOrder).First(&featured).Error
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				continue
+			}
+			if err != nil {
+				return err
+			}
+
+			if err := tx.Model(&featured).Update("featured_gallery_id", featuredGalleryID).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// Copy the images with their details and tags to the end of the gallery
+// using the given filenames
+func (r *imageRepository) CopyImages(images []models.Image, galleryID uint, filenames []string) ([]models.Image, error) {
+	copies := make([]models.Image, 0, len(images))
+
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		position, err := nextImagePosition(tx, galleryID)
+		if err != nil {
+			return err
+		}
+
+		for i, image := range images {
+			imageCopy := models.Image{
+				GalleryID:        galleryID,
+				Size:             image.Size,
+				Height:           image.Height,
+				Width:            image.Width,
+				Position:         position + i,
+				Filename:         filenames[i],
+				Title:            image.Title,
+				OriginalFilename: image.OriginalFilename,
+				Caption:          image.Caption,
+				AltText:          image.AltText,
+				Tags:             image.Tags,
+			}
+			if err := tx.Create(&imageCopy).Error; err != nil {
+				return err
+			}
+
+			copies = append(copies, imageCopy)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return copies, nil
+}
+
 func (r *imageRepository) CreateNewImage(image *models.Image) error {
 	return r.db.Create(image).Error
 }
 
+// Move the image to the trash, it is no longer the featured image of its
+// gallery
+func (r *imageRepository) TrashImage(image *models.Image) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(image).Updates(map[string]any{"featured_gallery_id": nil}).Error; err != nil {
+			return err
+		}
+
+		return tx.Delete(image).Error
+	})
+}
+
+// Get the images that were moved to the trash on their own
+func (r *imageRepository) GetTrashedImages() ([]models.Image, error) {
+	images := []models.Image{}
+
+	err := r.db.Unscoped().Model(&models.Image{}).
+		Where("deleted_at IS NOT NULL AND trashed_with_gallery = ?", false).
+		Order("deleted_at DESC").Find(&images).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return images, nil
+}
+
+func (r *imageRepository) GetTrashedImageByID(id string) (*models.Image, error) {
+	var image models.Image
+
+	err := r.db.Unscoped().Model(&models.Image{}).
+		Where("deleted_at IS NOT NULL AND trashed_with_gallery = ?", false).
+		First(&image, id).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return &image, nil
+}
+
+// Get the images that were moved to the trash on their own before the
+// given time
+func (r *imageRepository) GetImagesTrashedBefore(before time.Time) ([]models.Image, error) {
+	images := []models.Image{}
+
+	err := r.db.Unscoped().Model(&models.Image{}).
+		Where("deleted_at < ? AND trashed_with_gallery = ?", before, false).
+		Find(&images).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return images, nil
+}
+
+// Restore the image from the trash, it leaves its set if the set has since
+// been removed
+func (r *imageRepository) RestoreImage(image *models.Image) error {
+	fields := map[string]any{"deleted_at": nil}
+
+	if image.SetID != nil {
+		var count int64
+		if err := r.db.Model(&models.GallerySet{}).
+			Where("id = ? AND gallery_id = ?", *image.SetID, image.GalleryID).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 {
+			fields["set_id"] = nil
+		}
+	}
+
+	return r.db.Unscoped().Model(image).Updates(fields).Error
+}
+
 // Fully delete image from database
 func (r *imageRepository) DeleteImage(image *models.Image) error {
 	// Delete with unscoped as there is no need to persist individual
 	// images after deletion
-	return r.db.Unscoped().Delete(&image, image.ID).Error
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("image_id = ?", image.ID).
+			Delete(&models.ImageVersion{}).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Model(image).Association("Tags").Clear(); err != nil {
+			return err
+		}
+
+		if err := tx.Where("image_id = ?", image.ID).
+			Delete(&models.PreviewFavorite{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Unscoped().Delete(image, image.ID).Error
+	})
+}
+
+// imageVersionOf creates a version from the current file of the image
+func imageVersionOf(image *models.Image) models.ImageVersion {
+	return models.ImageVersion{
+		ImageID:          image.ID,
+		Filename:         image.Filename,
+		OriginalFilename: image.OriginalFilename,
+		Size:             image.Size,
+		Width:            image.Width,
+		Height:           image.Height,
+	}
+}
+
+// setImageFile updates the file details of the image
+func setImageFile(tx *gorm.DB, image *models.Image, filename, originalFilename string, size int64, width, height int) error {
+	return tx.Model(image).Updates(map[string]any{
+		"filename":          filename,
+		"original_filename": originalFilename,
+		"size":              size,
+		"width":             width,
+		"height":            height,
+	}).Error
+}
+
+// findOrCreateTags returns the tags with the given names, creating any
+// that don't exist yet
+func findOrCreateTags(tx *gorm.DB, names []string) ([]models.Tag, error) {
+	tags := []models.Tag{}
+	for _, name := range utils.NormalizeTags(names) {
+		tag := models.Tag{Name: name}
+		if err := tx.Where(models.Tag{Name: name}).FirstOrCreate(&tag).Error; err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+
+	return tags, nil
+}
+
+// nextImagePosition returns the position after the last image in the gallery
+func nextImagePosition(tx *gorm.DB, galleryID uint) (int, error) {
+	var position *int
+	if err := tx.Model(&models.Image{}).Where("gallery_id = ?", galleryID).
+		Select("MAX(position)").Scan(&position).Error; err != nil {
+		return 0, err
+	}
+
+	if position == nil {
+		return 0, nil
+	}
+
+	return *position + 1, nil
 }
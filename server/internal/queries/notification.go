@@ -0,0 +1,57 @@
+package queries
+
+import (
+	"github.com/austinbspencer/gshare-server/internal/models"
+	"github.com/austinbspencer/gshare-server/platform/database"
+	"gorm.io/gorm"
+)
+
+type NotificationRepository interface {
+	GetGalleryNotifications(galleryID uint) ([]models.LiveNotification, error)
+	CreateNotification(notification *models.LiveNotification) error
+	UpdateOutboxNotifications(outboxEmail *models.OutboxEmail) error
+	RelinkOutboxNotifications(outboxEmailID, resentID uint) error
+}
+
+type notificationRepository struct {
+	db *gorm.DB
+}
+
+func NewNotificationRepository() NotificationRepository {
+	return &notificationRepository{db: database.DB}
+}
+
+// Get the live notifications of the gallery, most recent first
+func (r *notificationRepository) GetGalleryNotifications(galleryID uint) ([]models.LiveNotification, error) {
+	notifications := []models.LiveNotification{}
+
+	err := r.db.Model(&models.LiveNotification{}).Where("gallery_id = ?", galleryID).
+		Order("id DESC").Find(&notifications).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return notifications, nil
+}
+
+func (r *notificationRepository) CreateNotification(notification *models.LiveNotification) error {
+	return r.db.Create(notification).Error
+}
+
+// Update the notifications sent with the email to the status of the email
+// in the outbox
+func (r *notificationRepository) UpdateOutboxNotifications(outboxEmail *models.OutboxEmail) error {
+	return r.db.Model(&models.LiveNotification{}).Where("outbox_email_id = ?", outboxEmail.ID).
+		Updates(map[string]any{
+			"status":     outboxEmail.Status,
+			"last_error": outboxEmail.LastError,
+			"sent_at":    outboxEmail.SentAt,
+		}).Error
+}
+
+// Link the notifications of the email to the copy that was queued to
+// resend it
+func (r *notificationRepository) RelinkOutboxNotifications(outboxEmailID, resentID uint) error {
+	return r.db.Model(&models.LiveNotification{}).Where("outbox_email_id = ?", outboxEmailID).
+		Updates(map[string]any{"outbox_email_id": resentID, "status": models.OutboxPending}).Error
+}
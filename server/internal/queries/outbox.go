@@ -0,0 +1,84 @@
+package queries
+
+import (
+	"time"
+
+	"github.com/austinbspencer/gshare-server/internal/models"
+	"github.com/austinbspencer/gshare-server/platform/database"
+	"gorm.io/gorm"
+)
+
+type OutboxRepository interface {
+	GetOutboxEmails(search models.OutboxSearch) ([]models.OutboxEmail, error)
+	GetOutboxEmailByID(id string) (*models.OutboxEmail, error)
+	GetDueOutboxEmails(now time.Time, limit int) ([]models.OutboxEmail, error)
+	CreateOutboxEmail(outboxEmail *models.OutboxEmail) error
+	SaveOutboxEmail(outboxEmail *models.OutboxEmail) error
+}
+
+type outboxRepository struct {
+	db *gorm.DB
+}
+
+func NewOutboxRepository() OutboxRepository {
+	return &outboxRepository{db: database.DB}
+}
+
+// Get the most recent emails of the outbox matching the search
+func (r *outboxRepository) GetOutboxEmails(search models.OutboxSearch) ([]models.OutboxEmail, error) {
+	outboxEmails := []models.OutboxEmail{}
+
+	query := r.db.Model(&models.OutboxEmail{})
+	if search.Status != "" {
+		query = query.Where("status = ?", search.Status)
+	}
+	if search.Kind != "" {
+		query = query.Where("kind = ?", search.Kind)
+	}
+
+	limit := search.Limit
+	if limit <= 0 {
+		limit = models.DefaultOutboxLimit
+	} else if limit > models.MaxOutboxLimit {
+		limit = models.MaxOutboxLimit
+	}
+
+	if err := query.Order("id DESC").Limit(limit).Find(&outboxEmails).Error; err != nil {
+		return nil, err
+	}
+
+	return outboxEmails, nil
+}
+
+func (r *outboxRepository) GetOutboxEmailByID(id string) (*models.OutboxEmail, error) {
+	outboxEmail := &models.OutboxEmail{}
+
+	if err := r.db.Model(&models.OutboxEmail{}).Where("id = ?", id).First(outboxEmail).Error; err != nil {
+		return nil, err
+	}
+
+	return outboxEmail, nil
+}
+
+// Get the pending emails that are due to be sent, oldest first
+func (r *outboxRepository) GetDueOutboxEmails(now time.Time, limit int) ([]models.OutboxEmail, error) {
+	outboxEmails := []models.OutboxEmail{}
+
+	err := r.db.Model(&models.OutboxEmail{}).
+		Where("status = ? AND next_attempt_at <= ?", models.OutboxPending, now).
+		Order("next_attempt_at, id").Limit(limit).Find(&outboxEmails).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return outboxEmails, nil
+}
+
+func (r *outboxRepository) CreateOutboxEmail(outboxEmail *models.OutboxEmail) error {
+	return r.db.Create(outboxEmail).Error
+}
+
+// Save the result of an attempt to send the email
+func (r *outboxRepository) SaveOutboxEmail(outboxEmail *models.OutboxEmail) error {
+	return r.db.Save(outboxEmail).Error
+}
@@ -0,0 +1,190 @@
+package queries
+
+import (
+	"errors"
+
+	"github.com/austinbspencer/gshare-server/internal/models"
+	"github.com/austinbspencer/gshare-server/platform/database"
+	"gorm.io/gorm"
+)
+
+type GalleryPresetRepository interface {
+	GetGalleryPresets() ([]models.GalleryPreset, error)
+	GetGalleryPresetByID(id string) (*models.GalleryPreset, error)
+	GetGalleryPresetByName(name string) (*models.GalleryPreset, error)
+	GetDefaultGalleryPreset() (*models.GalleryPreset, error)
+	CreateGalleryPreset(preset *models.GalleryPreset, update models.GalleryPresetUpdate) error
+	UpdateGalleryPreset(preset *models.GalleryPreset, update models.GalleryPresetUpdate) error
+	DeleteGalleryPreset(preset *models.GalleryPreset) error
+}
+
+type galleryPresetRepository struct {
+	db *gorm.DB
+}
+
+func NewGalleryPresetRepository() GalleryPresetRepository {
+	return &galleryPresetRepository{db: database.DB}
+}
+
+func (r *galleryPresetRepository) GetGalleryPresets() ([]models.GalleryPreset, error) {
+	presets := []models.GalleryPreset{}
+
+	err := r.db.Model(&models.GalleryPreset{}).Order("name").Find(&presets).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return presets, nil
+}
+
+func (r *galleryPresetRepository) GetGalleryPresetByID(id string) (*models.GalleryPreset, error) {
+	var preset models.GalleryPreset
+
+	if err := r.db.Model(&models.GalleryPreset{}).First(&preset, id).Error; err != nil {
+		return nil, err
+	}
+
+	return &preset, nil
+}
+
+func (r *galleryPresetRepository) GetGalleryPresetByName(name string) (*models.GalleryPreset, error) {
+	var preset models.GalleryPreset
+
+	if err := r.db.Model(&models.GalleryPreset{}).First(&preset, "name = ?", name).Error; err != nil {
+		return nil, err
+	}
+
+	return &preset, nil
+}
+
+// Get the default preset, nil is returned when no preset is the default
+func (r *galleryPresetRepository) GetDefaultGalleryPreset() (*models.GalleryPreset, error) {
+	var preset models.GalleryPreset
+
+	err := r.db.Model(&models.GalleryPreset{}).First(&preset, "is_default = ?", true).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &preset, nil
+}
+
+// Create the preset with the update applied to the given settings
+func (r *galleryPresetRepository) CreateGalleryPreset(preset *models.GalleryPreset, update models.GalleryPresetUpdate) error {
+	applyGalleryPresetUpdate(preset, update)
+	if update.DownloadPolicy != nil {
+		if err := updateDownloadPolicy(&preset.DownloadPolicy, *update.DownloadPolicy); err != nil {
+			return err
+		}
+	}
+
+	// Fields with a default are skipped on create when they are false and
+	// then read back with the default, so they are saved explicitly after
+	// the preset is created
+	saved := *preset
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(preset).Error; err != nil {
+			return err
+		}
+
+		preset.HeroEnabled = saved.HeroEnabled
+		preset.DownloadPolicy.Single = saved.DownloadPolicy.Single
+		preset.DownloadPolicy.Selection = saved.DownloadPolicy.Selection
+		preset.DownloadPolicy.Gallery = saved.DownloadPolicy.Gallery
+
+		if err := tx.Model(preset).Updates(map[string]any{
+			"hero_enabled":       saved.HeroEnabled,
+			"download_single":    saved.DownloadPolicy.Single,
+			"download_selection": saved.DownloadPolicy.Selection,
+			"download_gallery":   saved.DownloadPolicy.Gallery,
+		}).Error; err != nil {
+			return err
+		}
+
+		if preset.Default {
+			return clearDefaultPresets(tx, preset.ID)
+		}
+
+		return nil
+	})
+}
+
+func (r *galleryPresetRepository) UpdateGalleryPreset(preset *models.GalleryPreset, update models.GalleryPresetUpdate) error {
+	applyGalleryPresetUpdate(preset, update)
+	if update.DownloadPolicy != nil {
+		if err := updateDownloadPolicy(&preset.DownloadPolicy, *update.DownloadPolicy); err != nil {
+			return err
+		}
+	}
+
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(preset).Error; err != nil {
+			return err
+		}
+
+		if preset.Default {
+			return clearDefaultPresets(tx, preset.ID)
+		}
+
+		return nil
+	})
+}
+
+// Fully delete the preset, galleries created with it are left unchanged
+func (r *galleryPresetRepository) DeleteGalleryPreset(preset *models.GalleryPreset) error {
+	return r.db.Unscoped().Delete(preset, preset.ID).Error
+}
+
+// Apply the update to the settings of the preset
+func applyGalleryPresetUpdate(preset *models.GalleryPreset, update models.GalleryPresetUpdate) {
+	if update.Name != nil {
+		preset.Name = *update.Name
+	}
+	if update.Default != nil {
+		preset.Default = *update.Default
+	}
+	if update.ExpirationDays != nil {
+		preset.ExpirationDays = *update.ExpirationDays
+	}
+	if update.Public != nil {
+		preset.Public = *update.Public
+	}
+	if update.Protected != nil {
+		preset.Protected = *update.Protected
+	}
+	if update.Reminder != nil {
+		preset.Reminder = *update.Reminder
+	}
+	if update.ReminderEmails != nil {
+		if *update.ReminderEmails == "" {
+			preset.ReminderEmails = nil
+		} else {
+			preset.ReminderEmails = update.ReminderEmails
+		}
+	}
+	if update.ReminderDays != nil {
+		preset.ReminderDays = *update.ReminderDays
+	}
+	if update.LiveNotification != nil {
+		preset.LiveNotification = *update.LiveNotification
+	}
+	if update.HeroEnabled != nil {
+		preset.HeroEnabled = *update.HeroEnabled
+	}
+	if update.HeroVariant != nil {
+		preset.HeroVariant = *update.HeroVariant
+	}
+	if update.SignedURLs != nil {
+		preset.SignedURLs = *update.SignedURLs
+	}
+}
+
+// Only a single preset can be the default
+func clearDefaultPresets(tx *gorm.DB, defaultID uint) error {
+	return tx.Model(&models.GalleryPreset{}).Where("id <> ? AND is_default = ?", defaultID, true).
+		Update("is_default", false).Error
+}
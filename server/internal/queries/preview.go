@@ -0,0 +1,100 @@
+package queries
+
+import (
+	"time"
+
+	"github.com/austinbspencer/gshare-server/internal/models"
+	"github.com/austinbspencer/gshare-server/platform/database"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PreviewRepository interface {
+	GetGalleryPreviewLinks(galleryID uint) ([]models.PreviewLink, error)
+	GetPreviewLinkByID(galleryID uint, id string) (*models.PreviewLink, error)
+	CreatePreviewLink(link *models.PreviewLink) error
+	RevokePreviewLink(link *models.PreviewLink) error
+	DeletePreviewLink(link *models.PreviewLink) error
+	AddPreviewFavorite(link *models.PreviewLink, imageID uint) error
+	RemovePreviewFavorite(link *models.PreviewLink, imageID uint) error
+}
+
+type previewRepository struct {
+	db *gorm.DB
+}
+
+func NewPreviewRepository() PreviewRepository {
+	return &previewRepository{db: database.DB}
+}
+
+func (r *previewRepository) GetGalleryPreviewLinks(galleryID uint) ([]models.PreviewLink, error) {
+	links := []models.PreviewLink{}
+
+	err := r.db.Model(&models.PreviewLink{}).Preload("Favorites").
+		Where("gallery_id = ?", galleryID).
+		Order("created_at DESC").Find(&links).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return links, nil
+}
+
+// Get the preview link of the gallery, a gallery ID of 0 matches any gallery
+func (r *previewRepository) GetPreviewLinkByID(galleryID uint, id string) (*models.PreviewLink, error) {
+	var link models.PreviewLink
+
+	query := r.db.Model(&models.PreviewLink{}).Preload("Favorites")
+	if galleryID != 0 {
+		query = query.Where("gallery_id = ?", galleryID)
+	}
+
+	if err := query.First(&link, id).Error; err != nil {
+		return nil, err
+	}
+
+	return &link, nil
+}
+
+func (r *previewRepository) CreatePreviewLink(link *models.PreviewLink) error {
+	return r.db.Create(link).Error
+}
+
+func (r *previewRepository) RevokePreviewLink(link *models.PreviewLink) error {
+	now := time.Now()
+
+	if err := r.db.Model(link).Update("revoked_at", now).Error; err != nil {
+		return err
+	}
+
+	link.RevokedAt = &now
+
+	return nil
+}
+
+// Fully delete the preview link along with its favorites
+func (r *previewRepository) DeletePreviewLink(link *models.PreviewLink) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("preview_link_id = ?", link.ID).
+			Delete(&models.PreviewFavorite{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Unscoped().Delete(link, link.ID).Error
+	})
+}
+
+func (r *previewRepository) AddPreviewFavorite(link *models.PreviewLink, imageID uint) error {
+	favorite := models.PreviewFavorite{
+		PreviewLinkID: link.ID,
+		ImageID:       imageID,
+	}
+
+	// Favoriting the same image twice is a no-op
+	return r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(&favorite).Error
+}
+
+func (r *previewRepository) RemovePreviewFavorite(link *models.PreviewLink, imageID uint) error {
+	return r.db.Where("preview_link_id = ? AND image_id = ?", link.ID, imageID).
+		Delete(&models.PreviewFavorite{}).Error
+}
@@ -0,0 +1,69 @@
+package queries
+
+import (
+	"github.com/austinbspencer/gshare-server/internal/models"
+	"github.com/austinbspencer/gshare-server/platform/database"
+	"gorm.io/gorm"
+)
+
+type ReminderRepository interface {
+	GetGalleryReminders(galleryID uint) ([]models.GalleryReminder, error)
+	SaveReminder(reminder *models.GalleryReminder) error
+	DeleteGalleryReminders(galleryID uint) error
+	UpdateOutboxReminders(outboxEmail *models.OutboxEmail) error
+	RelinkOutboxReminders(outboxEmailID, resentID uint) error
+}
+
+type reminderRepository struct {
+	db *gorm.DB
+}
+
+func NewReminderRepository() ReminderRepository {
+	return &reminderRepository{db: database.DB}
+}
+
+func (r *reminderRepository) GetGalleryReminders(galleryID uint) ([]models.GalleryReminder, error) {
+	reminders := []models.GalleryReminder{}
+
+	err := r.db.Model(&models.GalleryReminder{}).Where("gallery_id = ?", galleryID).
+		Order("days DESC, email").Find(&reminders).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return reminders, nil
+}
+
+// Create the reminder or save the changes to it
+func (r *reminderRepository) SaveReminder(reminder *models.GalleryReminder) error {
+	return r.db.Save(reminder).Error
+}
+
+// Delete the reminders of the gallery so they are sent again, such as
+// after the expiration changed
+func (r *reminderRepository) DeleteGalleryReminders(galleryID uint) error {
+	return r.db.Unscoped().Where("gallery_id = ?", galleryID).
+		Delete(&models.GalleryReminder{}).Error
+}
+
+// Update the reminders sent with the email to the status of the email in
+// the outbox
+func (r *reminderRepository) UpdateOutboxReminders(outboxEmail *models.OutboxEmail) error {
+	updates := map[string]any{"last_error": outboxEmail.LastError}
+	switch outboxEmail.Status {
+	case models.OutboxSent:
+		updates["status"] = models.ReminderSent
+		updates["sent_at"] = outboxEmail.SentAt
+	case models.OutboxFailed:
+		updates["status"] = models.ReminderFailed
+	}
+
+	return r.db.Model(&models.GalleryReminder{}).
+		Where("outbox_email_id = ?", outboxEmail.ID).Updates(updates).Error
+}
+
+// Link the reminders of the email to the copy that was queued to resend it
+func (r *reminderRepository) RelinkOutboxReminders(outboxEmailID, resentID uint) error {
+	return r.db.Model(&models.GalleryReminder{}).Where("outbox_email_id = ?", outboxEmailID).
+		Updates(map[string]any{"outbox_email_id": resentID, "status": models.ReminderQueued}).Error
+}
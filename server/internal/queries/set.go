@@ -0,0 +1,141 @@
+package queries
+
+import (
+	"errors"
+
+	"github.com/austinbspencer/gshare-server/internal/models"
+	"github.com/austinbspencer/gshare-server/platform/database"
+	"gorm.io/gorm"
+)
+
+// ErrUnknownGallerySet is returned when an order references a set that is
+// not in the gallery
+var ErrUnknownGallerySet = errors.New("set does not belong to the gallery")
+
+type GallerySetRepository interface {
+	GetGallerySets(galleryID uint) ([]models.GallerySet, error)
+	GetGallerySetByID(galleryID uint, id string) (*models.GallerySet, error)
+	GetGallerySetImages(set *models.GallerySet) ([]models.Image, error)
+	CreateGallerySet(set *models.GallerySet) error
+	UpdateGallerySet(set *models.GallerySet, update models.GallerySetUpdate) error
+	SetGalleryImagesOrder(galleryID uint, order models.GalleryImagesOrder) error
+	DeleteGallerySet(set *models.GallerySet) error
+}
+
+type gallerySetRepository struct {
+	db *gorm.DB
+}
+
+func NewGallerySetRepository() GallerySetRepository {
+	return &gallerySetRepository{db: database.DB}
+}
+
+func (r *gallerySetRepository) GetGallerySets(galleryID uint) ([]models.GallerySet, error) {
+	sets := []models.GallerySet{}
+
+	err := r.db.Model(&models.GallerySet{}).Where("gallery_id = ?", galleryID).
+		Order("position").Find(&sets).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return sets, nil
+}
+
+func (r *gallerySetRepository) GetGallerySetByID(galleryID uint, id string) (*models.GallerySet, error) {
+	var set models.GallerySet
+
+	if err := r.db.Model(&models.GallerySet{}).Where("gallery_id = ?", galleryID).
+		First(&set, id).Error; err != nil {
+		return nil, err
+	}
+
+	return &set, nil
+}
+
+func (r *gallerySetRepository) GetGallerySetImages(set *models.GallerySet) ([]models.Image, error) {
+	images := []models.Image{}
+
+	err := r.db.Model(&models.Image{}).Where("gallery_id = ? AND set_id = ?", set.GalleryID, set.ID).
+		Order("position").Find(&images).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return images, nil
+}
+
+// Create the set after the existing sets of the gallery
+func (r *gallerySetRepository) CreateGallerySet(set *models.GallerySet) error {
+	var count int64
+	if err := r.db.Model(&models.GallerySet{}).Where("gallery_id = ?", set.GalleryID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+
+	set.Position = int(count)
+
+	return r.db.Create(set).Error
+}
+
+func (r *gallerySetRepository) UpdateGallerySet(set *models.GallerySet, update models.GallerySetUpdate) error {
+	if update.Title != nil {
+		set.Title = *update.Title
+	}
+
+	return r.db.Save(set).Error
+}
+
+// Set the order of the sets and the images within them. Images listed
+// outside of a set are removed from their set. Images not listed are left
+// unchanged.
+func (r *gallerySetRepository) SetGalleryImagesOrder(galleryID uint, order models.GalleryImagesOrder) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := setImagesOrder(tx, galleryID, nil, order.Images); err != nil {
+			return err
+		}
+
+		for idx, setImages := range order.Sets {
+			result := tx.Model(&models.GallerySet{}).
+				Where("id = ? AND gallery_id = ?", setImages.SetID, galleryID).
+				Update("position", idx)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return ErrUnknownGallerySet
+			}
+
+			if err := setImagesOrder(tx, galleryID, &setImages.SetID, setImages.Images); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// Fully delete the set, its images remain in the gallery without a set
+func (r *gallerySetRepository) DeleteGallerySet(set *models.GallerySet) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Image{}).Where("set_id = ?", set.ID).
+			Updates(map[string]any{"set_id": nil}).Error; err != nil {
+			return err
+		}
+
+		// Delete with unscoped so the title can be reused
+		return tx.Unscoped().Delete(set, set.ID).Error
+	})
+}
+
+// setImagesOrder moves the images into the set in the given order
+func setImagesOrder(tx *gorm.DB, galleryID uint, setID *uint, imageIDs []uint) error {
+	for idx, imageID := range imageIDs {
+		if err := tx.Model(&models.Image{}).Where("id = ? AND gallery_id = ?", imageID, galleryID).
+			Updates(map[string]any{"set_id": setID, "position": idx}).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
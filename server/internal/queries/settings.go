@@ -11,6 +11,9 @@ type SettingsRepository interface {
 	GetSettings() (*models.Settings, error)
 	UpdateSettings(settings *models.Settings) error
 	SetSettingsUpdate(update bool) error
+	SetDeployStatus(settings *models.Settings) error
+	GetDerivativesVersion() (int, error)
+	BumpDerivativesVersion() (int, error)
 }
 
 type settingsRepository struct {
@@ -39,3 +42,34 @@ func (r *settingsRepository) UpdateSettings(settings *models.Settings) error {
 func (r *settingsRepository) SetSettingsUpdate(update bool) error {
 	return r.db.Model(models.Settings{}).Where("id = 1").Update("update", update).Error
 }
+
+// SetDeployStatus saves the status of the last deploy of the client site.
+func (r *settingsRepository) SetDeployStatus(settings *models.Settings) error {
+	return r.db.Model(models.Settings{}).Where("id = 1").
+		Select("deploy_status", "deploy_paths", "deploy_error", "deployed_at").
+		Updates(settings).Error
+}
+
+// GetDerivativesVersion gets the version of the image derivatives.
+func (r *settingsRepository) GetDerivativesVersion() (int, error) {
+	var version int
+	err := r.db.Model(models.Settings{}).Where("id = 1").
+		Select("derivatives_version").Scan(&version).Error
+	return version, err
+}
+
+// BumpDerivativesVersion raises the version of the image derivatives and
+// returns the new version.
+func (r *settingsRepository) BumpDerivativesVersion() (int, error) {
+	var version int
+	err := r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(models.Settings{}).Where("id = 1").
+			UpdateColumn("derivatives_version", gorm.Expr("derivatives_version + 1")).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(models.Settings{}).Where("id = 1").
+			Select("derivatives_version").Scan(&version).Error
+	})
+	return version, err
+}
@@ -0,0 +1,63 @@
+package queries
+
+import (
+	"errors"
+
+	"github.com/austinbspencer/gshare-server/internal/models"
+	"github.com/austinbspencer/gshare-server/platform/database"
+	"gorm.io/gorm"
+)
+
+type EmailTemplateRepository interface {
+	GetEmailTemplate(kind models.EmailKind) (*models.EmailTemplate, error)
+	SaveEmailTemplate(template *models.EmailTemplate) error
+	DeleteEmailTemplate(kind models.EmailKind) error
+}
+
+type emailTemplateRepository struct {
+	db *gorm.DB
+}
+
+func NewEmailTemplateRepository() EmailTemplateRepository {
+	return &emailTemplateRepository{db: database.DB}
+}
+
+// Get the changed template of the email, gorm.ErrRecordNotFound is returned
+// while the shipped template is used
+func (r *emailTemplateRepository) GetEmailTemplate(kind models.EmailKind) (*models.EmailTemplate, error) {
+	template := &models.EmailTemplate{}
+
+	if err := r.db.Model(&models.EmailTemplate{}).Where("kind = ?", kind).First(template).Error; err != nil {
+		return nil, err
+	}
+
+	template.Custom = true
+
+	return template, nil
+}
+
+// Create the changed template of the email or save the changes to it
+func (r *emailTemplateRepository) SaveEmailTemplate(template *models.EmailTemplate) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		existing := &models.EmailTemplate{}
+		err := tx.Model(&models.EmailTemplate{}).Where("kind = ?", template.Kind).First(existing).Error
+		if err == nil {
+			template.Model = existing.Model
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		if err := tx.Save(template).Error; err != nil {
+			return err
+		}
+
+		template.Custom = true
+
+		return nil
+	})
+}
+
+// Delete the changed template so the shipped template is used again
+func (r *emailTemplateRepository) DeleteEmailTemplate(kind models.EmailKind) error {
+	return r.db.Unscoped().Where("kind = ?", kind).Delete(&models.EmailTemplate{}).Error
+}
@@ -0,0 +1,146 @@
+package queries
+
+import (
+	"time"
+
+	"github.com/austinbspencer/gshare-server/internal/models"
+	"github.com/austinbspencer/gshare-server/platform/database"
+	"gorm.io/gorm"
+)
+
+type WebhookRepository interface {
+	GetWebhooks() ([]models.Webhook, error)
+	GetEnabledWebhooks() ([]models.Webhook, error)
+	GetWebhookByID(id string) (*models.Webhook, error)
+	CreateWebhook(webhook *models.Webhook) error
+	UpdateWebhook(webhook *models.Webhook) error
+	DeleteWebhook(webhook *models.Webhook) error
+	GetWebhookDeliveries(webhookID uint, limit int) ([]models.WebhookDelivery, error)
+	GetWebhookDeliveryByID(webhookID uint, id string) (*models.WebhookDelivery, error)
+	GetDueWebhookDeliveries(now time.Time, limit int) ([]models.WebhookDelivery, error)
+	CreateWebhookDelivery(delivery *models.WebhookDelivery) error
+	SaveWebhookDelivery(delivery *models.WebhookDelivery) error
+}
+
+type webhookRepository struct {
+	db *gorm.DB
+}
+
+func NewWebhookRepository() WebhookRepository {
+	return &webhookRepository{db: database.DB}
+}
+
+func (r *webhookRepository) GetWebhooks() ([]models.Webhook, error) {
+	webhooks := []models.Webhook{}
+
+	if err := r.db.Model(&models.Webhook{}).Order("id").Find(&webhooks).Error; err != nil {
+		return nil, err
+	}
+
+	return webhooks, nil
+}
+
+func (r *webhookRepository) GetEnabledWebhooks() ([]models.Webhook, error) {
+	webhooks := []models.Webhook{}
+
+	if err := r.db.Model(&models.Webhook{}).Where("enabled = ?", true).Order("id").Find(&webhooks).Error; err != nil {
+		return nil, err
+	}
+
+	return webhooks, nil
+}
+
+func (r *webhookRepository) GetWebhookByID(id string) (*models.Webhook, error) {
+	webhook := &models.Webhook{}
+
+	if err := r.db.Model(&models.Webhook{}).Where("id = ?", id).First(webhook).Error; err != nil {
+		return nil, err
+	}
+
+	return webhook, nil
+}
+
+func (r *webhookRepository) CreateWebhook(webhook *models.Webhook) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(webhook).Error; err != nil {
+			return err
+		}
+
+		// Enabled is skipped on create when false since it has a default
+		return tx.Model(webhook).UpdateColumn("enabled", webhook.Enabled).Error
+	})
+}
+
+func (r *webhookRepository) UpdateWebhook(webhook *models.Webhook) error {
+	return r.db.Model(webhook).Select("url", "events", "secret", "enabled").Updates(webhook).Error
+}
+
+// Delete the webhook along with its delivery log
+func (r *webhookRepository) DeleteWebhook(webhook *models.Webhook) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Where("webhook_id = ?", webhook.ID).
+			Delete(&models.WebhookDelivery{}).Error; err != nil {
+			return err
+		}
+
+		return tx.Unscoped().Delete(webhook).Error
+	})
+}
+
+// Get the most recent deliveries of the webhook
+func (r *webhookRepository) GetWebhookDeliveries(webhookID uint, limit int) ([]models.WebhookDelivery, error) {
+	deliveries := []models.WebhookDelivery{}
+
+	if limit <= 0 {
+		limit = models.DefaultDeliveriesLimit
+	} else if limit > models.MaxDeliveriesLimit {
+		limit = models.MaxDeliveriesLimit
+	}
+
+	err := r.db.Model(&models.WebhookDelivery{}).Where("webhook_id = ?", webhookID).
+		Order("id DESC").Limit(limit).Find(&deliveries).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+func (r *webhookRepository) GetWebhookDeliveryByID(webhookID uint, id string) (*models.WebhookDelivery, error) {
+	delivery := &models.WebhookDelivery{}
+
+	err := r.db.Model(&models.WebhookDelivery{}).Where("webhook_id = ? AND id = ?", webhookID, id).
+		First(delivery).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return delivery, nil
+}
+
+// Get the pending deliveries that are due to be posted, oldest first. The
+// deliveries of disabled webhooks wait until the webhook is enabled again.
+func (r *webhookRepository) GetDueWebhookDeliveries(now time.Time, limit int) ([]models.WebhookDelivery, error) {
+	deliveries := []models.WebhookDelivery{}
+
+	disabled := r.db.Model(&models.Webhook{}).Select("id").Where("enabled = ?", false)
+
+	err := r.db.Model(&models.WebhookDelivery{}).
+		Where("status = ? AND next_attempt_at <= ?", models.OutboxPending, now).
+		Where("webhook_id NOT IN (?)", disabled).
+		Order("next_attempt_at, id").Limit(limit).Find(&deliveries).Error
+	if err != nil {
+		return nil, err
+	}
+
+	return deliveries, nil
+}
+
+func (r *webhookRepository) CreateWebhookDelivery(delivery *models.WebhookDelivery) error {
+	return r.db.Create(delivery).Error
+}
+
+// Save the result of an attempt to post the delivery
+func (r *webhookRepository) SaveWebhookDelivery(delivery *models.WebhookDelivery) error {
+	return r.db.Save(delivery).Error
+}
@@ -0,0 +1,16 @@
+package v1routes
+
+import (
+	"github.com/austinbspencer/gshare-server/internal/controllers"
+	"github.com/austinbspencer/gshare-server/pkg/middleware"
+	"github.com/gofiber/fiber/v2"
+)
+
+func ClientPrivateRoutes(a *fiber.App) {
+	// Create routes group.
+	route := a.Group("/api/v1")
+
+	clients := route.Group("/clients", middleware.JWTProtected())
+
+	clients.Get("", controllers.GetClients)
+}
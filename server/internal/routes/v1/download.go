@@ -11,7 +11,8 @@ func DownloadPublicRoutes(a *fiber.App) {
 
 	download := route.Group("/download/:size")
 
-	download.Get("/image/:imageID", controllers.DownloadImage)
-	download.Get("/image/:imageIDs", controllers.DownloadImages)
-	download.Get("/gallery/:galleryID", controllers.DownloadGallery)
+	download.Get("/image/:imagePublicID", controllers.DownloadImage)
+	download.Get("/images/:imagePublicIDs", controllers.DownloadImages)
+	download.Get("/gallery/:galleryPublicID", controllers.DownloadGallery)
+	download.Get("/gallery/:galleryPublicID/set/:setID", controllers.DownloadGallerySet)
 }
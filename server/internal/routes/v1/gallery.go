@@ -16,7 +16,10 @@ func GalleryPublicRoutes(a fiber.Router) {
 	gallery.Get("/live", controllers.GetLiveGalleries)
 	gallery.Get("/path/:galleryPath", controllers.GetGalleryByPath)
 	gallery.Post("/path/:galleryPath", controllers.UnlockGallery)
-	gallery.Get("/id/:galleryID/qr-code", controllers.GetGalleryQRCode)
+	gallery.Get("/public-id/:galleryPublicID/qr-code", controllers.GetGalleryQRCode)
+	gallery.Get("/preview", controllers.GetGalleryPreview)
+	gallery.Post("/preview/favorites/:imagePublicID", controllers.AddPreviewFavorite)
+	gallery.Delete("/preview/favorites/:imagePublicID", controllers.RemovePreviewFavorite)
 }
 
 func GalleryPrivateRoutes(a *fiber.App) {
@@ -30,7 +33,24 @@ func GalleryPrivateRoutes(a *fiber.App) {
 	gallery.Get("/id/:galleryID", controllers.GetGallery)
 	gallery.Put("/id/:galleryID", controllers.UpdateGallery)
 	gallery.Delete("/id/:galleryID", controllers.DeleteGallery)
+	gallery.Post("/id/:galleryID/clone", controllers.CloneGallery)
 	gallery.Post("/id/:galleryID/images", controllers.UploadGalleryImage)
 	gallery.Put("/id/:galleryID/images", controllers.UpdateGalleryImagesOrder)
 	gallery.Post("/id/:galleryID/images/zip", controllers.CreateGalleryImageZips)
+	gallery.Post("/id/:galleryID/images/reprocess", controllers.ReprocessGalleryImages)
+	gallery.Get("/id/:galleryID/sets", controllers.GetGallerySets)
+	gallery.Post("/id/:galleryID/sets", controllers.CreateGallerySet)
+	gallery.Put("/id/:galleryID/sets/:setID", controllers.UpdateGallerySet)
+	gallery.Delete("/id/:galleryID/sets/:setID", controllers.DeleteGallerySet)
+	gallery.Get("/id/:galleryID/downloads", controllers.GetGalleryDownloads)
+	gallery.Get("/id/:galleryID/previews", controllers.GetPreviewLinks)
+	gallery.Post("/id/:galleryID/previews", controllers.CreatePreviewLink)
+	gallery.Post("/id/:galleryID/previews/:previewID/revoke", controllers.RevokePreviewLink)
+	gallery.Delete("/id/:galleryID/previews/:previewID", controllers.DeletePreviewLink)
+	gallery.Get("/id/:galleryID/reminders", controllers.GetGalleryReminders)
+	gallery.Get("/id/:galleryID/notifications", controllers.GetGalleryNotifications)
+	gallery.Get("/id/:galleryID/contacts", controllers.GetGalleryContacts)
+	gallery.Post("/id/:galleryID/contacts", controllers.CreateGalleryContact)
+	gallery.Put("/id/:galleryID/contacts/:contactID", controllers.UpdateGalleryContact)
+	gallery.Delete("/id/:galleryID/contacts/:contactID", controllers.DeleteGalleryContact)
 }
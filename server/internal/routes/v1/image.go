@@ -12,7 +12,7 @@ func ImagePublicRoutes(a fiber.Router) {
 
 	image := route.Group("/images")
 
-	image.Get("/:imageID/:width/:quality", controllers.GetImageSized)
+	image.Get("/:imagePublicID/:width/:quality", controllers.GetImageSized)
 }
 
 func ImagePrivateRoutes(a *fiber.App) {
@@ -22,6 +22,14 @@ func ImagePrivateRoutes(a *fiber.App) {
 	image := route.Group("/images", middleware.JWTProtected())
 
 	image.Get("", controllers.GetImages)
+	image.Put("", controllers.UpdateImages)
+	image.Get("/tags", controllers.GetImageTags)
+	image.Post("/move", controllers.MoveImages)
+	image.Post("/copy", controllers.CopyImages)
 	image.Get("/:imageID", controllers.GetImage)
+	image.Put("/:imageID", controllers.UpdateImage)
 	image.Delete("/:imageID", controllers.DeleteImage)
+	image.Post("/:imageID/replace", controllers.ReplaceImage)
+	image.Get("/:imageID/versions", controllers.GetImageVersions)
+	image.Post("/:imageID/versions/:versionID/revert", controllers.RevertImageVersion)
 }
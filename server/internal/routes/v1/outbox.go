@@ -0,0 +1,18 @@
+package v1routes
+
+import (
+	"github.com/austinbspencer/gshare-server/internal/controllers"
+	"github.com/austinbspencer/gshare-server/pkg/middleware"
+	"github.com/gofiber/fiber/v2"
+)
+
+func OutboxPrivateRoutes(a *fiber.App) {
+	// Create routes group.
+	route := a.Group("/api/v1")
+
+	outbox := route.Group("/outbox", middleware.JWTProtected())
+
+	outbox.Get("", controllers.GetOutboxEmails)
+	outbox.Get("/:emailID", controllers.GetOutboxEmail)
+	outbox.Post("/:emailID/resend", controllers.ResendOutboxEmail)
+}
@@ -0,0 +1,20 @@
+package v1routes
+
+import (
+	"github.com/austinbspencer/gshare-server/internal/controllers"
+	"github.com/austinbspencer/gshare-server/pkg/middleware"
+	"github.com/gofiber/fiber/v2"
+)
+
+func PresetPrivateRoutes(a *fiber.App) {
+	// Create routes group.
+	route := a.Group("/api/v1")
+
+	preset := route.Group("/presets", middleware.JWTProtected())
+
+	preset.Get("", controllers.GetGalleryPresets)
+	preset.Post("", controllers.CreateGalleryPreset)
+	preset.Get("/:presetID", controllers.GetGalleryPreset)
+	preset.Put("/:presetID", controllers.UpdateGalleryPreset)
+	preset.Delete("/:presetID", controllers.DeleteGalleryPreset)
+}
@@ -0,0 +1,16 @@
+package v1routes
+
+import (
+	"github.com/austinbspencer/gshare-server/internal/controllers"
+	"github.com/austinbspencer/gshare-server/pkg/middleware"
+	"github.com/gofiber/fiber/v2"
+)
+
+func RetentionPrivateRoutes(a *fiber.App) {
+	// Create routes group.
+	route := a.Group("/api/v1")
+
+	retention := route.Group("/retention", middleware.JWTProtected())
+
+	retention.Get("", controllers.GetRetentionReport)
+}
@@ -1,10 +1,19 @@
 package v1routes
 
-// func ServerPrivateRoutes(a *fiber.App) {
-// 	// Create routes group.
-// 	route := a.Group("/api/v1")
+import (
+	"github.com/austinbspencer/gshare-server/internal/controllers"
+	"github.com/austinbspencer/gshare-server/pkg/middleware"
+	"github.com/gofiber/fiber/v2"
+)
 
-// 	server := route.Group("/server", middleware.JWTProtected())
+func ServerPrivateRoutes(a *fiber.App) {
+	// Create routes group.
+	route := a.Group("/api/v1")
 
-// 	server.Get("/cache/clear", controllers.ClearCache)
-// }
+	server := route.Group("/server", middleware.JWTProtected())
+
+	// server.Get("/cache/clear", controllers.ClearCache)
+	server.Post("/reprocess", controllers.ReprocessImages)
+	server.Get("/jobs", controllers.GetReprocessJobs)
+	server.Get("/jobs/:jobID", controllers.GetReprocessJob)
+}
@@ -0,0 +1,20 @@
+package v1routes
+
+import (
+	"github.com/austinbspencer/gshare-server/internal/controllers"
+	"github.com/austinbspencer/gshare-server/pkg/middleware"
+	"github.com/gofiber/fiber/v2"
+)
+
+func EmailTemplatePrivateRoutes(a *fiber.App) {
+	// Create routes group.
+	route := a.Group("/api/v1")
+
+	templates := route.Group("/email/templates", middleware.JWTProtected())
+
+	templates.Get("", controllers.GetEmailTemplates)
+	templates.Get("/:kind", controllers.GetEmailTemplate)
+	templates.Put("/:kind", controllers.UpdateEmailTemplate)
+	templates.Delete("/:kind", controllers.ResetEmailTemplate)
+	templates.Post("/:kind/preview", controllers.PreviewEmailTemplate)
+}
@@ -0,0 +1,20 @@
+package v1routes
+
+import (
+	"github.com/austinbspencer/gshare-server/internal/controllers"
+	"github.com/austinbspencer/gshare-server/pkg/middleware"
+	"github.com/gofiber/fiber/v2"
+)
+
+func TrashPrivateRoutes(a *fiber.App) {
+	// Create routes group.
+	route := a.Group("/api/v1")
+
+	trash := route.Group("/trash", middleware.JWTProtected())
+
+	trash.Get("", controllers.GetTrash)
+	trash.Post("/galleries/:galleryID/restore", controllers.RestoreTrashedGallery)
+	trash.Delete("/galleries/:galleryID", controllers.PurgeTrashedGallery)
+	trash.Post("/images/:imageID/restore", controllers.RestoreTrashedImage)
+	trash.Delete("/images/:imageID", controllers.PurgeTrashedImage)
+}
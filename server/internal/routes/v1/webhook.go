@@ -0,0 +1,23 @@
+package v1routes
+
+import (
+	"github.com/austinbspencer/gshare-server/internal/controllers"
+	"github.com/austinbspencer/gshare-server/pkg/middleware"
+	"github.com/gofiber/fiber/v2"
+)
+
+func WebhookPrivateRoutes(a *fiber.App) {
+	// Create routes group.
+	route := a.Group("/api/v1")
+
+	webhooks := route.Group("/webhooks", middleware.JWTProtected())
+
+	webhooks.Get("", controllers.GetWebhooks)
+	webhooks.Post("", controllers.CreateWebhook)
+	webhooks.Get("/:webhookID", controllers.GetWebhook)
+	webhooks.Put("/:webhookID", controllers.UpdateWebhook)
+	webhooks.Delete("/:webhookID", controllers.DeleteWebhook)
+	webhooks.Post("/:webhookID/ping", controllers.PingWebhook)
+	webhooks.Get("/:webhookID/deliveries", controllers.GetWebhookDeliveries)
+	webhooks.Post("/:webhookID/deliveries/:deliveryID/redeliver", controllers.RedeliverWebhookDelivery)
+}
@@ -8,15 +8,23 @@ import (
 func VersionedRoutes(a *fiber.App) {
 	v1routes.AuthPublicRoutes(a)
 	v1routes.AuthPrivateRoutes(a)
+	v1routes.ClientPrivateRoutes(a)
 	v1routes.DownloadPublicRoutes(a)
+	v1routes.EmailTemplatePrivateRoutes(a)
 	v1routes.EventPublicRoutes(a)
 	v1routes.EventPrivateRoutes(a)
 	v1routes.GalleryPublicRoutes(a)
 	v1routes.GalleryPrivateRoutes(a)
 	v1routes.ImagePublicRoutes(a)
 	v1routes.ImagePrivateRoutes(a)
+	v1routes.OutboxPrivateRoutes(a)
+	v1routes.PresetPrivateRoutes(a)
+	v1routes.RetentionPrivateRoutes(a)
 	v1routes.SettingsPublicRoutes(a)
+	v1routes.ServerPrivateRoutes(a)
 	v1routes.SettingsPrivateRoutes(a)
+	v1routes.TrashPrivateRoutes(a)
 	v1routes.UserPublicRoutes(a)
 	v1routes.UserPrivateRoutes(a)
+	v1routes.WebhookPrivateRoutes(a)
 }
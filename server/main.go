@@ -8,8 +8,9 @@ import (
 	"github.com/austinbspencer/gshare-server/pkg/configs"
 	"github.com/austinbspencer/gshare-server/pkg/middleware"
 	"github.com/austinbspencer/gshare-server/platform/database"
-	"github.com/austinbspencer/gshare-server/platform/docker"
+	"github.com/austinbspencer/gshare-server/platform/deploy"
 	"github.com/austinbspencer/gshare-server/platform/email"
+	"github.com/austinbspencer/gshare-server/platform/webhook"
 	"github.com/austinbspencer/gshare-server/runner"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/log"
@@ -18,13 +19,10 @@ import (
 )
 
 func init() {
-	// Test docker setup
-	err := docker.TestDockerConnection()
-	if err != nil {
-		log.Warnf("Error encountered while testing the docker connection: %v\n", err)
-	}
-
+	configs.CheckRequiredEnv()
 	database.Connect()
+	// Test the client deployer setup
+	deploy.TestDeployer()
 	email.TestEmailConnection()
 	// Set swagger docs
 	configs.SetDocsInfo()
@@ -38,6 +36,11 @@ func init() {
 // @in header
 // @name Authorization
 func main() {
+	// Run a one-off command instead of the server when one is given
+	if len(os.Args) > 1 {
+		os.Exit(runCommand(os.Args[1:]))
+	}
+
 	// Define a new Fiber app with config.
 	app := fiber.New(configs.FiberConfig())
 
@@ -50,6 +53,15 @@ func main() {
 	// Run scheduled scripts
 	runner.RunScripts()
 
+	// Send the emails in the outbox
+	email.RunOutbox()
+
+	// Drop the cached images when the reprocess command rebuilds them
+	runner.WatchDerivatives()
+
+	// Post the webhook deliveries
+	webhook.RunDeliveries()
+
 	// starting server with a graceful shutdown.
 	startServerWithGracefulShutdown(app)
 }
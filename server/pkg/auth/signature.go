@@ -0,0 +1,186 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/austinbspencer/gshare-server/pkg/configs"
+)
+
+var (
+	// ErrSignatureInvalid is returned when the signature doesn't match the resource
+	ErrSignatureInvalid = errors.New("the URL signature is invalid")
+	// ErrSignatureExpired is returned when the signed URL has expired
+	ErrSignatureExpired = errors.New("the URL signature has expired")
+	// ErrGrantInvalid is returned when the access grant isn't valid for the gallery
+	ErrGrantInvalid = errors.New("the gallery access grant is invalid or expired")
+	// ErrPreviewInvalid is returned when the preview link token isn't valid
+	ErrPreviewInvalid = errors.New("the preview link is invalid or expired")
+)
+
+// ImageResource is the signed resource for every URL of an image
+func ImageResource(imageID uint) string {
+	return fmt.Sprintf("image:%d", imageID)
+}
+
+// GalleryResource is the signed resource for the zip downloads of a gallery
+func GalleryResource(galleryID uint) string {
+	return fmt.Sprintf("gallery:%d", galleryID)
+}
+
+// PreviewResource is the signed resource for the URLs admins use to preview
+// the resource, which work outside of the live window of the gallery
+func PreviewResource(resource string) string {
+	return "preview:" + resource
+}
+
+// PreviewLinkResource is the signed resource for the URLs of a preview
+// link, which stop working when the link is revoked
+func PreviewLinkResource(linkID uint, resource string) string {
+	return fmt.Sprintf("preview-link:%d:%s", linkID, resource)
+}
+
+// SignResource signs the resource and returns the query string to add to
+// its URLs. When a grant is given, the signature is bound to the grant and
+// expires along with it.
+func SignResource(resource, grant string) string {
+	expires := time.Now().Add(time.Hour * time.Duration(configs.GetenvInt("SIGNED_URL_EXPIRE_HOURS_COUNT", 6)))
+
+	if grant != "" {
+		if grantExpires, err := grantExpiration(grant); err == nil && grantExpires.Before(expires) {
+			expires = grantExpires
+		}
+	}
+
+	query := url.Values{}
+	query.Set("expires", fmt.Sprint(expires.Unix()))
+	query.Set("signature", sign("url", resource, fmt.Sprint(expires.Unix()), grant))
+	if grant != "" {
+		query.Set("grant", grant)
+	}
+
+	return query.Encode()
+}
+
+// VerifyResource checks the signature and expiration that were given for
+// the resource
+func VerifyResource(resource, expires, signature, grant string) error {
+	expiresUnix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || signature == "" {
+		return ErrSignatureInvalid
+	}
+
+	if !hmac.Equal([]byte(signature), []byte(sign("url", resource, expires, grant))) {
+		return ErrSignatureInvalid
+	}
+
+	if time.Now().Unix() > expiresUnix {
+		return ErrSignatureExpired
+	}
+
+	return nil
+}
+
+// GenerateGalleryGrant creates an access grant for a gallery that was
+// unlocked. The grant is tied to the password hash so changing the password
+// revokes every grant.
+func GenerateGalleryGrant(galleryID uint, passwordHash string) (string, time.Time) {
+	expires := time.Now().Add(time.Hour * time.Duration(configs.GetenvInt("GALLERY_GRANT_EXPIRE_HOURS_COUNT", 24)))
+	expiresUnix := fmt.Sprint(expires.Unix())
+
+	signature := sign("grant", fmt.Sprint(galleryID), expiresUnix, passwordHash)
+
+	return strings.Join([]string{fmt.Sprint(galleryID), expiresUnix, signature}, "."), expires
+}
+
+// VerifyGalleryGrant checks the grant was given for the gallery and hasn't
+// expired
+func VerifyGalleryGrant(grant string, galleryID uint, passwordHash string) error {
+	parts := strings.Split(grant, ".")
+	if len(parts) != 3 || parts[0] != fmt.Sprint(galleryID) {
+		return ErrGrantInvalid
+	}
+
+	if !hmac.Equal([]byte(parts[2]), []byte(sign("grant", parts[0], parts[1], passwordHash))) {
+		return ErrGrantInvalid
+	}
+
+	expires, err := grantExpiration(grant)
+	if err != nil || time.Now().After(expires) {
+		return ErrGrantInvalid
+	}
+
+	return nil
+}
+
+// GeneratePreviewToken creates the token of a preview link that is valid
+// until the link expires
+func GeneratePreviewToken(linkID uint, expires time.Time) string {
+	expiresUnix := fmt.Sprint(expires.Unix())
+
+	signature := sign("preview-link", fmt.Sprint(linkID), expiresUnix)
+
+	return strings.Join([]string{fmt.Sprint(linkID), expiresUnix, signature}, ".")
+}
+
+// VerifyPreviewToken checks the token of a preview link hasn't been tampered
+// with or expired and returns the ID of the link
+func VerifyPreviewToken(token string) (uint, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return 0, ErrPreviewInvalid
+	}
+
+	if !hmac.Equal([]byte(parts[2]), []byte(sign("preview-link", parts[0], parts[1]))) {
+		return 0, ErrPreviewInvalid
+	}
+
+	linkID, err := strconv.ParseUint(parts[0], 10, 32)
+	if err != nil {
+		return 0, ErrPreviewInvalid
+	}
+
+	expires, err := grantExpiration(token)
+	if err != nil || time.Now().After(expires) {
+		return 0, ErrPreviewInvalid
+	}
+
+	return uint(linkID), nil
+}
+
+// grantExpiration reads the expiration time from the grant or preview token
+func grantExpiration(grant string) (time.Time, error) {
+	parts := strings.Split(grant, ".")
+	if len(parts) != 3 {
+		return time.Time{}, ErrGrantInvalid
+	}
+
+	expiresUnix, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return time.Time{}, ErrGrantInvalid
+	}
+
+	return time.Unix(expiresUnix, 0), nil
+}
+
+// sign returns the hex encoded HMAC of the values
+func sign(values ...string) string {
+	// Fall back to the JWT secret when no separate signing key is set
+	key := os.Getenv("URL_SIGNING_KEY")
+	if key == "" {
+		key = os.Getenv("JWT_SECRET_KEY")
+	}
+
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(strings.Join(values, "\n")))
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
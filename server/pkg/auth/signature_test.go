@@ -0,0 +1,195 @@
+package auth_test
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/austinbspencer/gshare-server/pkg/auth"
+)
+
+// signedQuery signs the resource and returns the parsed query
+func signedQuery(t *testing.T, resource, grant string) url.Values {
+	t.Helper()
+
+	query, err := url.ParseQuery(auth.SignResource(resource, grant))
+	if err != nil {
+		t.Fatalf("SignResource() returned an invalid query: %v", err)
+	}
+
+	return query
+}
+
+func TestSignResource(t *testing.T) {
+	t.Setenv("JWT_SECRET_KEY", "test-secret")
+	t.Setenv("SIGNED_URL_EXPIRE_HOURS_COUNT", "6")
+
+	query := signedQuery(t, auth.ImageResource(1), "")
+
+	if err := auth.VerifyResource(auth.ImageResource(1), query.Get("expires"), query.Get("signature"), ""); err != nil {
+		t.Errorf("VerifyResource() returned an error for a valid signature: %v", err)
+	}
+
+	var expires int64
+	fmt.Sscan(query.Get("expires"), &expires)
+	if until := time.Until(time.Unix(expires, 0)); until < 5*time.Hour || until > 6*time.Hour {
+		t.Errorf("SignResource() expires in %s, want: 6h", until)
+	}
+
+	if query.Has("grant") {
+		t.Errorf("SignResource() added a grant without one being given")
+	}
+}
+
+func TestVerifyResourceTampered(t *testing.T) {
+	t.Setenv("JWT_SECRET_KEY", "test-secret")
+
+	query := signedQuery(t, auth.ImageResource(1), "")
+	expires, signature := query.Get("expires"), query.Get("signature")
+
+	tests := []struct {
+		name      string
+		resource  string
+		expires   string
+		signature string
+		grant     string
+	}{
+		{"other resource", auth.ImageResource(2), expires, signature, ""},
+		{"preview resource", auth.PreviewResource(auth.ImageResource(1)), expires, signature, ""},
+		{"preview link resource", auth.PreviewLinkResource(1, auth.ImageResource(1)), expires, signature, ""},
+		{"later expiration", auth.ImageResource(1), fmt.Sprint(time.Now().Add(48 * time.Hour).Unix()), signature, ""},
+		{"invalid expiration", auth.ImageResource(1), "soon", signature, ""},
+		{"changed signature", auth.ImageResource(1), expires, strings.Repeat("0", len(signature)), ""},
+		{"missing signature", auth.ImageResource(1), expires, "", ""},
+		{"added grant", auth.ImageResource(1), expires, signature, "1.2.3"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := auth.VerifyResource(tt.resource, tt.expires, tt.signature, tt.grant)
+			if !errors.Is(err, auth.ErrSignatureInvalid) {
+				t.Errorf("VerifyResource() = %v, want: %v", err, auth.ErrSignatureInvalid)
+			}
+		})
+	}
+}
+
+func TestVerifyResourceOtherKey(t *testing.T) {
+	t.Setenv("JWT_SECRET_KEY", "test-secret")
+
+	query := signedQuery(t, auth.ImageResource(1), "")
+
+	t.Setenv("URL_SIGNING_KEY", "other-secret")
+
+	err := auth.VerifyResource(auth.ImageResource(1), query.Get("expires"), query.Get("signature"), "")
+	if !errors.Is(err, auth.ErrSignatureInvalid) {
+		t.Errorf("VerifyResource() = %v, want: %v", err, auth.ErrSignatureInvalid)
+	}
+}
+
+func TestVerifyResourceExpired(t *testing.T) {
+	t.Setenv("JWT_SECRET_KEY", "test-secret")
+	t.Setenv("SIGNED_URL_EXPIRE_HOURS_COUNT", "-1")
+
+	query := signedQuery(t, auth.ImageResource(1), "")
+
+	err := auth.VerifyResource(auth.ImageResource(1), query.Get("expires"), query.Get("signature"), "")
+	if !errors.Is(err, auth.ErrSignatureExpired) {
+		t.Errorf("VerifyResource() = %v, want: %v", err, auth.ErrSignatureExpired)
+	}
+}
+
+func TestSignResourceWithGrant(t *testing.T) {
+	t.Setenv("JWT_SECRET_KEY", "test-secret")
+	t.Setenv("SIGNED_URL_EXPIRE_HOURS_COUNT", "6")
+	t.Setenv("GALLERY_GRANT_EXPIRE_HOURS_COUNT", "1")
+
+	grant, grantExpires := auth.GenerateGalleryGrant(1, "hash")
+	query := signedQuery(t, auth.GalleryResource(1), grant)
+
+	if query.Get("grant") != grant {
+		t.Errorf("SignResource() grant = %q, want: %q", query.Get("grant"), grant)
+	}
+
+	// The URLs expire along with the grant when it expires first
+	if query.Get("expires") != fmt.Sprint(grantExpires.Unix()) {
+		t.Errorf("SignResource() expires = %s, want: %d", query.Get("expires"), grantExpires.Unix())
+	}
+
+	if err := auth.VerifyResource(auth.GalleryResource(1), query.Get("expires"), query.Get("signature"), grant); err != nil {
+		t.Errorf("VerifyResource() returned an error for a valid signature: %v", err)
+	}
+
+	if err := auth.VerifyResource(auth.GalleryResource(1), query.Get("expires"), query.Get("signature"), ""); !errors.Is(err, auth.ErrSignatureInvalid) {
+		t.Errorf("VerifyResource() without the grant = %v, want: %v", err, auth.ErrSignatureInvalid)
+	}
+}
+
+func TestVerifyGalleryGrant(t *testing.T) {
+	t.Setenv("JWT_SECRET_KEY", "test-secret")
+	t.Setenv("GALLERY_GRANT_EXPIRE_HOURS_COUNT", "24")
+
+	grant, _ := auth.GenerateGalleryGrant(1, "hash")
+
+	if err := auth.VerifyGalleryGrant(grant, 1, "hash"); err != nil {
+		t.Errorf("VerifyGalleryGrant() returned an error for a valid grant: %v", err)
+	}
+
+	parts := strings.Split(grant, ".")
+	tests := []struct {
+		name         string
+		grant        string
+		galleryID    uint
+		passwordHash string
+	}{
+		{"other gallery", grant, 2, "hash"},
+		{"changed password", grant, 1, "other-hash"},
+		{"later expiration", strings.Join([]string{parts[0], fmt.Sprint(time.Now().Add(48 * time.Hour).Unix()), parts[2]}, "."), 1, "hash"},
+		{"malformed", "not-a-grant", 1, "hash"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := auth.VerifyGalleryGrant(tt.grant, tt.galleryID, tt.passwordHash)
+			if !errors.Is(err, auth.ErrGrantInvalid) {
+				t.Errorf("VerifyGalleryGrant() = %v, want: %v", err, auth.ErrGrantInvalid)
+			}
+		})
+	}
+}
+
+func TestVerifyGalleryGrantExpired(t *testing.T) {
+	t.Setenv("JWT_SECRET_KEY", "test-secret")
+	t.Setenv("GALLERY_GRANT_EXPIRE_HOURS_COUNT", "-1")
+
+	grant, _ := auth.GenerateGalleryGrant(1, "hash")
+
+	if err := auth.VerifyGalleryGrant(grant, 1, "hash"); !errors.Is(err, auth.ErrGrantInvalid) {
+		t.Errorf("VerifyGalleryGrant() = %v, want: %v", err, auth.ErrGrantInvalid)
+	}
+}
+
+func TestVerifyPreviewToken(t *testing.T) {
+	t.Setenv("JWT_SECRET_KEY", "test-secret")
+
+	token := auth.GeneratePreviewToken(7, time.Now().Add(time.Hour))
+
+	linkID, err := auth.VerifyPreviewToken(token)
+	if err != nil || linkID != 7 {
+		t.Errorf("VerifyPreviewToken() = %d, %v, want: 7, nil", linkID, err)
+	}
+
+	parts := strings.Split(token, ".")
+	tampered := strings.Join([]string{"8", parts[1], parts[2]}, ".")
+	if _, err := auth.VerifyPreviewToken(tampered); !errors.Is(err, auth.ErrPreviewInvalid) {
+		t.Errorf("VerifyPreviewToken() of a tampered token = %v, want: %v", err, auth.ErrPreviewInvalid)
+	}
+
+	expired := auth.GeneratePreviewToken(7, time.Now().Add(-time.Minute))
+	if _, err := auth.VerifyPreviewToken(expired); !errors.Is(err, auth.ErrPreviewInvalid) {
+		t.Errorf("VerifyPreviewToken() of an expired token = %v, want: %v", err, auth.ErrPreviewInvalid)
+	}
+}
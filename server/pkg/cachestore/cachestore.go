@@ -0,0 +1,120 @@
+package cachestore
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/austinbspencer/gshare-server/pkg/configs"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/log"
+	"github.com/gofiber/storage/memory/v2"
+	"github.com/gofiber/storage/redis/v3"
+)
+
+// keyLister is implemented by the memory and redis storages
+type keyLister interface {
+	Keys() ([][]byte, error)
+}
+
+var (
+	store     fiber.Storage
+	storeOnce sync.Once
+)
+
+// Get returns the storage used for the API response cache
+func Get() fiber.Storage {
+	storeOnce.Do(func() {
+		if strings.ToLower(configs.Getenv("CACHE_DRIVER", "memory")) == "redis" {
+			log.Info("Using Redis for cache storage")
+			store = redis.New(redis.Config{
+				Host:     configs.Getenv("REDIS_HOST", "localhost"),
+				Port:     configs.GetenvInt("REDIS_PORT", 6379),
+				Username: configs.Getenv("REDIS_USER", ""),
+				Password: configs.Getenv("REDIS_PASSWORD", ""),
+				Database: 0,
+			})
+		} else {
+			log.Info("Using Memory for cache storage")
+			// Default is in memory
+			store = memory.New()
+		}
+	})
+
+	return store
+}
+
+// InvalidatePaths removes the cached responses for the given paths along
+// with every cached response nested below them
+func InvalidatePaths(paths ...string) {
+	lister, ok := Get().(keyLister)
+	if !ok {
+		log.Warn("Cache storage is unable to list keys, resetting the entire cache")
+		if err := Get().Reset(); err != nil {
+			log.Errorf("Unable to reset the cache: %v\n", err)
+		}
+		return
+	}
+
+	keys, err := lister.Keys()
+	if err != nil {
+		log.Errorf("Unable to list the cache keys: %v\n", err)
+		return
+	}
+
+	for _, rawKey := range keys {
+		key := string(rawKey)
+		for _, path := range paths {
+			// Cache keys are the path followed by "_{method}"
+			if strings.HasPrefix(key, path+"_") || strings.HasPrefix(key, path+"/") {
+				if err := Get().Delete(key); err != nil {
+					log.Errorf("Unable to delete cache key %s: %v\n", key, err)
+				}
+				break
+			}
+		}
+	}
+}
+
+// InvalidateImages removes the cached resizes and downloads of the images
+// with the given public IDs,
+// along with every cached selection download since those can include them
+func InvalidateImages(publicIDs ...string) {
+	if len(publicIDs) == 0 {
+		return
+	}
+
+	var paths []string
+	for _, size := range []string{"web", "original"} {
+		paths = append(paths, fmt.Sprintf("/api/v1/download/%s/images", size))
+	}
+	for _, id := range publicIDs {
+		paths = append(paths, fmt.Sprintf("/api/v1/images/%s", id))
+		for _, size := range []string{"web", "original"} {
+			paths = append(paths, fmt.Sprintf("/api/v1/download/%s/image/%s", size, id))
+		}
+	}
+
+	InvalidatePaths(paths...)
+}
+
+// InvalidateDerivatives removes every cached resize and download, for when
+// the derivatives were rebuilt without knowing which images changed
+func InvalidateDerivatives() {
+	InvalidatePaths("/api/v1/images", "/api/v1/download")
+}
+
+// InvalidateDownloads removes every cached download, for when downloads
+// stop being cacheable
+func InvalidateDownloads() {
+	InvalidatePaths("/api/v1/download")
+}
+
+// InvalidateGallery removes the cached zip downloads of the gallery with
+// the given public ID
+func InvalidateGallery(publicID string) {
+	InvalidatePaths(
+		fmt.Sprintf("/api/v1/download/web/gallery/%s", publicID),
+		fmt.Sprintf("/api/v1/download/original/gallery/%s", publicID),
+	)
+}
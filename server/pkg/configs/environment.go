@@ -8,7 +8,10 @@ import (
 	"github.com/gofiber/fiber/v2/log"
 )
 
-func init() {
+// CheckRequiredEnv stops the server when a required environment variable
+// isn't set. It's called when the server starts rather than on import, so
+// the .env file is loaded first and packages can be tested without it.
+func CheckRequiredEnv() {
 	requiredVars := []string{"JWT_SECRET_KEY"}
 
 	for _, envVar := range requiredVars {
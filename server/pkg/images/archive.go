@@ -0,0 +1,85 @@
+package images
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/gofiber/fiber/v2/log"
+)
+
+// ArchiveGallery compresses the originals of the gallery into a single
+// archive, then removes the originals, web sized copies and zips. Prior
+// originals of replaced images are kept.
+func ArchiveGallery(galleryID uint, entries []ZipEntry) error {
+	archivePath := GetArchivePath(galleryID)
+
+	// Write to a temporary file so a failed archive never replaces the
+	// originals
+	tmpPath := archivePath + ".tmp"
+	archiveFile, err := os.Create(tmpPath)
+	if err != nil {
+		log.Errorf("Unable to create archive file: %v\n", err)
+		return err
+	}
+
+	if err := writeZip(archiveFile, galleryID, "original", entries); err != nil {
+		archiveFile.Close()
+		os.Remove(tmpPath)
+		log.Errorf("Unable to write archive for gallery %d: %v\n", galleryID, err)
+		return err
+	}
+
+	if err := archiveFile.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	if err := os.Rename(tmpPath, archivePath); err != nil {
+		os.Remove(tmpPath)
+		log.Errorf("Unable to move archive for gallery %d: %v\n", galleryID, err)
+		return err
+	}
+
+	for _, dir := range []string{
+		filepath.Join(GetGalleryPath(galleryID), "original"),
+		filepath.Join(GetGalleryPath(galleryID), "web"),
+		GetZipsPath(galleryID),
+	} {
+		if err := os.RemoveAll(dir); err != nil {
+			log.Errorf("Unable to remove %s after archiving gallery %d: %v\n", filepath.Base(dir), galleryID, err)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GalleryDiskUsage returns the size in bytes of the files in the gallery
+// directory
+func GalleryDiskUsage(galleryID uint) (int64, error) {
+	var size int64
+
+	err := filepath.WalkDir(GetGalleryPath(galleryID), func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+
+		if d.IsDir() {
+			return nil
+		}
+
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		size += info.Size()
+
+		return nil
+	})
+
+	return size, err
+}
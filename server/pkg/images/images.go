@@ -76,6 +76,11 @@ func GetImagePath(galleryID uint, size, filename string) string {
 	return filepath.Join(BaseImagesDir, fmt.Sprint(galleryID), size, filename)
 }
 
+// GetVersionPath returns the path to a replaced original of an image
+func GetVersionPath(galleryID uint, filename string) string {
+	return filepath.Join(BaseImagesDir, fmt.Sprint(galleryID), "versions", filename)
+}
+
 func GetZipsPath(galleryID uint) string {
 	return filepath.Join(BaseImagesDir, fmt.Sprint(galleryID), "zips")
 }
@@ -84,3 +89,8 @@ func GetZipPath(galleryID uint, size string) string {
 	return filepath.Join(BaseImagesDir, fmt.Sprint(galleryID), "zips",
 		fmt.Sprintf("gallery_%s.zip", size))
 }
+
+// GetArchivePath returns the path to the archive of the gallery originals
+func GetArchivePath(galleryID uint) string {
+	return filepath.Join(BaseImagesDir, fmt.Sprint(galleryID), "archive.zip")
+}
@@ -0,0 +1,103 @@
+package images
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"time"
+
+	"github.com/austinbspencer/gshare-server/pkg/configs"
+	"github.com/gofiber/fiber/v2/log"
+	"golang.org/x/sync/semaphore"
+)
+
+var (
+	// ErrProcessorBusy is returned when no worker or pixel budget became
+	// available before the queue timeout
+	ErrProcessorBusy = errors.New("image processor is busy, try again later")
+	// ErrImageTooLarge is returned when a single image exceeds the maximum
+	// number of pixels allowed to be decoded
+	ErrImageTooLarge = errors.New("image exceeds the maximum allowed dimensions")
+)
+
+var (
+	ProcessingWorkers      int = runtime.NumCPU()
+	ProcessingBudgetMP     int = 240
+	MaxImageMP             int = 120
+	ProcessingQueueTimeout int = 30
+
+	// Processor is the shared image processor used by uploads and resizes
+	Processor *ImageProcessor
+)
+
+func init() {
+	ProcessingWorkers = configs.GetenvInt("IMAGES_PROCESSING_WORKERS", ProcessingWorkers)
+	ProcessingBudgetMP = configs.GetenvInt("IMAGES_PROCESSING_BUDGET_MP", ProcessingBudgetMP)
+	MaxImageMP = configs.GetenvInt("IMAGES_MAX_MP", MaxImageMP)
+	ProcessingQueueTimeout = configs.GetenvInt("IMAGES_PROCESSING_QUEUE_TIMEOUT", ProcessingQueueTimeout)
+
+	Processor = NewImageProcessor(ProcessingWorkers, int64(ProcessingBudgetMP)*1_000_000,
+		int64(MaxImageMP)*1_000_000, time.Duration(ProcessingQueueTimeout)*time.Second)
+}
+
+// ImageProcessor limits how many images are decoded and resized at once and
+// how many decoded pixels may be held in memory across all of them
+type ImageProcessor struct {
+	workers      *semaphore.Weighted
+	budget       *semaphore.Weighted
+	maxPixels    int64
+	queueTimeout time.Duration
+}
+
+// NewImageProcessor creates a processor with the given worker count, total
+// pixel budget, per-image pixel limit and queue timeout
+func NewImageProcessor(workers int, budgetPixels, maxPixels int64, queueTimeout time.Duration) *ImageProcessor {
+	if workers < 1 {
+		log.Warnf("Invalid image processing worker count (%d). Defaulting to 1.\n", workers)
+		workers = 1
+	}
+
+	// A single image must always be able to fit inside of the budget,
+	// otherwise it would wait in the queue forever
+	if maxPixels > budgetPixels {
+		log.Warnf("Maximum image size (%d px) is larger than the processing budget (%d px). Raising the budget.\n",
+			maxPixels, budgetPixels)
+		budgetPixels = maxPixels
+	}
+
+	return &ImageProcessor{
+		workers:      semaphore.NewWeighted(int64(workers)),
+		budget:       semaphore.NewWeighted(budgetPixels),
+		maxPixels:    maxPixels,
+		queueTimeout: queueTimeout,
+	}
+}
+
+// Acquire waits for a worker and the given number of pixels from the budget.
+// The returned release func must be called once the decoded images are no
+// longer held in memory.
+func (p *ImageProcessor) Acquire(pixels int64) (func(), error) {
+	if pixels <= 0 || pixels > p.maxPixels {
+		log.Warnf("Rejecting image processing of %d px (max %d px)\n", pixels, p.maxPixels)
+		return nil, ErrImageTooLarge
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), p.queueTimeout)
+	defer cancel()
+
+	if err := p.workers.Acquire(ctx, 1); err != nil {
+		log.Warn("Timed out waiting for an image processing worker")
+		return nil, ErrProcessorBusy
+	}
+
+	if err := p.budget.Acquire(ctx, pixels); err != nil {
+		p.workers.Release(1)
+		log.Warnf("Timed out waiting for %d px of image processing budget\n", pixels)
+		return nil, ErrProcessorBusy
+	}
+
+	return func() {
+		p.budget.Release(pixels)
+		p.workers.Release(1)
+	}, nil
+}
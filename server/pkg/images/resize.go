@@ -0,0 +1,39 @@
+package images
+
+import (
+	"bytes"
+	"image"
+
+	"github.com/austinbspencer/gshare-server/pkg/utils"
+)
+
+// ResizeImage resizes the image bytes to the given width and quality once
+// the processor has room for it. Quality only applies to jpeg images.
+func ResizeImage(input []byte, width, quality uint, contentType string) ([]byte, error) {
+	config, _, err := image.DecodeConfig(bytes.NewReader(input))
+	if err != nil {
+		return nil, err
+	}
+
+	// Hold the budget for both the decoded source and the resized output
+	release, err := Processor.Acquire(pixelCount(config, width))
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	return utils.ResizeImage(input, width, quality, contentType)
+}
+
+// pixelCount returns the number of pixels held in memory while resizing an
+// image with the given config to the given width
+func pixelCount(config image.Config, width uint) int64 {
+	source := int64(config.Width) * int64(config.Height)
+	if config.Width == 0 {
+		return source
+	}
+
+	height := int64(float64(width) / float64(config.Width) * float64(config.Height))
+
+	return source + int64(width)*height
+}
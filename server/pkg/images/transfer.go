@@ -0,0 +1,108 @@
+package images
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/gofiber/fiber/v2/log"
+)
+
+// MoveImage moves the original, web sized copy and prior originals of an
+// image to another gallery. Files that were already moved are put back
+// when a later move fails.
+func MoveImage(fromGalleryID, toGalleryID uint, filename string, versions []string) error {
+	type move struct{ from, to string }
+
+	moves := []move{
+		{GetImagePath(fromGalleryID, "original", filename), GetImagePath(toGalleryID, "original", filename)},
+		{GetImagePath(fromGalleryID, "web", filename), GetImagePath(toGalleryID, "web", filename)},
+	}
+	for _, version := range versions {
+		moves = append(moves, move{GetVersionPath(fromGalleryID, version), GetVersionPath(toGalleryID, version)})
+	}
+
+	for idx, m := range moves {
+		err := os.MkdirAll(filepath.Dir(m.to), 0755)
+		if err == nil {
+			err = os.Rename(m.from, m.to)
+		}
+		if err != nil {
+			log.Errorf("Unable to move %s to gallery %d: %v\n", filepath.Base(m.from), toGalleryID, err)
+			for _, done := range moves[:idx] {
+				if err := os.Rename(done.to, done.from); err != nil {
+					log.Errorf("Unable to move %s back to gallery %d: %v\n", filepath.Base(done.to), fromGalleryID, err)
+				}
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+// CopyImage copies the original and web sized copy of an image to another
+// gallery under the new filename
+func CopyImage(fromGalleryID, toGalleryID uint, filename, newFilename string) error {
+	if err := CreateGalleryDirectory(toGalleryID); err != nil {
+		return err
+	}
+
+	for _, size := range []string{"original", "web"} {
+		if err := copyFile(GetImagePath(fromGalleryID, size, filename), GetImagePath(toGalleryID, size, newFilename)); err != nil {
+			log.Errorf("Unable to copy %s image to gallery %d: %v\n", size, toGalleryID, err)
+			os.Remove(GetImagePath(toGalleryID, "original", newFilename))
+			return err
+		}
+	}
+
+	return nil
+}
+
+// LinkImage shares the original and web sized copy of an image with another
+// gallery under the new filename. The files are hard linked so they aren't
+// stored twice, and copied when they can't be linked, such as when the
+// images are on different filesystems.
+func LinkImage(fromGalleryID, toGalleryID uint, filename, newFilename string) error {
+	if err := CreateGalleryDirectory(toGalleryID); err != nil {
+		return err
+	}
+
+	for _, size := range []string{"original", "web"} {
+		src := GetImagePath(fromGalleryID, size, filename)
+		dst := GetImagePath(toGalleryID, size, newFilename)
+
+		if err := os.Link(src, dst); err != nil {
+			log.Debugf("Unable to link %s image to gallery %d, copying instead: %v\n", size, toGalleryID, err)
+			if err := copyFile(src, dst); err != nil {
+				log.Errorf("Unable to copy %s image to gallery %d: %v\n", size, toGalleryID, err)
+				os.Remove(GetImagePath(toGalleryID, "original", newFilename))
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// copyFile copies the file at src to dst
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+
+	return out.Close()
+}
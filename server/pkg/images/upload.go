@@ -10,14 +10,31 @@ import (
 	"mime/multipart"
 	"os"
 
+	"github.com/austinbspencer/gshare-server/pkg/utils"
 	"github.com/gofiber/fiber/v2/log"
-	"github.com/nfnt/resize"
 )
 
 // UploadGalleryImage uploads an image to the gallery directory
 func UploadGalleryImage(galleryID uint, contentType, imageFilename string, file multipart.File) error {
+	config, _, err := image.DecodeConfig(file)
+	if err != nil {
+		log.Errorf("Error with image.DecodeConfig: %v\n", err)
+		return err
+	}
+
+	// Wait for room in the processor before writing or decoding the image
+	// so a rejected upload doesn't leave a file behind
+	release, err := Processor.Acquire(pixelCount(config, uint(WebSizeWidth)))
+	if err != nil {
+		log.Errorf("Unable to process image for gallery %d: %v\n", galleryID, err)
+		return err
+	}
+	defer release()
+
+	file.Seek(0, 0) // Reset file position to the beginning
+
 	// Create the directory for the gallery if it doesn't exist
-	err := CreateGalleryDirectory(galleryID)
+	err = CreateGalleryDirectory(galleryID)
 	if err != nil {
 		log.Errorf("Unable to create gallery directory: %v\n", err)
 		return err
@@ -62,10 +79,7 @@ func UploadGalleryImage(galleryID uint, contentType, imageFilename string, file
 }
 
 func uploadWebSizedImage(galleryID uint, imageFilename, contentType string, img image.Image) error {
-	aspectRatio := float64(img.Bounds().Dx()) / float64(img.Bounds().Dy())
-	height := uint(float64(WebSizeWidth) / aspectRatio)
-
-	resizedImg := resize.Resize(uint(WebSizeWidth), height, img, resize.Lanczos3)
+	resizedImg := utils.Resample(img, uint(WebSizeWidth))
 
 	// Convert the resized image to a buffer
 	var resizedBuffer bytes.Buffer
@@ -90,6 +104,13 @@ func uploadWebSizedImage(galleryID uint, imageFilename, contentType string, img
 	// Create the full path for the image
 	imageLocation := GetImagePath(galleryID, "web", imageFilename)
 
+	// Remove the existing copy rather than writing over it, since it may be
+	// hard linked to the image of a cloned gallery
+	if err := os.Remove(imageLocation); err != nil && !os.IsNotExist(err) {
+		log.Errorf("Unable to remove web sized image: %v\n", err)
+		return err
+	}
+
 	// Create the file for the uploaded image
 	outFile, err := os.Create(imageLocation)
 	if err != nil {
@@ -108,3 +129,37 @@ func uploadWebSizedImage(galleryID uint, imageFilename, contentType string, img
 
 	return nil
 }
+
+// RegenerateWebImage rebuilds the web sized copy of an image from its original
+func RegenerateWebImage(galleryID uint, imageFilename string) error {
+	file, err := os.Open(GetImagePath(galleryID, "original", imageFilename))
+	if err != nil {
+		log.Errorf("Unable to open original image: %v\n", err)
+		return err
+	}
+	defer file.Close()
+
+	config, _, err := image.DecodeConfig(file)
+	if err != nil {
+		log.Errorf("Error with image.DecodeConfig: %v\n", err)
+		return err
+	}
+
+	// Wait for room in the processor before decoding the full image
+	release, err := Processor.Acquire(pixelCount(config, uint(WebSizeWidth)))
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	file.Seek(0, 0) // Reset file position to the beginning
+
+	img, _, err := image.Decode(file)
+	if err != nil {
+		log.Errorf("Error with image.Decode: %v\n", err)
+		return err
+	}
+
+	return uploadWebSizedImage(galleryID, imageFilename,
+		utils.GetMimeTypeFromExtension(imageFilename), img)
+}
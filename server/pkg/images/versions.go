@@ -0,0 +1,55 @@
+package images
+
+import (
+	"os"
+	"path/filepath"
+
+	"github.com/gofiber/fiber/v2/log"
+)
+
+// ArchiveImageVersion moves the original of an image into the gallery
+// versions directory and removes its web sized copy
+func ArchiveImageVersion(galleryID uint, filename string) error {
+	versionPath := GetVersionPath(galleryID, filename)
+	if err := os.MkdirAll(filepath.Dir(versionPath), 0755); err != nil {
+		log.Errorf("Unable to create gallery versions directory: %v\n", err)
+		return err
+	}
+
+	if err := os.Rename(GetImagePath(galleryID, "original", filename), versionPath); err != nil {
+		log.Errorf("Unable to move original into versions: %v\n", err)
+		return err
+	}
+
+	if err := os.Remove(GetImagePath(galleryID, "web", filename)); err != nil && !os.IsNotExist(err) {
+		log.Errorf("Unable to remove web sized image: %v\n", err)
+		return err
+	}
+
+	return nil
+}
+
+// RestoreImageVersion moves a prior original back into the gallery and
+// regenerates its web sized copy
+func RestoreImageVersion(galleryID uint, filename string) error {
+	if err := CreateGalleryDirectory(galleryID); err != nil {
+		return err
+	}
+
+	if err := os.Rename(GetVersionPath(galleryID, filename), GetImagePath(galleryID, "original", filename)); err != nil {
+		log.Errorf("Unable to move version back into originals: %v\n", err)
+		return err
+	}
+
+	return RegenerateWebImage(galleryID, filename)
+}
+
+// RemoveImageVersion removes a prior original from the versions directory
+func RemoveImageVersion(galleryID uint, filename string) error {
+	if err := os.Remove(GetVersionPath(galleryID, filename)); err != nil && !os.IsNotExist(err) {
+		log.Errorf("Unable to remove image version: %v\n", err)
+		return err
+	}
+
+	return nil
+}
@@ -6,12 +6,22 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"path"
 	"path/filepath"
+	"strings"
 
 	"github.com/gofiber/fiber/v2/log"
 )
 
-func GenerateGalleryZips(galleryID uint) error {
+// ZipEntry is an image to add to a zip
+type ZipEntry struct {
+	// Filename of the image in the gallery directory
+	Filename string
+	// Name of the file within the zip, which may include a folder
+	Name string
+}
+
+func GenerateGalleryZips(galleryID uint, entries []ZipEntry) error {
 	// Create the directory for the gallery zips if it doesn't exist
 	err := CreateGalleryZipsDirectory(galleryID)
 	if err != nil {
@@ -20,14 +30,14 @@ func GenerateGalleryZips(galleryID uint) error {
 	}
 
 	// Generate the zip file for the gallery original images
-	err = GenerateGalleryZip(galleryID, "original")
+	err = GenerateGalleryZip(galleryID, "original", entries)
 	if err != nil {
 		log.Errorf("Unable to generate gallery zip for originals: %v\n", err)
 		return err
 	}
 
 	// Generate the zip file for the gallery web images
-	err = GenerateGalleryZip(galleryID, "web")
+	err = GenerateGalleryZip(galleryID, "web", entries)
 	if err != nil {
 		log.Errorf("Unable to generate gallery zip for web sizes: %v\n", err)
 		return err
@@ -37,7 +47,7 @@ func GenerateGalleryZips(galleryID uint) error {
 }
 
 // Generate the zip file for the gallery
-func GenerateGalleryZip(galleryID uint, size string) error {
+func GenerateGalleryZip(galleryID uint, size string, entries []ZipEntry) error {
 	// Create a new zip file
 	zipFile, err := os.Create(GetZipPath(galleryID, size))
 	if err != nil {
@@ -46,83 +56,76 @@ func GenerateGalleryZip(galleryID uint, size string) error {
 	}
 	defer zipFile.Close()
 
-	// Create a new zip writer
-	zipWriter := zip.NewWriter(zipFile)
-	defer zipWriter.Close()
-
-	// Walk through the directory
-	err = filepath.Walk(filepath.Join(BaseImagesDir, fmt.Sprint(galleryID)), func(path string, info os.FileInfo, err error) error {
-		// Skip directories and non-image files
-		if info.IsDir() || !isImage(path) {
-			return nil
-		}
-
-		// Open the file
-		file, err := os.Open(path)
-		if err != nil {
-			return err
-		}
-		defer file.Close()
-
-		// Create a new file in the zip archive
-		zipFile, err := zipWriter.Create(info.Name())
-		if err != nil {
-			return err
-		}
-
-		// Copy file contents to the zip file
-		_, err = io.Copy(zipFile, file)
-		if err != nil {
-			return err
-		}
-
-		return nil
-	})
-
-	return err
+	return writeZip(zipFile, galleryID, size, entries)
 }
 
-func GenerateZipOnDemand(galleryID uint, size string, images []string) ([]byte, error) {
+func GenerateZipOnDemand(galleryID uint, size string, entries []ZipEntry) ([]byte, error) {
 	// Create a new buffer
 	buf := new(bytes.Buffer)
 
+	if err := writeZip(buf, galleryID, size, entries); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// writeZip writes the images of the given size as a zip
+func writeZip(w io.Writer, galleryID uint, size string, entries []ZipEntry) error {
 	// Create a new zip writer
-	zipWriter := zip.NewWriter(buf)
+	zipWriter := zip.NewWriter(w)
+
+	names := make(map[string]bool, len(entries))
 
-	for _, imageName := range images {
-		imagePath := GetImagePath(galleryID, size, imageName)
+	for _, entry := range entries {
+		imagePath := GetImagePath(galleryID, size, entry.Filename)
 
 		// Skip non-image files
 		if !isImage(imagePath) {
 			continue
 		}
 
-		// Open the file
-		file, err := os.Open(imagePath)
-		if err != nil {
-			return nil, err
+		if err := addZipFile(zipWriter, imagePath, uniqueZipName(entry.Name, names)); err != nil {
+			return err
 		}
-		defer file.Close()
+	}
 
-		// Create a new file in the zip archive
-		zipFile, err := zipWriter.Create(imageName)
-		if err != nil {
-			return nil, err
-		}
+	// Close the zip writer
+	return zipWriter.Close()
+}
 
-		// Copy file contents to the zip file
-		_, err = io.Copy(zipFile, file)
-		if err != nil {
-			return nil, err
-		}
+// uniqueZipName numbers the name when it was already used in the zip, as
+// in "_DSC1234 (1).jpg". Names are compared ignoring case.
+func uniqueZipName(name string, names map[string]bool) string {
+	unique := name
+	ext := path.Ext(name)
+	for n := 1; names[strings.ToLower(unique)]; n++ {
+		unique = fmt.Sprintf("%s (%d)%s", strings.TrimSuffix(name, ext), n, ext)
 	}
+	names[strings.ToLower(unique)] = true
 
-	// Close the zip writer
-	if err := zipWriter.Close(); err != nil {
-		return nil, err
+	return unique
+}
+
+// addZipFile copies the file into the zip under the given name
+func addZipFile(zipWriter *zip.Writer, path, name string) error {
+	// Open the file
+	file, err := os.Open(path)
+	if err != nil {
+		return err
 	}
+	defer file.Close()
 
-	return buf.Bytes(), nil
+	// Create a new file in the zip archive
+	zipFile, err := zipWriter.Create(name)
+	if err != nil {
+		return err
+	}
+
+	// Copy file contents to the zip file
+	_, err = io.Copy(zipFile, file)
+
+	return err
 }
 
 // Check if the file is an image
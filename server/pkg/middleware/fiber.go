@@ -2,10 +2,11 @@ package middleware
 
 import (
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/austinbspencer/gshare-server/internal/controllers"
+	"github.com/austinbspencer/gshare-server/internal/models"
+	"github.com/austinbspencer/gshare-server/pkg/cachestore"
 	"github.com/austinbspencer/gshare-server/pkg/configs"
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/log"
@@ -17,31 +18,8 @@ import (
 	"github.com/gofiber/fiber/v2/middleware/logger"
 	"github.com/gofiber/fiber/v2/middleware/recover"
 	"github.com/gofiber/fiber/v2/utils"
-	"github.com/gofiber/storage/memory/v2"
-	"github.com/gofiber/storage/redis/v3"
 )
 
-// Get the cache storage option
-func getStorage() fiber.Storage {
-	var store fiber.Storage
-	if strings.ToLower(configs.Getenv("CACHE_DRIVER", "memory")) == "redis" {
-		log.Info("Using Redis for cache storage")
-		store = redis.New(redis.Config{
-			Host:     configs.Getenv("REDIS_HOST", "localhost"),
-			Port:     configs.GetenvInt("REDIS_PORT", 6379),
-			Username: configs.Getenv("REDIS_USER", ""),
-			Password: configs.Getenv("REDIS_PASSWORD", ""),
-			Database: 0,
-		})
-	} else {
-		log.Info("Using Memory for cache storage")
-		// Default is in memory
-		store = memory.New()
-	}
-
-	return store
-}
-
 // Get the limiter expiration time
 func getLimiterExpiration() time.Duration {
 	expiry := configs.GetenvInt("LIMITER_EXPIRATION", 5)
@@ -59,7 +37,7 @@ func FiberMiddleware(a *fiber.App) {
 		cors.New(cors.Config{
 			AllowOrigins:     configs.Getenv("ALLOWED_ORIGINS", configs.Getenv("NEXT_PUBLIC_CLIENT_URL", "http://localhost:3000")),
 			AllowMethods:     "GET, POST, OPTIONS, PUT, DELETE",
-			AllowHeaders:     "Origin, Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, Cache-Time",
+			AllowHeaders:     "Origin, Accept, Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, Cache-Time, " + models.DownloadPinHeader + ", " + models.DownloadEmailHeader + ", " + models.PreviewTokenHeader,
 			ExposeHeaders:    "Origin",
 			AllowCredentials: true,
 		}),
@@ -92,7 +70,7 @@ func FiberMiddleware(a *fiber.App) {
 			KeyGenerator: func(c *fiber.Ctx) string {
 				return utils.CopyString(c.Path())
 			},
-			Storage: getStorage(),
+			Storage: cachestore.Get(),
 		}),
 		// Add simple Favicon
 		favicon.New(favicon.Config{
@@ -12,11 +12,13 @@ import (
 	"math/big"
 	"mime/multipart"
 	"net/http"
+	"net/url"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2/log"
-	"github.com/nfnt/resize"
 	"golang.org/x/crypto/bcrypt"
 )
 
@@ -51,6 +53,80 @@ func GenerateRandomState(length *int) string {
 	return base64.URLEncoding.EncodeToString(b)
 }
 
+// NormalizeTags trims and lowercases the tags, dropping empty and
+// duplicate tags while keeping their order
+func NormalizeTags(tags []string) []string {
+	seen := make(map[string]struct{}, len(tags))
+	normalized := []string{}
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if _, found := seen[tag]; found || tag == "" {
+			continue
+		}
+		seen[tag] = struct{}{}
+		normalized = append(normalized, tag)
+	}
+	return normalized
+}
+
+// SanitizeFilename returns the base name of an uploaded file with path
+// separators and control characters removed
+func SanitizeFilename(name string) string {
+	// Browsers may send the full client path
+	if idx := strings.LastIndexAny(name, `/\`); idx >= 0 {
+		name = name[idx+1:]
+	}
+
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || r == '"' {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.TrimSpace(name)
+
+	if name == "." || name == ".." {
+		return ""
+	}
+
+	// Keep the extension when trimming long names
+	if len(name) > 255 {
+		ext := filepath.Ext(name)
+		if len(ext) > 16 {
+			ext = ""
+		}
+		name = strings.ToValidUTF8(name[:255-len(ext)], "") + ext
+	}
+
+	return name
+}
+
+// ContentDisposition returns the Content-Disposition header value for the
+// filename, using the extended parameter for non-ASCII names
+func ContentDisposition(disposition, filename string) string {
+	ascii := true
+	for _, r := range filename {
+		if r > 0x7e || r < 0x20 {
+			ascii = false
+			break
+		}
+	}
+
+	quoted := strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(filename)
+	if ascii {
+		return fmt.Sprintf(`%s; filename="%s"`, disposition, quoted)
+	}
+
+	fallback := strings.Map(func(r rune) rune {
+		if r > 0x7e || r < 0x20 {
+			return '_'
+		}
+		return r
+	}, quoted)
+
+	return fmt.Sprintf(`%s; filename="%s"; filename*=UTF-8''%s`, disposition, fallback, url.PathEscape(filename))
+}
+
 // Contains returns whether or not the string exists in the slice
 func Contains(s []string, e string) bool {
 	for _, a := range s {
@@ -121,12 +197,7 @@ func ResizeImage(input []byte, width, quality uint, contentType string) ([]byte,
 		return nil, err
 	}
 
-	// Calculate the corresponding height to maintain the aspect ratio
-	originalWidth := uint(img.Bounds().Dx())
-	originalHeight := uint(img.Bounds().Dy())
-	newHeight := uint(float64(width) / float64(originalWidth) * float64(originalHeight))
-
-	resizedImg := resize.Resize(width, newHeight, img, resize.Lanczos3)
+	resizedImg := Resample(img, width)
 
 	var resizedBuffer bytes.Buffer
 	switch contentType {
@@ -158,6 +229,18 @@ func ParseSize(sizeStr string) (int, error) {
 	return value * 1024 * 1024, nil // Convert to bytes (1 MB = 1024 * 1024 bytes)
 }
 
+// RetryDelay returns how long to wait before the next attempt after the
+// given number of attempts failed, doubling the base delay for every
+// attempt up to the max delay
+func RetryDelay(base, maxDelay time.Duration, attempts int) time.Duration {
+	delay := base
+	for i := 1; i < attempts && delay < maxDelay; i++ {
+		delay *= 2
+	}
+
+	return min(delay, maxDelay)
+}
+
 // Generate2FACode generates a random 6-digit number for 2FA
 func Generate2FACode() (string, error) {
 	// Generate a random 6-digit number
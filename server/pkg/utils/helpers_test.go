@@ -2,6 +2,7 @@ package utils_test
 
 import (
 	"testing"
+	"time"
 
 	"github.com/austinbspencer/gshare-server/pkg/utils"
 )
@@ -33,6 +34,54 @@ func TestGenerateRandomState(t *testing.T) {
 	}
 }
 
+func TestNormalizeTags(t *testing.T) {
+	tags := []string{" Wedding", "portrait", "", "wedding ", "  ", "Ceremony"}
+	expected := []string{"wedding", "portrait", "ceremony"}
+
+	result := utils.NormalizeTags(tags)
+
+	if len(result) != len(expected) {
+		t.Fatalf("NormalizeTags() returned wrong result length, got: %d, want: %d", len(result), len(expected))
+	}
+
+	for i, v := range result {
+		if v != expected[i] {
+			t.Errorf("NormalizeTags() mismatch at index %d, got: %s, want: %s", i, v, expected[i])
+		}
+	}
+}
+
+func TestSanitizeFilename(t *testing.T) {
+	tests := map[string]string{
+		"_DSC1234.jpg":             "_DSC1234.jpg",
+		`C:\Users\me\_DSC1234.jpg`: "_DSC1234.jpg",
+		"photos/../_DSC1234.jpg":   "_DSC1234.jpg",
+		" my \"best\" shot.png ":   "my best shot.png",
+		"..":                       "",
+		"line\nbreak.jpg":          "linebreak.jpg",
+	}
+
+	for input, expected := range tests {
+		if result := utils.SanitizeFilename(input); result != expected {
+			t.Errorf("SanitizeFilename(%q) = %q, want: %q", input, result, expected)
+		}
+	}
+}
+
+func TestContentDisposition(t *testing.T) {
+	tests := map[string]string{
+		"_DSC1234.jpg": `attachment; filename="_DSC1234.jpg"`,
+		"my shot.jpg":  `attachment; filename="my shot.jpg"`,
+		"café.jpg":     `attachment; filename="caf_.jpg"; filename*=UTF-8''caf%C3%A9.jpg`,
+	}
+
+	for input, expected := range tests {
+		if result := utils.ContentDisposition("attachment", input); result != expected {
+			t.Errorf("ContentDisposition(%q) = %q, want: %q", input, result, expected)
+		}
+	}
+}
+
 func TestContains(t *testing.T) {
 	s := []string{"apple", "banana", "orange", "pear"}
 	e := "banana"
@@ -46,3 +95,26 @@ func TestContains(t *testing.T) {
 		t.Errorf("Contains() found non-existing element, got: true, want: false")
 	}
 }
+
+func TestRetryDelay(t *testing.T) {
+	tests := []struct {
+		name     string
+		base     time.Duration
+		attempts int
+		delay    time.Duration
+	}{
+		{"first retry", 30 * time.Second, 1, 30 * time.Second},
+		{"doubled for the second retry", 30 * time.Second, 2, time.Minute},
+		{"doubled for every retry", 30 * time.Second, 5, 8 * time.Minute},
+		{"capped at the max delay", 30 * time.Second, 20, time.Hour},
+		{"base capped at the max delay", 2 * time.Hour, 1, time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if delay := utils.RetryDelay(tt.base, time.Hour, tt.attempts); delay != tt.delay {
+				t.Errorf("RetryDelay(%s, %d) = %s, want: %s", tt.base, tt.attempts, delay, tt.delay)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package utils
+
+import (
+	"image"
+
+	"golang.org/x/image/draw"
+)
+
+// Resample scales the image to the given width while keeping the aspect ratio.
+// Large downscales are first reduced by an integer box filter, which is cheap
+// and keeps the interpolation buffers small, before the final Catmull-Rom pass.
+func Resample(img image.Image, width uint) image.Image {
+	// An image needs at least one column
+	if width < 1 {
+		width = 1
+	}
+
+	bounds := img.Bounds()
+	height := int(float64(width) / float64(bounds.Dx()) * float64(bounds.Dy()))
+	if height < 1 {
+		height = 1
+	}
+
+	src := img
+	if factor := bounds.Dx() / int(width); factor >= 2 {
+		src = boxShrink(img, factor)
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, int(width), height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, src.Bounds(), draw.Src, nil)
+
+	return dst
+}
+
+// boxShrink reduces the image by the given factor by averaging each
+// factor x factor block. Unsupported image types are returned as is.
+func boxShrink(img image.Image, factor int) image.Image {
+	bounds := img.Bounds()
+	rect := image.Rect(0, 0, bounds.Dx()/factor, bounds.Dy()/factor)
+
+	switch src := img.(type) {
+	case *image.YCbCr:
+		dst := image.NewYCbCr(rect, src.SubsampleRatio)
+		shrinkPlane(src.Y[src.YOffset(bounds.Min.X, bounds.Min.Y):], src.YStride, 1,
+			bounds.Dx(), bounds.Dy(), dst.Y, dst.YStride, rect.Dx(), rect.Dy(), factor)
+
+		// Chroma planes may be subsampled, so size them from the strides
+		srcCW, srcCH := chromaSize(src)
+		dstCW, dstCH := chromaSize(dst)
+		offset := src.COffset(bounds.Min.X, bounds.Min.Y)
+		shrinkPlane(src.Cb[offset:], src.CStride, 1, srcCW, srcCH, dst.Cb, dst.CStride, dstCW, dstCH, factor)
+		shrinkPlane(src.Cr[offset:], src.CStride, 1, srcCW, srcCH, dst.Cr, dst.CStride, dstCW, dstCH, factor)
+		return dst
+	case *image.RGBA:
+		dst := image.NewRGBA(rect)
+		shrinkChannels(src.Pix[src.PixOffset(bounds.Min.X, bounds.Min.Y):], src.Stride,
+			bounds.Dx(), bounds.Dy(), dst.Pix, dst.Stride, rect.Dx(), rect.Dy(), factor)
+		return dst
+	case *image.NRGBA:
+		dst := image.NewNRGBA(rect)
+		shrinkChannels(src.Pix[src.PixOffset(bounds.Min.X, bounds.Min.Y):], src.Stride,
+			bounds.Dx(), bounds.Dy(), dst.Pix, dst.Stride, rect.Dx(), rect.Dy(), factor)
+		return dst
+	}
+
+	return img
+}
+
+// chromaSize returns the width and height of the chroma planes
+func chromaSize(img *image.YCbCr) (int, int) {
+	w, h := img.Rect.Dx(), img.Rect.Dy()
+
+	switch img.SubsampleRatio {
+	case image.YCbCrSubsampleRatio422:
+		return (w + 1) / 2, h
+	case image.YCbCrSubsampleRatio420:
+		return (w + 1) / 2, (h + 1) / 2
+	case image.YCbCrSubsampleRatio440:
+		return w, (h + 1) / 2
+	case image.YCbCrSubsampleRatio411:
+		return (w + 3) / 4, h
+	case image.YCbCrSubsampleRatio410:
+		return (w + 3) / 4, (h + 1) / 2
+	}
+
+	return w, h
+}
+
+// shrinkChannels box filters each of the 4 interleaved channels
+func shrinkChannels(src []uint8, srcStride, srcW, srcH int, dst []uint8, dstStride, dstW, dstH, factor int) {
+	for channel := 0; channel < 4; channel++ {
+		shrinkPlane(src[channel:], srcStride, 4, srcW, srcH, dst[channel:], dstStride, dstW, dstH, factor)
+	}
+}
+
+// shrinkPlane box filters a single 8-bit plane where consecutive samples are
+// step bytes apart. Blocks at the edges are clamped to the source size.
+func shrinkPlane(src []uint8, srcStride, step, srcW, srcH int, dst []uint8, dstStride, dstW, dstH, factor int) {
+	for y := 0; y < dstH; y++ {
+		y0 := y * factor
+		y1 := min(y0+factor, srcH)
+
+		for x := 0; x < dstW; x++ {
+			x0 := x * factor
+			x1 := min(x0+factor, srcW)
+
+			sum, count := 0, 0
+			for sy := y0; sy < y1; sy++ {
+				row := sy * srcStride
+				for sx := x0; sx < x1; sx++ {
+					sum += int(src[row+sx*step])
+				}
+				count += x1 - x0
+			}
+
+			if count > 0 {
+				dst[y*dstStride+x*step] = uint8(sum / count)
+			}
+		}
+	}
+}
@@ -0,0 +1,79 @@
+package utils_test
+
+import (
+	"image"
+	"testing"
+
+	"github.com/austinbspencer/gshare-server/pkg/utils"
+	"github.com/nfnt/resize"
+)
+
+// newTestImage builds a YCbCr image like the ones decoded from a jpeg
+func newTestImage(width, height int) image.Image {
+	img := image.NewYCbCr(image.Rect(0, 0, width, height), image.YCbCrSubsampleRatio420)
+	for i := range img.Y {
+		img.Y[i] = uint8(i % 251)
+	}
+	for i := range img.Cb {
+		img.Cb[i] = uint8(i % 241)
+		img.Cr[i] = uint8(i % 239)
+	}
+	return img
+}
+
+func TestResample(t *testing.T) {
+	img := newTestImage(4000, 3000)
+
+	result := utils.Resample(img, 1080)
+
+	if result.Bounds().Dx() != 1080 || result.Bounds().Dy() != 810 {
+		t.Errorf("Resample() returned wrong dimensions, got: %dx%d, want: 1080x810",
+			result.Bounds().Dx(), result.Bounds().Dy())
+	}
+}
+
+func TestResampleZeroWidth(t *testing.T) {
+	img := newTestImage(400, 300)
+
+	result := utils.Resample(img, 0)
+
+	if result.Bounds().Dx() != 1 || result.Bounds().Dy() != 1 {
+		t.Errorf("Resample() returned wrong dimensions, got: %dx%d, want: 1x1",
+			result.Bounds().Dx(), result.Bounds().Dy())
+	}
+}
+
+func TestResampleKeepsColor(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 3000, 2000))
+	for i := 0; i < len(img.Pix); i += 4 {
+		img.Pix[i], img.Pix[i+1], img.Pix[i+2], img.Pix[i+3] = 200, 100, 50, 255
+	}
+
+	result := utils.Resample(img, 500)
+
+	r, g, b, _ := result.At(250, 150).RGBA()
+	if r>>8 != 200 || g>>8 != 100 || b>>8 != 50 {
+		t.Errorf("Resample() changed the color, got: (%d, %d, %d), want: (200, 100, 50)", r>>8, g>>8, b>>8)
+	}
+}
+
+// Baseline using the previous nfnt/resize Lanczos3 implementation
+func BenchmarkResizeLanczos3(b *testing.B) {
+	img := newTestImage(4000, 3000)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		resize.Resize(1080, 810, img, resize.Lanczos3)
+	}
+}
+
+func BenchmarkResample(b *testing.B) {
+	img := newTestImage(4000, 3000)
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		utils.Resample(img, 1080)
+	}
+}
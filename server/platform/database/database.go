@@ -4,7 +4,9 @@ import (
 	"log"
 	"os"
 	"strings"
+	"time"
 
+	"github.com/austinbspencer/gshare-server/internal/models"
 	"github.com/austinbspencer/gshare-server/pkg/configs"
 	fiberLog "github.com/gofiber/fiber/v2/log"
 	"gorm.io/gorm"
@@ -28,6 +30,83 @@ func Connect() {
 	}
 }
 
+// backfillPublicIDs gives a public ID to the galleries and images that were
+// created before public IDs were added
+func backfillPublicIDs() {
+	for _, model := range []any{&models.Gallery{}, &models.Image{}} {
+		var ids []uint
+		if err := DB.Unscoped().Model(model).Where("public_id IS NULL OR public_id = ''").
+			Pluck("id", &ids).Error; err != nil {
+			fiberLog.Errorf("Unable to find rows without a public ID: %v\n", err)
+			continue
+		}
+
+		for _, id := range ids {
+			// Update the column directly to skip the update hooks
+			if err := DB.Unscoped().Model(model).Where("id = ?", id).
+				UpdateColumn("public_id", models.NewPublicID()).Error; err != nil {
+				fiberLog.Errorf("Unable to set the public ID of row %d: %v\n", id, err)
+			}
+		}
+
+		if len(ids) > 0 {
+			fiberLog.Infof("Added public IDs to %d existing rows\n", len(ids))
+		}
+	}
+}
+
+// migrateReminderEmails moves the space separated reminder emails that
+// galleries had before contacts were added into the gallery contacts
+func migrateReminderEmails() {
+	if !DB.Migrator().HasColumn(&models.Gallery{}, "reminder_emails") {
+		return
+	}
+
+	var rows []struct {
+		ID             uint
+		ReminderEmails string
+	}
+	if err := DB.Table("galleries").Select("id, reminder_emails").
+		Where("reminder_emails IS NOT NULL AND reminder_emails <> ''").
+		Scan(&rows).Error; err != nil {
+		fiberLog.Errorf("Unable to find galleries with reminder emails: %v\n", err)
+		return
+	}
+
+	for _, row := range rows {
+		err := DB.Transaction(func(tx *gorm.DB) error {
+			for _, field := range strings.Fields(row.ReminderEmails) {
+				email, err := models.NormalizeContactEmail(field)
+				if err != nil {
+					fiberLog.Warnf("Skipping reminder email of gallery %d: %v\n", row.ID, err)
+					continue
+				}
+
+				client := models.Client{Email: email}
+				if err := tx.Where("email = ?", email).FirstOrCreate(&client).Error; err != nil {
+					return err
+				}
+
+				contact := models.GalleryContact{GalleryID: row.ID, ClientID: client.ID, Role: models.ContactClient}
+				if err := tx.Where("gallery_id = ? AND client_id = ?", row.ID, client.ID).
+					FirstOrCreate(&contact).Error; err != nil {
+					return err
+				}
+			}
+
+			return tx.Table("galleries").Where("id = ?", row.ID).
+				UpdateColumn("reminder_emails", nil).Error
+		})
+		if err != nil {
+			fiberLog.Errorf("Unable to move the reminder emails of gallery %d to contacts: %v\n", row.ID, err)
+		}
+	}
+
+	if len(rows) > 0 {
+		fiberLog.Infof("Moved the reminder emails of %d galleries to contacts\n", len(rows))
+	}
+}
+
 func getCustomLogger() logger.Interface {
 	return logger.New(
 		log.New(os.Stdout, "\r\n", log.LstdFlags), // io writer
@@ -57,3 +136,21 @@ func getGormLogLevel(level string) logger.LogLevel {
 		return logger.Warn
 	}
 }
+
+// markFiredGalleries marks the galleries that were already live or expired
+// before their events were marked as fired, so upgrading doesn't fire the
+// events of every past gallery again
+func markFiredGalleries() {
+	now := time.Now()
+
+	for _, event := range []struct{ column, date string }{
+		{"live_fired_at", "live"},
+		{"expired_fired_at", "expiration"},
+	} {
+		if err := DB.Unscoped().Model(&models.Gallery{}).
+			Where(event.column+" IS NULL AND "+event.date+" <= ?", now).
+			UpdateColumn(event.column, now).Error; err != nil {
+			fiberLog.Errorf("Unable to mark the %s of the existing galleries: %v\n", event.column, err)
+		}
+	}
+}
@@ -35,18 +35,41 @@ func connectPostgres() {
 
 	fiberLog.Infof("Connection Opened to Database: %s\n", dbName)
 
+	// Galleries from before the fired markers already had their events
+	markFired := !DB.Migrator().HasColumn(&models.Gallery{}, "live_fired_at")
+
 	// Migrate the models into DB
 	DB.AutoMigrate(
 		&models.User{},
 		&models.Client{},
+		&models.GalleryContact{},
 		&models.Gallery{},
+		&models.GallerySet{},
 		&models.Image{},
+		&models.ImageVersion{},
+		&models.Tag{},
 		&models.Event{},
+		&models.GalleryDownload{},
+		&models.PreviewLink{},
+		&models.PreviewFavorite{},
+		&models.GalleryPreset{},
+		&models.GalleryReminder{},
+		&models.LiveNotification{},
+		&models.OutboxEmail{},
+		&models.EmailTemplate{},
+		&models.Webhook{},
+		&models.WebhookDelivery{},
 		&models.Settings{},
 	)
 
 	// Create settings if not exists
 	DB.Where("id = 1").FirstOrCreate(&models.Settings{})
 
+	backfillPublicIDs()
+	migrateReminderEmails()
+	if markFired {
+		markFiredGalleries()
+	}
+
 	fiberLog.Infof("Database `%s` Migrated\n", dbName)
 }
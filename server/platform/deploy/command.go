@@ -0,0 +1,60 @@
+package deploy
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2/log"
+)
+
+// How long the deploy command has to finish
+const commandTimeout = 10 * time.Minute
+
+// commandDeployer runs a shell command to deploy the client. The paths are
+// given as the arguments of the command and in GSHARE_DEPLOY_PATHS.
+type commandDeployer struct {
+	command string
+}
+
+func (d *commandDeployer) Deploy(paths []string) error {
+	if d.command == "" {
+		return errors.New("CLIENT_DEPLOY_COMMAND is required to deploy the client with a command")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	// The paths are the positional parameters of the command ($1, $2, ...)
+	args := append([]string{"-c", d.command, "gshare-deploy"}, paths...)
+	cmd := exec.CommandContext(ctx, "/bin/sh", args...)
+	cmd.Env = append(os.Environ(), "GSHARE_DEPLOY_PATHS="+strings.Join(paths, " "))
+
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+
+	if err := cmd.Run(); err != nil {
+		out := strings.TrimSpace(output.String())
+		if len(out) > maxErrorBody {
+			out = out[len(out)-maxErrorBody:]
+		}
+		return fmt.Errorf("deploy command failed: %v: %s", err, out)
+	}
+
+	log.Debugf("Deploy command output: %s\n", output.String())
+
+	return nil
+}
+
+func (d *commandDeployer) Test() error {
+	if d.command == "" {
+		return errors.New("CLIENT_DEPLOY_COMMAND is required to deploy the client with a command")
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+package deploy
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/austinbspencer/gshare-server/internal/models"
+	"github.com/austinbspencer/gshare-server/internal/queries"
+	"github.com/austinbspencer/gshare-server/pkg/configs"
+	"github.com/gofiber/fiber/v2/log"
+)
+
+// Deployer updates the client site after the galleries change, it is
+// chosen with CLIENT_DEPLOYER
+type Deployer interface {
+	// Deploy updates the pages at the paths, or the whole site when there
+	// are no paths
+	Deploy(paths []string) error
+	// Test checks that the client can be deployed
+	Test() error
+}
+
+var (
+	DockerDeployer     = "docker"
+	RevalidateDeployer = "revalidate"
+	CommandDeployer    = "command"
+	NoDeployer         = "none"
+)
+
+// Name returns the deployer set by CLIENT_DEPLOYER, docker by default
+func Name() string {
+	return configs.Getenv("CLIENT_DEPLOYER", DockerDeployer)
+}
+
+// NewDeployer returns the deployer set by CLIENT_DEPLOYER
+func NewDeployer() (Deployer, error) {
+	switch deployer := Name(); deployer {
+	case DockerDeployer:
+		return &dockerDeployer{
+			containerName: configs.Getenv("CLIENT_CONTAINER", "gshare-client"),
+		}, nil
+	case RevalidateDeployer:
+		return &revalidateDeployer{
+			url:    configs.Getenv("CLIENT_REVALIDATE_URL", os.Getenv("NEXT_PUBLIC_CLIENT_URL")+"/api/revalidate"),
+			secret: os.Getenv("CLIENT_REVALIDATE_SECRET"),
+		}, nil
+	case CommandDeployer:
+		return &commandDeployer{
+			command: os.Getenv("CLIENT_DEPLOY_COMMAND"),
+		}, nil
+	case NoDeployer:
+		return &noDeployer{}, nil
+	default:
+		return nil, fmt.Errorf("unknown client deployer %q, use docker, revalidate, command or none", deployer)
+	}
+}
+
+// TestDeployer checks the deployer set by CLIENT_DEPLOYER, only logging
+// what is wrong so the server still starts
+func TestDeployer() {
+	deployer, err := NewDeployer()
+	if err == nil {
+		err = deployer.Test()
+	}
+
+	if err != nil {
+		log.Warnf("Error encountered while testing the %s client deployer: %v\n", Name(), err)
+	}
+}
+
+// Deploy updates the pages at the paths with the deployer, or the whole
+// site when there are no paths, and saves the status of the deploy in the
+// settings. The pending update is cleared when the deploy succeeds.
+func Deploy(paths ...string) error {
+	settingsQueries := queries.NewSettingsRepository()
+
+	deployedAt := time.Now()
+	settings := &models.Settings{
+		DeployStatus: models.DeploySuccess,
+		DeployPaths:  strings.Join(paths, " "),
+		DeployedAt:   &deployedAt,
+	}
+
+	deployer, err := NewDeployer()
+	if err == nil {
+		err = deployer.Deploy(paths)
+	}
+
+	if err != nil {
+		deployError := err.Error()
+		settings.DeployStatus = models.DeployFailed
+		settings.DeployError = &deployError
+	} else if Name() == NoDeployer {
+		settings.DeployStatus = models.DeploySkipped
+	}
+
+	if err := settingsQueries.SetDeployStatus(settings); err != nil {
+		log.Errorf("Unable to save the deploy status in DB: %v\n", err)
+	}
+
+	// Deploying some paths leaves the changes to the other pages pending
+	if settings.DeployStatus == models.DeploySuccess && len(paths) == 0 {
+		if err := settingsQueries.SetSettingsUpdate(false); err != nil {
+			log.Errorf("Error setting settings update to false: %v\n", err)
+		}
+	}
+
+	return err
+}
+
+// GalleryPaths returns the paths of the client site that show the gallery
+func GalleryPaths(gallery *models.Gallery) []string {
+	return []string{"/", "/" + gallery.Path}
+}
+
+// noDeployer leaves deploying the client to the admin
+type noDeployer struct{}
+
+func (d *noDeployer) Deploy(paths []string) error {
+	log.Debugf("Client deployer is none, not deploying %v\n", paths)
+	return nil
+}
+
+func (d *noDeployer) Test() error {
+	return nil
+}
@@ -1,4 +1,4 @@
-package docker
+package deploy
 
 import (
 	"context"
@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"strings"
 
-	"github.com/austinbspencer/gshare-server/pkg/configs"
 	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/client"
 	"github.com/gofiber/fiber/v2/log"
@@ -14,7 +13,32 @@ import (
 
 var dockerHost = "unix:///var/run/docker.sock"
 
-func TestDockerConnection() error {
+// dockerDeployer restarts the client container, which builds the whole site
+// again. It needs the Docker socket mounted in the server container.
+type dockerDeployer struct {
+	containerName string
+}
+
+// Deploy restarts the client container, the paths are ignored since the
+// restart builds every page
+func (d *dockerDeployer) Deploy(paths []string) error {
+	// Run again in case client container has changed since last set
+	clientContainerID, err := d.getClientContainerID()
+	if err != nil {
+		return fmt.Errorf("gshare client docker container unable to find: %v", err)
+	}
+
+	dockerClient, err := client.NewClientWithOpts(client.WithHost(dockerHost), client.WithAPIVersionNegotiation())
+	if err != nil {
+		return err
+	}
+
+	log.Infof("Restarting container with ID: %s\n", clientContainerID)
+
+	return dockerClient.ContainerRestart(context.Background(), clientContainerID, container.StopOptions{})
+}
+
+func (d *dockerDeployer) Test() error {
 	ctx := context.Background()
 	dockerClient, err := client.NewClientWithOpts(client.WithHost(dockerHost), client.WithAPIVersionNegotiation())
 	if err != nil {
@@ -33,7 +57,7 @@ func TestDockerConnection() error {
 	return nil
 }
 
-func GetClientContainerID() (string, error) {
+func (d *dockerDeployer) getClientContainerID() (string, error) {
 	var clientContainerID string
 
 	ctx := context.Background()
@@ -47,15 +71,13 @@ func GetClientContainerID() (string, error) {
 		return clientContainerID, err
 	}
 
-	clientContainerName := configs.Getenv("CLIENT_CONTAINER", "gshare-client")
-
 	// Set the client container ID with the container name
 	for _, container := range containers {
 		if strings.Contains(container.Image, "gshare") {
 			log.Debugf("gshare image found: %s\n", container.Image)
 			for _, name := range container.Names {
-				if strings.Contains(name, clientContainerName) {
-					log.Infof("Setting client container ID: %s\n", clientContainerID)
+				if strings.Contains(name, d.containerName) {
+					log.Infof("Setting client container ID: %s\n", container.ID)
 					clientContainerID = container.ID
 				}
 			}
@@ -82,21 +104,3 @@ func GetClientContainerID() (string, error) {
 
 	return clientContainerID, nil
 }
-
-func RestartClientContainer() error {
-	// Run again in case client container has changed since last set
-	clientContainerID, err := GetClientContainerID()
-	if err != nil {
-		return fmt.Errorf("gshare client docker container unable to find: %v\n", err)
-	}
-
-	ctx := context.Background()
-	dockerClient, err := client.NewClientWithOpts(client.WithHost(dockerHost), client.WithAPIVersionNegotiation())
-	if err != nil {
-		return err
-	}
-
-	log.Infof("Restarting container with ID: %s\n", clientContainerID)
-
-	return dockerClient.ContainerRestart(ctx, clientContainerID, container.StopOptions{})
-}
@@ -0,0 +1,111 @@
+package deploy
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/austinbspencer/gshare-server/internal/queries"
+)
+
+const (
+	// How long the client has to revalidate the pages
+	revalidateTimeout = 2 * time.Minute
+	// Most of the response body kept in the error of a failed revalidation
+	maxErrorBody = 512
+)
+
+// revalidateDeployer asks the client to regenerate the pages with Next.js
+// on-demand revalidation, without restarting it
+type revalidateDeployer struct {
+	url    string
+	secret string
+}
+
+// revalidateRequest is the body posted to the revalidate API of the client
+type revalidateRequest struct {
+	Paths []string `json:"paths"`
+}
+
+// Deploy revalidates the paths, or every page of the site when there are
+// no paths
+func (d *revalidateDeployer) Deploy(paths []string) error {
+	if len(paths) == 0 {
+		var err error
+		if paths, err = sitePaths(); err != nil {
+			return err
+		}
+	}
+
+	return d.post(paths)
+}
+
+// Test checks the revalidation is set up, the client isn't asked since it
+// usually starts after the server
+func (d *revalidateDeployer) Test() error {
+	if d.secret == "" {
+		return errors.New("CLIENT_REVALIDATE_SECRET is required to revalidate the client")
+	}
+
+	if _, err := url.ParseRequestURI(d.url); err != nil {
+		return fmt.Errorf("invalid CLIENT_REVALIDATE_URL: %v", err)
+	}
+
+	return nil
+}
+
+func (d *revalidateDeployer) post(paths []string) error {
+	if err := d.Test(); err != nil {
+		return err
+	}
+
+	body, err := json.Marshal(revalidateRequest{Paths: paths})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, d.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+d.secret)
+
+	client := &http.Client{Timeout: revalidateTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return fmt.Errorf("client responded with %s: %s", resp.Status, bytes.TrimSpace(body))
+	}
+
+	return nil
+}
+
+// sitePaths returns the path of the home page and of every gallery, expired
+// galleries included so their pages are removed
+func sitePaths() ([]string, error) {
+	galleryQueries := queries.NewGalleryRepository()
+
+	galleries, err := galleryQueries.GetGalleries()
+	if err != nil {
+		return nil, err
+	}
+
+	paths := []string{"/"}
+	for _, gallery := range galleries {
+		paths = append(paths, "/"+gallery.Path)
+	}
+
+	return paths, nil
+}
@@ -1,27 +1,16 @@
 package email
 
 import (
-	"bytes"
-	"crypto/tls"
-	"fmt"
-	"net/smtp"
+	"net/mail"
 	"os"
 	"strings"
-	"text/template"
 
+	"github.com/austinbspencer/gshare-server/internal/models"
 	"github.com/austinbspencer/gshare-server/pkg/configs"
 	"github.com/gofiber/fiber/v2/log"
 )
 
-var (
-	twoFactorTemplatePath = "templates/twofactor.html"
-	twoFactorTemplate     = "twofactor.html"
-
-	reminderTemplatePath = "templates/reminder.html"
-	reminderTemplate     = "reminder.html"
-)
-
-// TestEmailConnection is a function to test the SMTP connection.
+// TestEmailConnection is a function to test the mail transport.
 func TestEmailConnection() {
 	// Check if TWO_FA is enabled
 	if !configs.GetenvBool("TWO_FACTOR_AUTHENTICATION", false) {
@@ -29,207 +18,107 @@ func TestEmailConnection() {
 		return
 	}
 
-	username := os.Getenv("SMTP_USERNAME")
-	password := os.Getenv("SMTP_PASSWORD")
-
-	// SMTP server details
-	smtpHost := configs.Getenv("SMTP_HOST", "smtp.gmail.com")
-	smtpPort := configs.Getenv("SMTP_PORT", "587")
-
-	// Connect to the SMTP server
-	auth := smtp.PlainAuth("", username, password, smtpHost)
-	smtpAddress := fmt.Sprintf("%s:%s", smtpHost, smtpPort)
-	connection, err := smtp.Dial(smtpAddress)
+	transport, err := NewTransport()
 	if err != nil {
-		log.Errorf("Error connecting to SMTP server:", err)
+		log.Errorf("Invalid mail transport: %v\n", err)
 		return
 	}
-	defer connection.Close()
-
-	// Start TLS if available
-	if configs.GetenvBool("SMTP_TLS", true) {
-		if err := connection.StartTLS(&tls.Config{ServerName: smtpHost}); err != nil {
-			log.Errorf("Error starting TLS:", err)
-			return
-		}
-	}
 
-	// Authenticate
-	if err := connection.Auth(auth); err != nil {
-		log.Errorf("Error authenticating:", err)
+	if err := transport.Test(); err != nil {
+		log.Errorf("Error testing the %s mail transport: %v\n", configs.Getenv("MAIL_TRANSPORT", SMTPTransport), err)
 		return
 	}
 
-	log.Info("SMTP authentication successful.")
-}
-
-// loadTemplate will load the html file an input any variables
-func loadTemplate(temp, path string, emailVars any) (string, error) {
-	t := template.New(temp)
-	var err error
-	var result string
-
-	t, err = t.ParseFiles(path)
-	if err != nil {
-		return result, err
-	}
-
-	var tpl bytes.Buffer
-	if err := t.Execute(&tpl, emailVars); err != nil {
-		return result, err
-	}
-
-	result = tpl.String()
-
-	return result, nil
+	log.Infof("The %s mail transport is ready.\n", configs.Getenv("MAIL_TRANSPORT", SMTPTransport))
 }
 
+// SendEmail sends the message with the mail transport right away, other
+// emails are sent through the outbox so they are retried when sending fails
 func SendEmail(to string, msg []byte) error {
-	from := os.Getenv("SMTP_USERNAME")
-
-	// Sender email, username and password
-	username := os.Getenv("SMTP_USERNAME")
-	password := os.Getenv("SMTP_PASSWORD")
-
-	// SMTP server details
-	smtpHost := configs.Getenv("SMTP_HOST", "smtp.gmail.com")
-	smtpPort := configs.Getenv("SMTP_PORT", "587")
-
-	// Authentication credentials
-	auth := smtp.PlainAuth("", username, password, smtpHost)
-
-	// Connect to the SMTP server
-	serverAddr := smtpHost + ":" + smtpPort
-	conn, err := smtp.Dial(serverAddr)
+	transport, err := NewTransport()
 	if err != nil {
-		log.Errorf("Unable to connect to SMTP server (%s) with error: %v\n", serverAddr, err)
+		log.Errorf("Invalid mail transport: %v\n", err)
 		return err
 	}
 
-	// Close the connection
-	defer conn.Quit()
-
-	// Start TLS if available
-	if configs.GetenvBool("SMTP_TLS", true) {
-		if err := conn.StartTLS(&tls.Config{ServerName: smtpHost}); err != nil {
-			log.Errorf("Error starting TLS: %v\n", err)
-			return err
-		}
-	}
+	// Handle multiple recipients
+	recipients := strings.Fields(to)
 
-	// Authenticate
-	if err := conn.Auth(auth); err != nil {
-		log.Errorf("SMTP authentication failed: %v\n", err)
+	if err := transport.Send(envelopeSender(), recipients, msg); err != nil {
+		log.Errorf("Unable to send email to %s: %v\n", to, err)
 		return err
 	}
 
-	// // Set the sender and recipient
-	if err := conn.Mail(from); err != nil {
-		log.Errorf("Unable to set the sender (%s) for SMTP: %v\n", from, err)
-		return err
-	}
+	log.Info("Email sent successfully.")
 
-	// Handle multiple recipients
-	recipients := strings.Split(to, " ")
+	return nil
+}
 
-	for _, recipient := range recipients {
-		if err := conn.Rcpt(strings.TrimSpace(recipient)); err != nil {
-			log.Errorf("Unable to set the recipient (%s) for SMTP: %v\n", to, err)
-			return err
-		}
+// envelopeSender returns the address that the emails are sent from, the
+// SMTP username unless only SMTP_FROM is set
+func envelopeSender() string {
+	if username := os.Getenv("SMTP_USERNAME"); username != "" {
+		return username
 	}
 
-	// Send the email body
-	wc, err := conn.Data()
-	if err != nil {
-		log.Errorf("Unable to open data connection with SMTP: %v\n", err)
-		return err
-	}
-	_, err = wc.Write(msg)
-	if err != nil {
-		log.Errorf("Unable to write to SMTP WriteCloser: %v\n", err)
-		return err
+	if address, err := mail.ParseAddress(os.Getenv("SMTP_FROM")); err == nil {
+		return address.Address
 	}
-	err = wc.Close()
-	if err != nil {
-		log.Errorf("Unable to close SMTP WriteCloser: %v\n", err)
-	}
-
-	log.Info("Email sent successfully.")
 
-	return nil
+	return ""
 }
 
 func Send2FAEmail(to, code string) error {
-	from := configs.Getenv("SMTP_FROM", os.Getenv("SMTP_USERNAME"))
-
-	// Email subject and body
-	subject := "Two-Factor Authentication Code"
-
-	emailVars := struct {
-		Code string
-	}{
+	emailVars := twoFactorVars{
 		Code: code,
 	}
 
-	body, err := loadTemplate(twoFactorTemplate, twoFactorTemplatePath, emailVars)
-	if err != nil {
-		return err
-	}
-
-	// Construct the email message
-	msg := []byte("From: " + from + "\r\n" +
-		"To: " + to + "\r\n" +
-		"Subject: " + subject + "\r\n" +
-		"Content-Type: text/html; charset=UTF-8\r\n" +
-		"\r\n" +
-		body + "\r\n")
-
-	// Send the email
-	err = SendEmail(to, msg)
-	if err != nil {
-		log.Errorf("Error sending 2FA email: %v\n", err)
+	// Queue the email in the outbox
+	if _, err := queueTemplateEmail(models.EmailTwoFactor, to, emailVars); err != nil {
+		log.Errorf("Error queueing 2FA email: %v\n", err)
 		return err
 	}
 
 	return nil
 }
 
-func SendReminderEmail(to, galleryLink, expiration string) error {
-	from := configs.Getenv("SMTP_FROM", os.Getenv("SMTP_USERNAME"))
-
-	// Email subject and body
-	subject := "Friendly Reminder: Gallery Expiring Soon"
-
-	emailVars := struct {
-		ExpirationDate   string
-		GalleryLink      string
-		PhotographerName string
-	}{
+// SendReminderEmail queues the reminder that the gallery expires soon and
+// returns the email in the outbox
+func SendReminderEmail(to, galleryLink, expiration string) (*models.OutboxEmail, error) {
+	emailVars := reminderVars{
 		ExpirationDate:   expiration,
 		GalleryLink:      galleryLink,
 		PhotographerName: configs.Getenv("NEXT_PUBLIC_PHOTOGRAPHER_NAME", "Your Photographer"),
 	}
 
-	body, err := loadTemplate(reminderTemplate, reminderTemplatePath, emailVars)
+	// Queue the email in the outbox
+	outboxEmail, err := queueTemplateEmail(models.EmailReminder, to, emailVars)
 	if err != nil {
-		return err
+		log.Errorf("Error queueing reminder email: %v\n", err)
+		return nil, err
 	}
 
-	// Construct the email message
-	msg := []byte("From: " + from + "\r\n" +
-		"To: " + to + "\r\n" +
-		"Subject: " + subject + "\r\n" +
-		"Content-Type: text/html; charset=UTF-8\r\n" +
-		"\r\n" +
-		body + "\r\n")
+	return outboxEmail, nil
+}
+
+// SendLiveEmail announces to the clients that their gallery is live. The
+// password hint is left out when it is empty. The email in the outbox is
+// returned.
+func SendLiveEmail(to, galleryTitle, galleryLink, passwordHint, expiration string) (*models.OutboxEmail, error) {
+	emailVars := liveVars{
+		GalleryTitle:     galleryTitle,
+		GalleryLink:      galleryLink,
+		PasswordHint:     passwordHint,
+		ExpirationDate:   expiration,
+		PhotographerName: configs.Getenv("NEXT_PUBLIC_PHOTOGRAPHER_NAME", "Your Photographer"),
+	}
 
-	// Send the email
-	err = SendEmail(to, msg)
+	// Queue the email in the outbox
+	outboxEmail, err := queueTemplateEmail(models.EmailLive, to, emailVars)
 	if err != nil {
-		log.Errorf("Error sending 2FA email: %v\n", err)
-		return err
+		log.Errorf("Error queueing live email: %v\n", err)
+		return nil, err
 	}
 
-	return nil
+	return outboxEmail, nil
 }
@@ -0,0 +1,190 @@
+package email
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/austinbspencer/gshare-server/internal/models"
+	"github.com/austinbspencer/gshare-server/internal/queries"
+	"github.com/austinbspencer/gshare-server/pkg/configs"
+	"github.com/austinbspencer/gshare-server/pkg/utils"
+	"github.com/gofiber/fiber/v2/log"
+)
+
+const (
+	defaultMaxAttempts = 8
+	// Seconds before the first retry, doubled for every retry after it
+	defaultRetryDelay = 30
+	maxRetryDelay     = time.Hour
+	// 2FA emails are only sent while the login is likely still waiting
+	twoFactorMaxAge = 10 * time.Minute
+
+	// How often the outbox checks for emails that are due
+	outboxInterval = 30 * time.Second
+	// Emails sent by a single check of the outbox
+	outboxBatchSize = 50
+)
+
+// wakeOutbox has the outbox send new emails right away instead of waiting
+// for its next check
+var wakeOutbox = make(chan struct{}, 1)
+
+// RunOutbox starts sending the emails in the outbox in the background
+func RunOutbox() {
+	go func() {
+		ticker := time.NewTicker(outboxInterval)
+		defer ticker.Stop()
+
+		for {
+			sendDueEmails()
+
+			select {
+			case <-ticker.C:
+			case <-wakeOutbox:
+			}
+		}
+	}()
+}
+
+// QueueEmail adds the email to the outbox to be sent in the background
+func QueueEmail(kind models.EmailKind, to, subject string, msg []byte) (*models.OutboxEmail, error) {
+	outboxQueries := queries.NewOutboxRepository()
+
+	now := time.Now()
+	outboxEmail := &models.OutboxEmail{
+		Kind:          kind,
+		To:            to,
+		Subject:       subject,
+		Message:       msg,
+		Status:        models.OutboxPending,
+		NextAttemptAt: now,
+	}
+	if kind == models.EmailTwoFactor {
+		expiresAt := now.Add(twoFactorMaxAge)
+		outboxEmail.ExpiresAt = &expiresAt
+	}
+
+	if err := outboxQueries.CreateOutboxEmail(outboxEmail); err != nil {
+		log.Errorf("Unable to add %s email to the outbox: %v\n", kind, err)
+		return nil, err
+	}
+
+	// Don't block when the outbox was already woken up
+	select {
+	case wakeOutbox <- struct{}{}:
+	default:
+	}
+
+	return outboxEmail, nil
+}
+
+// ResendEmail queues a copy of the email, the email itself is kept as it
+// was in the delivery log. The reminders and notifications of the email
+// follow the copy.
+func ResendEmail(outboxEmail *models.OutboxEmail) (*models.OutboxEmail, error) {
+	resent, err := QueueEmail(outboxEmail.Kind, outboxEmail.To, outboxEmail.Subject, outboxEmail.Message)
+	if err != nil {
+		return nil, err
+	}
+
+	reminderQueries := queries.NewReminderRepository()
+	if err := reminderQueries.RelinkOutboxReminders(outboxEmail.ID, resent.ID); err != nil {
+		log.Errorf("Unable to link the reminders of email %d to email %d: %v\n", outboxEmail.ID, resent.ID, err)
+	}
+
+	notificationQueries := queries.NewNotificationRepository()
+	if err := notificationQueries.RelinkOutboxNotifications(outboxEmail.ID, resent.ID); err != nil {
+		log.Errorf("Unable to link the notifications of email %d to email %d: %v\n", outboxEmail.ID, resent.ID, err)
+	}
+
+	UpdateOutboxEmailSenders(resent.ID)
+
+	return resent, nil
+}
+
+// retryDelay returns how long to wait before the next attempt after the
+// given number of attempts failed
+func retryDelay(attempts int) time.Duration {
+	base := time.Duration(configs.GetenvInt("EMAIL_RETRY_DELAY", defaultRetryDelay)) * time.Second
+	return utils.RetryDelay(base, maxRetryDelay, attempts)
+}
+
+// sendDueEmails sends the pending emails that are due
+func sendDueEmails() {
+	outboxQueries := queries.NewOutboxRepository()
+
+	outboxEmails, err := outboxQueries.GetDueOutboxEmails(time.Now(), outboxBatchSize)
+	if err != nil {
+		log.Errorf("Error getting the emails due in the outbox: %v\n", err)
+		return
+	}
+
+	for idx := range outboxEmails {
+		sendOutboxEmail(&outboxEmails[idx])
+	}
+}
+
+// sendOutboxEmail tries to send the email, a failed email is tried again
+// later until it runs out of attempts or expires
+func sendOutboxEmail(outboxEmail *models.OutboxEmail) {
+	outboxQueries := queries.NewOutboxRepository()
+
+	if outboxEmail.Expired(time.Now()) {
+		log.Warnf("Not sending %s email %d as it expired\n", outboxEmail.Kind, outboxEmail.ID)
+
+		lastError := models.ErrOutboxEmailExpired.Error()
+		outboxEmail.LastError = &lastError
+		outboxEmail.Status = models.OutboxFailed
+	} else {
+		err := SendEmail(outboxEmail.To, outboxEmail.Message)
+		outboxEmail.RecordAttempt(err, time.Now(), configs.GetenvInt("EMAIL_MAX_ATTEMPTS", defaultMaxAttempts), retryDelay)
+
+		switch {
+		case outboxEmail.Status == models.OutboxFailed:
+			log.Errorf("Giving up on %s email %d after %d attempts: %v\n", outboxEmail.Kind, outboxEmail.ID, outboxEmail.Attempts, err)
+		case err != nil:
+			log.Warnf("Retrying %s email %d at %s\n", outboxEmail.Kind, outboxEmail.ID, outboxEmail.NextAttemptAt.Format(time.RFC3339))
+		}
+	}
+
+	if err := outboxQueries.SaveOutboxEmail(outboxEmail); err != nil {
+		log.Errorf("Unable to save %s email %d in the outbox: %v\n", outboxEmail.Kind, outboxEmail.ID, err)
+		return
+	}
+
+	updateOutboxEmailSenders(outboxEmail)
+}
+
+// UpdateOutboxEmailSenders updates the reminders and notifications linked
+// to the email once it was sent or failed. The outbox can try the email
+// before it was linked, so the senders call it after linking the email.
+func UpdateOutboxEmailSenders(outboxEmailID uint) {
+	outboxQueries := queries.NewOutboxRepository()
+
+	outboxEmail, err := outboxQueries.GetOutboxEmailByID(fmt.Sprint(outboxEmailID))
+	if err != nil {
+		log.Errorf("Unable to get email %d from the outbox: %v\n", outboxEmailID, err)
+		return
+	}
+
+	if outboxEmail.Status != models.OutboxPending {
+		updateOutboxEmailSenders(outboxEmail)
+	}
+}
+
+// updateOutboxEmailSenders updates the reminders and notifications that
+// were sent with the email to its status
+func updateOutboxEmailSenders(outboxEmail *models.OutboxEmail) {
+	switch outboxEmail.Kind {
+	case models.EmailReminder:
+		reminderQueries := queries.NewReminderRepository()
+		if err := reminderQueries.UpdateOutboxReminders(outboxEmail); err != nil {
+			log.Errorf("Unable to update the reminders of email %d: %v\n", outboxEmail.ID, err)
+		}
+	case models.EmailLive:
+		notificationQueries := queries.NewNotificationRepository()
+		if err := notificationQueries.UpdateOutboxNotifications(outboxEmail); err != nil {
+			log.Errorf("Unable to update the notifications of email %d: %v\n", outboxEmail.ID, err)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package email
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// sendmailTransport hands the emails to the local sendmail program
+type sendmailTransport struct {
+	path string
+}
+
+func (t *sendmailTransport) Send(from string, to []string, msg []byte) error {
+	// -i keeps a line with a single dot from ending the message
+	args := []string{"-i"}
+	if from != "" {
+		args = append(args, "-f", from)
+	}
+	args = append(args, "--")
+	args = append(args, to...)
+
+	var stderr bytes.Buffer
+	cmd := exec.Command(t.path, args...)
+	cmd.Stdin = bytes.NewReader(msg)
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if output := strings.TrimSpace(stderr.String()); output != "" {
+			return fmt.Errorf("%v: %s", err, output)
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (t *sendmailTransport) Test() error {
+	_, err := exec.LookPath(t.path)
+	return err
+}
@@ -0,0 +1,141 @@
+package email
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/smtp"
+	"os"
+	"time"
+
+	"github.com/austinbspencer/gshare-server/pkg/configs"
+)
+
+var (
+	// Upgrade the connection with STARTTLS, usually on port 587
+	SMTPStartTLS = "starttls"
+	// Connect with TLS from the start, usually on port 465
+	SMTPImplicitTLS = "tls"
+	// Plain connection, only for relays on a trusted network
+	SMTPNoTLS = "none"
+)
+
+// How long to wait for the SMTP server to answer the connection
+const smtpTimeout = 30 * time.Second
+
+// smtpTransport sends the emails through an SMTP server
+type smtpTransport struct {
+	host     string
+	port     string
+	username string
+	password string
+	security string
+}
+
+func newSMTPTransport() (*smtpTransport, error) {
+	// SMTP_TLS only chooses between STARTTLS and no TLS, SMTP_SECURITY
+	// takes precedence over it
+	security := SMTPNoTLS
+	if configs.GetenvBool("SMTP_TLS", true) {
+		security = SMTPStartTLS
+	}
+	security = configs.Getenv("SMTP_SECURITY", security)
+
+	switch security {
+	case SMTPStartTLS, SMTPImplicitTLS, SMTPNoTLS:
+	default:
+		return nil, fmt.Errorf("unknown SMTP security %q, use starttls, tls or none", security)
+	}
+
+	return &smtpTransport{
+		host:     configs.Getenv("SMTP_HOST", "smtp.gmail.com"),
+		port:     configs.Getenv("SMTP_PORT", "587"),
+		username: os.Getenv("SMTP_USERNAME"),
+		password: os.Getenv("SMTP_PASSWORD"),
+		security: security,
+	}, nil
+}
+
+// connect opens an authenticated connection to the SMTP server
+func (t *smtpTransport) connect() (*smtp.Client, error) {
+	serverAddr := net.JoinHostPort(t.host, t.port)
+	tlsConfig := &tls.Config{ServerName: t.host}
+
+	var conn net.Conn
+	var err error
+	if t.security == SMTPImplicitTLS {
+		conn, err = tls.DialWithDialer(&net.Dialer{Timeout: smtpTimeout}, "tcp", serverAddr, tlsConfig)
+	} else {
+		conn, err = net.DialTimeout("tcp", serverAddr, smtpTimeout)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("unable to connect to SMTP server (%s): %w", serverAddr, err)
+	}
+
+	client, err := smtp.NewClient(conn, t.host)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("unable to start SMTP session with %s: %w", serverAddr, err)
+	}
+
+	if t.security == SMTPStartTLS {
+		if err := client.StartTLS(tlsConfig); err != nil {
+			client.Close()
+			return nil, fmt.Errorf("unable to start TLS: %w", err)
+		}
+	}
+
+	// Relays that don't need authentication are used without a username
+	if t.username != "" {
+		auth := smtp.PlainAuth("", t.username, t.password, t.host)
+		if err := client.Auth(auth); err != nil {
+			client.Close()
+			return nil, fmt.Errorf("SMTP authentication failed: %w", err)
+		}
+	}
+
+	return client, nil
+}
+
+func (t *smtpTransport) Send(from string, to []string, msg []byte) error {
+	client, err := t.connect()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	// Set the sender and recipients
+	if err := client.Mail(from); err != nil {
+		return fmt.Errorf("unable to set the sender (%s) for SMTP: %w", from, err)
+	}
+
+	for _, recipient := range to {
+		if err := client.Rcpt(recipient); err != nil {
+			return fmt.Errorf("unable to set the recipient (%s) for SMTP: %w", recipient, err)
+		}
+	}
+
+	// Send the email body
+	wc, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("unable to open data connection with SMTP: %w", err)
+	}
+	if _, err := wc.Write(msg); err != nil {
+		wc.Close()
+		return fmt.Errorf("unable to write the email to SMTP: %w", err)
+	}
+	if err := wc.Close(); err != nil {
+		return fmt.Errorf("unable to finish the email with SMTP: %w", err)
+	}
+
+	return client.Quit()
+}
+
+func (t *smtpTransport) Test() error {
+	client, err := t.connect()
+	if err != nil {
+		return err
+	}
+
+	return client.Quit()
+}
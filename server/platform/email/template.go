@@ -0,0 +1,285 @@
+package email
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"os"
+	"strings"
+	texttemplate "text/template"
+	"time"
+
+	"github.com/austinbspencer/gshare-server/internal/models"
+	"github.com/austinbspencer/gshare-server/internal/queries"
+	"github.com/austinbspencer/gshare-server/pkg/configs"
+	"github.com/gofiber/fiber/v2/log"
+	"gorm.io/gorm"
+)
+
+// shippedFiles holds the HTML and plain text of the shipped templates, so
+// they are found wherever the server is started from
+//
+//go:embed templates/*
+var shippedFiles embed.FS
+
+// shippedTemplate is the template of an email that ships with gshare, the
+// HTML and plain text are read from the embedded templates directory
+type shippedTemplate struct {
+	subject  string
+	htmlPath string
+	textPath string
+}
+
+var shippedTemplates = map[models.EmailKind]shippedTemplate{
+	models.EmailTwoFactor: {
+		subject:  "Two-Factor Authentication Code",
+		htmlPath: "templates/twofactor.html",
+		textPath: "templates/twofactor.txt",
+	},
+	models.EmailReminder: {
+		subject:  "Friendly Reminder: Gallery Expiring Soon",
+		htmlPath: "templates/reminder.html",
+		textPath: "templates/reminder.txt",
+	},
+	models.EmailLive: {
+		subject:  "Your Photos Are Live: {{ .GalleryTitle }}",
+		htmlPath: "templates/live.html",
+		textPath: "templates/live.txt",
+	},
+	models.EmailAlert: {
+		subject:  "{{ .Subject }}",
+		htmlPath: "templates/alert.html",
+		textPath: "templates/alert.txt",
+	},
+}
+
+// Variables of the templates of each email
+type twoFactorVars struct {
+	Code string
+}
+
+type reminderVars struct {
+	ExpirationDate   string
+	GalleryLink      string
+	PhotographerName string
+}
+
+type liveVars struct {
+	GalleryTitle     string
+	GalleryLink      string
+	PasswordHint     string
+	ExpirationDate   string
+	PhotographerName string
+}
+
+type alertVars struct {
+	Subject string
+	Message string
+}
+
+// ShippedEmailTemplate returns the template of the email that ships with gshare
+func ShippedEmailTemplate(kind models.EmailKind) (*models.EmailTemplate, error) {
+	shipped, ok := shippedTemplates[kind]
+	if !ok {
+		return nil, fmt.Errorf("unknown email %q", kind)
+	}
+
+	html, err := shippedFiles.ReadFile(shipped.htmlPath)
+	if err != nil {
+		return nil, err
+	}
+
+	text, err := shippedFiles.ReadFile(shipped.textPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.EmailTemplate{
+		Kind:    kind,
+		Subject: shipped.subject,
+		HTML:    string(html),
+		Text:    string(text),
+	}, nil
+}
+
+// GetEmailTemplate returns the changed template of the email, or the
+// shipped template when it wasn't changed
+func GetEmailTemplate(kind models.EmailKind) (*models.EmailTemplate, error) {
+	templateQueries := queries.NewEmailTemplateRepository()
+
+	emailTemplate, err := templateQueries.GetEmailTemplate(kind)
+	if err == nil {
+		return emailTemplate, nil
+	}
+
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		// Still send the email when the changed template can't be read
+		log.Errorf("Unable to get the %s email template from DB: %v\n", kind, err)
+	}
+
+	return ShippedEmailTemplate(kind)
+}
+
+// RenderEmailTemplate renders the subject, HTML and plain text of the
+// template with the variables. The variables are escaped in the HTML.
+func RenderEmailTemplate(emailTemplate *models.EmailTemplate, vars any) (*models.RenderedEmail, error) {
+	var subject, html, text bytes.Buffer
+
+	subjectTemplate, err := texttemplate.New("subject").Parse(emailTemplate.Subject)
+	if err != nil {
+		return nil, fmt.Errorf("subject: %w", err)
+	}
+	if err := subjectTemplate.Execute(&subject, vars); err != nil {
+		return nil, fmt.Errorf("subject: %w", err)
+	}
+
+	htmlTemplate, err := htmltemplate.New("html").Parse(emailTemplate.HTML)
+	if err != nil {
+		return nil, fmt.Errorf("html: %w", err)
+	}
+	if err := htmlTemplate.Execute(&html, vars); err != nil {
+		return nil, fmt.Errorf("html: %w", err)
+	}
+
+	textTemplate, err := texttemplate.New("text").Parse(emailTemplate.Text)
+	if err != nil {
+		return nil, fmt.Errorf("text: %w", err)
+	}
+	if err := textTemplate.Execute(&text, vars); err != nil {
+		return nil, fmt.Errorf("text: %w", err)
+	}
+
+	return &models.RenderedEmail{
+		// Keep the subject on a single line so it can't add headers
+		Subject: strings.Join(strings.Fields(subject.String()), " "),
+		HTML:    html.String(),
+		Text:    text.String(),
+	}, nil
+}
+
+// SampleEmailVars returns example variables of the email to preview and
+// check its template
+func SampleEmailVars(kind models.EmailKind) any {
+	photographerName := configs.Getenv("NEXT_PUBLIC_PHOTOGRAPHER_NAME", "Your Photographer")
+	galleryLink := fmt.Sprintf("%s/%s", os.Getenv("NEXT_PUBLIC_CLIENT_URL"), "sample-gallery")
+	expiration := time.Now().AddDate(0, 1, 0).Format("January 2, 2006")
+
+	switch kind {
+	case models.EmailTwoFactor:
+		return twoFactorVars{Code: "123456"}
+	case models.EmailReminder:
+		return reminderVars{
+			ExpirationDate:   expiration,
+			GalleryLink:      galleryLink,
+			PhotographerName: photographerName,
+		}
+	case models.EmailLive:
+		return liveVars{
+			GalleryTitle:     "Sample Gallery",
+			GalleryLink:      galleryLink,
+			PasswordHint:     "The name of the venue",
+			ExpirationDate:   expiration,
+			PhotographerName: photographerName,
+		}
+	case models.EmailAlert:
+		return alertVars{
+			Subject: "gshare automated alert",
+			Message: "Client container restarted to make gallery 1 live",
+		}
+	default:
+		return nil
+	}
+}
+
+// queueTemplateEmail renders the template of the email and queues it in
+// the outbox. When a changed template fails to render the shipped template
+// is used instead so the email still goes out.
+func queueTemplateEmail(kind models.EmailKind, to string, vars any) (*models.OutboxEmail, error) {
+	emailTemplate, err := GetEmailTemplate(kind)
+	if err != nil {
+		return nil, err
+	}
+
+	rendered, err := RenderEmailTemplate(emailTemplate, vars)
+	if err != nil && emailTemplate.Custom {
+		log.Errorf("Unable to render the changed %s email template, using the shipped template: %v\n", kind, err)
+
+		if emailTemplate, err = ShippedEmailTemplate(kind); err != nil {
+			return nil, err
+		}
+		rendered, err = RenderEmailTemplate(emailTemplate, vars)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	msg, err := buildMessage(to, rendered)
+	if err != nil {
+		return nil, err
+	}
+
+	return QueueEmail(kind, to, rendered.Subject, msg)
+}
+
+// buildMessage builds a multipart email with the plain text and HTML of
+// the rendered email
+func buildMessage(to string, rendered *models.RenderedEmail) ([]byte, error) {
+	from := configs.Getenv("SMTP_FROM", os.Getenv("SMTP_USERNAME"))
+	// Encode the name of the sender when it isn't plain ASCII
+	if address, err := mail.ParseAddress(from); err == nil {
+		from = address.String()
+	}
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	parts := []struct {
+		contentType string
+		content     string
+	}{
+		// Clients show the last part they support, so HTML goes last
+		{"text/plain; charset=UTF-8", rendered.Text},
+		{"text/html; charset=UTF-8", rendered.HTML},
+	}
+
+	for _, p := range parts {
+		part, err := writer.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {p.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		qp := quotedprintable.NewWriter(part)
+		if _, err := qp.Write([]byte(p.content)); err != nil {
+			return nil, err
+		}
+		if err := qp.Close(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	var msg bytes.Buffer
+	msg.WriteString("From: " + from + "\r\n")
+	msg.WriteString("To: " + strings.Join(strings.Fields(to), ", ") + "\r\n")
+	msg.WriteString("Subject: " + mime.QEncoding.Encode("UTF-8", rendered.Subject) + "\r\n")
+	msg.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: multipart/alternative; boundary=\"" + writer.Boundary() + "\"\r\n")
+	msg.WriteString("\r\n")
+	msg.Write(body.Bytes())
+
+	return msg.Bytes(), nil
+}
@@ -0,0 +1,139 @@
+package email_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/austinbspencer/gshare-server/internal/models"
+	"github.com/austinbspencer/gshare-server/internal/queries"
+	"github.com/austinbspencer/gshare-server/platform/database"
+	"github.com/austinbspencer/gshare-server/platform/email"
+)
+
+// A variable of each email with the value it has in the sample variables
+var sampleVars = map[models.EmailKind]struct{ field, value string }{
+	models.EmailTwoFactor: {"Code", "123456"},
+	models.EmailReminder:  {"GalleryLink", "/sample-gallery"},
+	models.EmailLive:      {"GalleryTitle", "Sample Gallery"},
+	models.EmailAlert:     {"Message", "Client container restarted to make gallery 1 live"},
+}
+
+// connectTestDB connects a sqlite database in a temporary directory
+func connectTestDB(t *testing.T) {
+	t.Helper()
+
+	t.Setenv("DB_DRIVER", "sqlite")
+	t.Setenv("DB_PATH", t.TempDir())
+	database.Connect()
+}
+
+func TestShippedEmailTemplates(t *testing.T) {
+	connectTestDB(t)
+
+	for _, kind := range models.EmailKinds {
+		t.Run(string(kind), func(t *testing.T) {
+			emailTemplate, err := email.GetEmailTemplate(kind)
+			if err != nil {
+				t.Fatalf("GetEmailTemplate() returned an error: %v", err)
+			}
+			if emailTemplate.Custom {
+				t.Errorf("GetEmailTemplate() returned a changed template, want the shipped template")
+			}
+
+			rendered, err := email.RenderEmailTemplate(emailTemplate, email.SampleEmailVars(kind))
+			if err != nil {
+				t.Fatalf("RenderEmailTemplate() returned an error: %v", err)
+			}
+
+			value := sampleVars[kind].value
+			if rendered.Subject == "" || strings.Contains(rendered.Subject, "{{") {
+				t.Errorf("Subject = %q, want the rendered subject", rendered.Subject)
+			}
+			if !strings.Contains(rendered.HTML, value) {
+				t.Errorf("HTML doesn't contain %q", value)
+			}
+			if !strings.Contains(rendered.Text, value) {
+				t.Errorf("Text doesn't contain %q", value)
+			}
+			if strings.Contains(rendered.Text, "<") {
+				t.Errorf("Text contains HTML: %q", rendered.Text)
+			}
+		})
+	}
+}
+
+func TestCustomEmailTemplates(t *testing.T) {
+	connectTestDB(t)
+	templateQueries := queries.NewEmailTemplateRepository()
+
+	for _, kind := range models.EmailKinds {
+		t.Run(string(kind), func(t *testing.T) {
+			field, value := sampleVars[kind].field, sampleVars[kind].value
+
+			err := templateQueries.SaveEmailTemplate(&models.EmailTemplate{
+				Kind:    kind,
+				Subject: "Custom {{ ." + field + " }}",
+				HTML:    "<p>{{ ." + field + " }}</p>",
+				Text:    "Custom text {{ ." + field + " }}",
+			})
+			if err != nil {
+				t.Fatalf("SaveEmailTemplate() returned an error: %v", err)
+			}
+
+			emailTemplate, err := email.GetEmailTemplate(kind)
+			if err != nil {
+				t.Fatalf("GetEmailTemplate() returned an error: %v", err)
+			}
+			if !emailTemplate.Custom {
+				t.Errorf("GetEmailTemplate() returned the shipped template, want the changed template")
+			}
+
+			rendered, err := email.RenderEmailTemplate(emailTemplate, email.SampleEmailVars(kind))
+			if err != nil {
+				t.Fatalf("RenderEmailTemplate() returned an error: %v", err)
+			}
+
+			if want := "Custom " + value; rendered.Subject != want {
+				t.Errorf("Subject = %q, want: %q", rendered.Subject, want)
+			}
+			if want := "<p>" + value + "</p>"; rendered.HTML != want {
+				t.Errorf("HTML = %q, want: %q", rendered.HTML, want)
+			}
+			if want := "Custom text " + value; rendered.Text != want {
+				t.Errorf("Text = %q, want: %q", rendered.Text, want)
+			}
+
+			// Deleting the changed template goes back to the shipped template
+			if err := templateQueries.DeleteEmailTemplate(kind); err != nil {
+				t.Fatalf("DeleteEmailTemplate() returned an error: %v", err)
+			}
+			if emailTemplate, err = email.GetEmailTemplate(kind); err != nil || emailTemplate.Custom {
+				t.Errorf("GetEmailTemplate() = %v, %v, want the shipped template", emailTemplate, err)
+			}
+		})
+	}
+}
+
+func TestRenderEmailTemplateEscaping(t *testing.T) {
+	emailTemplate := &models.EmailTemplate{
+		Subject: "Live: {{ .Title }}",
+		HTML:    "<p>{{ .Title }}</p>",
+		Text:    "{{ .Title }}",
+	}
+	vars := struct{ Title string }{"<b>Tom & Jerry</b>\nBcc: a@example.com"}
+
+	rendered, err := email.RenderEmailTemplate(emailTemplate, vars)
+	if err != nil {
+		t.Fatalf("RenderEmailTemplate() returned an error: %v", err)
+	}
+
+	if want := "Live: <b>Tom & Jerry</b> Bcc: a@example.com"; rendered.Subject != want {
+		t.Errorf("Subject = %q, want: %q", rendered.Subject, want)
+	}
+	if want := "<p>&lt;b&gt;Tom &amp; Jerry&lt;/b&gt;\nBcc: a@example.com</p>"; rendered.HTML != want {
+		t.Errorf("HTML = %q, want: %q", rendered.HTML, want)
+	}
+	if rendered.Text != vars.Title {
+		t.Errorf("Text = %q, want: %q", rendered.Text, vars.Title)
+	}
+}
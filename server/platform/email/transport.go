@@ -0,0 +1,89 @@
+package email
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/austinbspencer/gshare-server/pkg/configs"
+	"github.com/gofiber/fiber/v2/log"
+)
+
+// Transport delivers the emails, it is chosen with MAIL_TRANSPORT
+type Transport interface {
+	// Send delivers the message to the recipients
+	Send(from string, to []string, msg []byte) error
+	// Test checks that emails can be delivered
+	Test() error
+}
+
+var (
+	SMTPTransport     = "smtp"
+	SendmailTransport = "sendmail"
+	FileTransport     = "file"
+	LogTransport      = "log"
+)
+
+// NewTransport returns the transport set by MAIL_TRANSPORT, smtp by default
+func NewTransport() (Transport, error) {
+	switch transport := configs.Getenv("MAIL_TRANSPORT", SMTPTransport); transport {
+	case SMTPTransport:
+		return newSMTPTransport()
+	case SendmailTransport:
+		return &sendmailTransport{
+			path: configs.Getenv("SENDMAIL_PATH", "/usr/sbin/sendmail"),
+		}, nil
+	case FileTransport:
+		return &fileTransport{
+			directory: configs.Getenv("MAIL_DIRECTORY", "/data/mail"),
+		}, nil
+	case LogTransport:
+		return &logTransport{}, nil
+	default:
+		return nil, fmt.Errorf("unknown mail transport %q, use smtp, sendmail, file or log", transport)
+	}
+}
+
+// fileTransport writes every email to a .eml file instead of sending it, to
+// develop without a mail server
+type fileTransport struct {
+	directory string
+}
+
+// fileCount keeps the names of emails written at the same time unique
+var fileCount atomic.Uint64
+
+func (t *fileTransport) Send(from string, to []string, msg []byte) error {
+	if err := os.MkdirAll(t.directory, 0755); err != nil {
+		return err
+	}
+
+	filename := fmt.Sprintf("%s-%d.eml", time.Now().Format("20060102-150405.000000"), fileCount.Add(1))
+	path := filepath.Join(t.directory, filename)
+	if err := os.WriteFile(path, msg, 0644); err != nil {
+		return err
+	}
+
+	log.Infof("Email to %s written to %s\n", strings.Join(to, " "), path)
+
+	return nil
+}
+
+func (t *fileTransport) Test() error {
+	return os.MkdirAll(t.directory, 0755)
+}
+
+// logTransport writes every email to the logs instead of sending it
+type logTransport struct{}
+
+func (t *logTransport) Send(from string, to []string, msg []byte) error {
+	log.Infof("Email from %s to %s:\n%s\n", from, strings.Join(to, " "), msg)
+	return nil
+}
+
+func (t *logTransport) Test() error {
+	return nil
+}
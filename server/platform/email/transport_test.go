@@ -0,0 +1,348 @@
+package email
+
+import (
+	"bufio"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/austinbspencer/gshare-server/internal/models"
+)
+
+// unsetenv unsets the variable for the test, restoring it afterwards
+func unsetenv(t *testing.T, key string) {
+	t.Helper()
+
+	t.Setenv(key, "")
+	os.Unsetenv(key)
+}
+
+func TestNewTransport(t *testing.T) {
+	t.Setenv("SENDMAIL_PATH", "/bin/test-sendmail")
+	t.Setenv("MAIL_DIRECTORY", "/tmp/test-mail")
+
+	tests := []struct {
+		name      string
+		transport string
+		check     func(Transport) bool
+	}{
+		{"smtp", SMTPTransport, func(tr Transport) bool { _, ok := tr.(*smtpTransport); return ok }},
+		{"sendmail", SendmailTransport, func(tr Transport) bool {
+			sendmail, ok := tr.(*sendmailTransport)
+			return ok && sendmail.path == "/bin/test-sendmail"
+		}},
+		{"file", FileTransport, func(tr Transport) bool {
+			file, ok := tr.(*fileTransport)
+			return ok && file.directory == "/tmp/test-mail"
+		}},
+		{"log", LogTransport, func(tr Transport) bool { _, ok := tr.(*logTransport); return ok }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("MAIL_TRANSPORT", tt.transport)
+
+			transport, err := NewTransport()
+			if err != nil {
+				t.Fatalf("NewTransport() returned an error: %v", err)
+			}
+			if !tt.check(transport) {
+				t.Errorf("NewTransport() = %#v, want the %s transport", transport, tt.transport)
+			}
+		})
+	}
+
+	t.Run("smtp by default", func(t *testing.T) {
+		unsetenv(t, "MAIL_TRANSPORT")
+
+		transport, err := NewTransport()
+		if _, ok := transport.(*smtpTransport); err != nil || !ok {
+			t.Errorf("NewTransport() = %#v, %v, want the smtp transport", transport, err)
+		}
+	})
+
+	t.Run("unknown transport", func(t *testing.T) {
+		t.Setenv("MAIL_TRANSPORT", "pigeon")
+
+		if _, err := NewTransport(); err == nil {
+			t.Errorf("NewTransport() returned no error for an unknown transport")
+		}
+	})
+}
+
+func TestNewSMTPTransportSecurity(t *testing.T) {
+	tests := []struct {
+		name     string
+		tls      *string
+		security *string
+		want     string
+		wantErr  bool
+	}{
+		{name: "starttls by default", want: SMTPStartTLS},
+		{name: "starttls with SMTP_TLS", tls: ptr("true"), want: SMTPStartTLS},
+		{name: "none without SMTP_TLS", tls: ptr("false"), want: SMTPNoTLS},
+		{name: "implicit tls", security: ptr("tls"), want: SMTPImplicitTLS},
+		{name: "SMTP_SECURITY over SMTP_TLS", tls: ptr("false"), security: ptr("starttls"), want: SMTPStartTLS},
+		{name: "none over SMTP_TLS", tls: ptr("true"), security: ptr("none"), want: SMTPNoTLS},
+		{name: "unknown security", security: ptr("ssl"), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unsetenv(t, "SMTP_TLS")
+			unsetenv(t, "SMTP_SECURITY")
+			if tt.tls != nil {
+				t.Setenv("SMTP_TLS", *tt.tls)
+			}
+			if tt.security != nil {
+				t.Setenv("SMTP_SECURITY", *tt.security)
+			}
+
+			transport, err := newSMTPTransport()
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("newSMTPTransport() returned no error, want one")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("newSMTPTransport() returned an error: %v", err)
+			}
+			if transport.security != tt.want {
+				t.Errorf("security = %s, want: %s", transport.security, tt.want)
+			}
+		})
+	}
+}
+
+func ptr(value string) *string {
+	return &value
+}
+
+// fakeSMTPServer accepts a single plain SMTP session without STARTTLS and
+// sends the data of the email it receives on the channel
+func fakeSMTPServer(t *testing.T) (string, string, <-chan string) {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Unable to listen: %v", err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	received := make(chan string, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		reader := bufio.NewReader(conn)
+		reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+
+		reply("220 localhost ESMTP")
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+
+			switch command := strings.ToUpper(strings.Fields(line + " x")[0]); command {
+			case "EHLO", "HELO":
+				reply("250 localhost")
+			case "MAIL", "RCPT":
+				reply("250 OK")
+			case "DATA":
+				reply("354 End data with <CR><LF>.<CR><LF>")
+				var data strings.Builder
+				for {
+					dataLine, err := reader.ReadString('\n')
+					if err != nil || dataLine == ".\r\n" {
+						break
+					}
+					data.WriteString(dataLine)
+				}
+				received <- data.String()
+				reply("250 OK")
+			case "QUIT":
+				reply("221 Bye")
+				return
+			default:
+				reply("502 Command not implemented")
+			}
+		}
+	}()
+
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	return host, port, received
+}
+
+func TestSMTPTransportSecurity(t *testing.T) {
+	msg := []byte("Subject: Test\r\n\r\nHello\r\n")
+
+	t.Run("none sends over the plain connection", func(t *testing.T) {
+		host, port, received := fakeSMTPServer(t)
+		transport := &smtpTransport{host: host, port: port, security: SMTPNoTLS}
+
+		if err := transport.Send("from@example.com", []string{"to@example.com"}, msg); err != nil {
+			t.Fatalf("Send() returned an error: %v", err)
+		}
+		if data := <-received; data != string(msg) {
+			t.Errorf("Send() sent %q, want: %q", data, msg)
+		}
+	})
+
+	tests := []struct {
+		name     string
+		security string
+		err      string
+	}{
+		{"starttls requires STARTTLS", SMTPStartTLS, "unable to start TLS"},
+		{"tls requires a TLS connection", SMTPImplicitTLS, "unable to connect to SMTP server"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			host, port, _ := fakeSMTPServer(t)
+			transport := &smtpTransport{host: host, port: port, security: tt.security}
+
+			err := transport.Send("from@example.com", []string{"to@example.com"}, msg)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("Send() = %v, want an error containing %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestSendmailTransport(t *testing.T) {
+	dir := t.TempDir()
+	script := filepath.Join(dir, "sendmail")
+	err := os.WriteFile(script, []byte("#!/bin/sh\necho \"$@\" > \"$(dirname \"$0\")/args\"\ncat > \"$(dirname \"$0\")/stdin\"\n"), 0755)
+	if err != nil {
+		t.Fatalf("Unable to write the sendmail script: %v", err)
+	}
+
+	transport := &sendmailTransport{path: script}
+	if err := transport.Test(); err != nil {
+		t.Errorf("Test() returned an error: %v", err)
+	}
+
+	msg := []byte("Subject: Test\r\n\r\nHello\r\n")
+	if err := transport.Send("from@example.com", []string{"a@example.com", "b@example.com"}, msg); err != nil {
+		t.Fatalf("Send() returned an error: %v", err)
+	}
+
+	args, _ := os.ReadFile(filepath.Join(dir, "args"))
+	if want := "-i -f from@example.com -- a@example.com b@example.com\n"; string(args) != want {
+		t.Errorf("sendmail args = %q, want: %q", args, want)
+	}
+	stdin, _ := os.ReadFile(filepath.Join(dir, "stdin"))
+	if string(stdin) != string(msg) {
+		t.Errorf("sendmail stdin = %q, want: %q", stdin, msg)
+	}
+
+	failing := filepath.Join(dir, "failing")
+	if err := os.WriteFile(failing, []byte("#!/bin/sh\necho 'no such user' >&2\nexit 67\n"), 0755); err != nil {
+		t.Fatalf("Unable to write the sendmail script: %v", err)
+	}
+	transport = &sendmailTransport{path: failing}
+	if err := transport.Send("", []string{"a@example.com"}, msg); err == nil || !strings.Contains(err.Error(), "no such user") {
+		t.Errorf("Send() = %v, want an error with the output of sendmail", err)
+	}
+
+	transport = &sendmailTransport{path: filepath.Join(dir, "missing")}
+	if err := transport.Test(); err == nil {
+		t.Errorf("Test() returned no error for a missing sendmail")
+	}
+}
+
+func TestFileTransportMessage(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("MAIL_TRANSPORT", FileTransport)
+	t.Setenv("MAIL_DIRECTORY", dir)
+	t.Setenv("SMTP_FROM", "Ünsal Photography <photos@example.com>")
+
+	rendered := &models.RenderedEmail{
+		Subject: "Your Photos Are Live: Café",
+		HTML:    "<p>Your gallery is <a href=\"https://example.com/g\">live</a></p>",
+		Text:    "Your gallery is live: https://example.com/g",
+	}
+
+	msg, err := buildMessage("a@example.com b@example.com", rendered)
+	if err != nil {
+		t.Fatalf("buildMessage() returned an error: %v", err)
+	}
+	if err := SendEmail("a@example.com b@example.com", msg); err != nil {
+		t.Fatalf("SendEmail() returned an error: %v", err)
+	}
+
+	files, _ := filepath.Glob(filepath.Join(dir, "*.eml"))
+	if len(files) != 1 {
+		t.Fatalf("SendEmail() wrote %d files, want: 1", len(files))
+	}
+	file, err := os.Open(files[0])
+	if err != nil {
+		t.Fatalf("Unable to open the email: %v", err)
+	}
+	defer file.Close()
+
+	parsed, err := mail.ReadMessage(file)
+	if err != nil {
+		t.Fatalf("Unable to parse the email: %v", err)
+	}
+
+	decoder := new(mime.WordDecoder)
+	subject, _ := decoder.DecodeHeader(parsed.Header.Get("Subject"))
+	from, _ := parsed.Header.AddressList("From")
+	to, _ := parsed.Header.AddressList("To")
+	if subject != rendered.Subject {
+		t.Errorf("Subject = %q, want: %q", subject, rendered.Subject)
+	}
+	if len(from) != 1 || from[0].Name != "Ünsal Photography" || from[0].Address != "photos@example.com" {
+		t.Errorf("From = %v, want: Ünsal Photography <photos@example.com>", from)
+	}
+	if len(to) != 2 || to[0].Address != "a@example.com" || to[1].Address != "b@example.com" {
+		t.Errorf("To = %v, want: a@example.com, b@example.com", to)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(parsed.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type = %s, %v, want: multipart/alternative", mediaType, err)
+	}
+
+	want := []struct{ contentType, content string }{
+		{"text/plain; charset=UTF-8", rendered.Text},
+		{"text/html; charset=UTF-8", rendered.HTML},
+	}
+
+	reader := multipart.NewReader(parsed.Body, params["boundary"])
+	for _, w := range want {
+		part, err := reader.NextRawPart()
+		if err != nil {
+			t.Fatalf("Missing the %s part: %v", w.contentType, err)
+		}
+		if contentType := part.Header.Get("Content-Type"); contentType != w.contentType {
+			t.Errorf("Content-Type = %s, want: %s", contentType, w.contentType)
+		}
+
+		content, err := io.ReadAll(quotedprintable.NewReader(part))
+		if err != nil {
+			t.Fatalf("Unable to decode the %s part: %v", w.contentType, err)
+		}
+		if string(content) != w.content {
+			t.Errorf("%s part = %q, want: %q", w.contentType, content, w.content)
+		}
+	}
+
+	if _, err := reader.NextRawPart(); err != io.EOF {
+		t.Errorf("The email has more than the plain text and HTML parts")
+	}
+}
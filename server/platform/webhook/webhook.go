@@ -0,0 +1,347 @@
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/austinbspencer/gshare-server/internal/models"
+	"github.com/austinbspencer/gshare-server/internal/queries"
+	"github.com/austinbspencer/gshare-server/pkg/configs"
+	"github.com/austinbspencer/gshare-server/pkg/utils"
+	"github.com/gofiber/fiber/v2/log"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultMaxAttempts = 8
+	// Seconds before the first retry, doubled for every retry after it
+	defaultRetryDelay = 30
+	maxRetryDelay     = time.Hour
+
+	// How often the deliveries are checked for ones that are due
+	deliveryInterval = 30 * time.Second
+	// Deliveries posted by a single check
+	deliveryBatchSize = 50
+	// How long a webhook has to answer
+	deliveryTimeout = 10 * time.Second
+	// Most of the response body kept in the error of a failed delivery
+	maxErrorBody = 512
+)
+
+// errWebhookDeleted is the error of the deliveries left by a deleted webhook
+var errWebhookDeleted = errors.New("the webhook was deleted")
+
+// wakeDeliveries has the new deliveries posted right away instead of
+// waiting for the next check
+var wakeDeliveries = make(chan struct{}, 1)
+
+var client = &http.Client{Timeout: deliveryTimeout}
+
+// The enabled webhooks are kept in memory so firing an event doesn't read
+// them from DB on every request, they are loaded again once invalidated
+var (
+	enabledMutex    sync.Mutex
+	enabledWebhooks []models.Webhook
+	enabledLoaded   bool
+)
+
+// getEnabledWebhooks returns the enabled webhooks, reading them from DB
+// when they aren't in memory
+func getEnabledWebhooks() ([]models.Webhook, error) {
+	enabledMutex.Lock()
+	defer enabledMutex.Unlock()
+
+	if enabledLoaded {
+		return enabledWebhooks, nil
+	}
+
+	webhookQueries := queries.NewWebhookRepository()
+
+	webhooks, err := webhookQueries.GetEnabledWebhooks()
+	if err != nil {
+		return nil, err
+	}
+
+	enabledWebhooks = webhooks
+	enabledLoaded = true
+
+	return enabledWebhooks, nil
+}
+
+// InvalidateWebhooks drops the enabled webhooks kept in memory, it must be
+// called whenever a webhook is created, changed or deleted
+func InvalidateWebhooks() {
+	enabledMutex.Lock()
+	defer enabledMutex.Unlock()
+
+	enabledWebhooks = nil
+	enabledLoaded = false
+}
+
+// RunDeliveries starts posting the webhook deliveries in the background
+func RunDeliveries() {
+	go func() {
+		ticker := time.NewTicker(deliveryInterval)
+		defer ticker.Stop()
+
+		for {
+			postDueDeliveries()
+
+			select {
+			case <-ticker.C:
+			case <-wakeDeliveries:
+			}
+		}
+	}()
+}
+
+// Fire queues the event for every enabled webhook subscribed to it. Errors
+// are only logged so the event never fails the request that fired it.
+func Fire(event models.WebhookEvent, data any) {
+	webhooks, err := getEnabledWebhooks()
+	if err != nil {
+		log.Errorf("Unable to get the webhooks for %s: %v\n", event, err)
+		return
+	}
+
+	for idx := range webhooks {
+		if !webhooks[idx].Subscribed(event) {
+			continue
+		}
+
+		if _, err := queueDelivery(&webhooks[idx], event, data); err != nil {
+			log.Errorf("Unable to queue %s for webhook %d: %v\n", event, webhooks[idx].ID, err)
+		}
+	}
+}
+
+// Subscribed checks if an enabled webhook is subscribed to the event, for
+// requests that can't be served from the cache while one is
+func Subscribed(event models.WebhookEvent) bool {
+	webhooks, err := getEnabledWebhooks()
+	if err != nil {
+		log.Errorf("Unable to get the webhooks for %s: %v\n", event, err)
+		return false
+	}
+
+	for idx := range webhooks {
+		if webhooks[idx].Subscribed(event) {
+			return true
+		}
+	}
+	return false
+}
+
+// Ping queues a ping to the webhook to test it
+func Ping(webhook *models.Webhook) (*models.WebhookDelivery, error) {
+	return queueDelivery(webhook, models.WebhookPing, map[string]any{
+		"webhook_id": webhook.ID,
+	})
+}
+
+// Redeliver queues a copy of the delivery, the delivery itself is kept as
+// it was in the delivery log
+func Redeliver(delivery *models.WebhookDelivery) (*models.WebhookDelivery, error) {
+	redelivery := &models.WebhookDelivery{
+		WebhookID:     delivery.WebhookID,
+		Event:         delivery.Event,
+		Payload:       delivery.Payload,
+		Status:        models.OutboxPending,
+		NextAttemptAt: time.Now(),
+	}
+
+	return redelivery, createDelivery(redelivery)
+}
+
+// Sign returns the signature of the payload sent at the timestamp
+func Sign(secret, timestamp string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(payload)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// retryDelay returns how long to wait before the next attempt after the
+// given number of attempts failed
+func retryDelay(attempts int) time.Duration {
+	base := time.Duration(configs.GetenvInt("WEBHOOK_RETRY_DELAY", defaultRetryDelay)) * time.Second
+	return utils.RetryDelay(base, maxRetryDelay, attempts)
+}
+
+func queueDelivery(webhook *models.Webhook, event models.WebhookEvent, data any) (*models.WebhookDelivery, error) {
+	payload, err := json.Marshal(models.WebhookPayload{
+		Event:     event,
+		CreatedAt: time.Now(),
+		Data:      data,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	delivery := &models.WebhookDelivery{
+		WebhookID:     webhook.ID,
+		Event:         event,
+		Payload:       string(payload),
+		Status:        models.OutboxPending,
+		NextAttemptAt: time.Now(),
+	}
+
+	return delivery, createDelivery(delivery)
+}
+
+func createDelivery(delivery *models.WebhookDelivery) error {
+	webhookQueries := queries.NewWebhookRepository()
+
+	if err := webhookQueries.CreateWebhookDelivery(delivery); err != nil {
+		return err
+	}
+
+	// Don't block when the deliveries were already woken up
+	select {
+	case wakeDeliveries <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// postDueDeliveries posts the pending deliveries that are due. Each webhook
+// is posted its deliveries in order, at the same time as the other
+// webhooks so a slow webhook doesn't hold back the others.
+func postDueDeliveries() {
+	webhookQueries := queries.NewWebhookRepository()
+
+	deliveries, err := webhookQueries.GetDueWebhookDeliveries(time.Now(), deliveryBatchSize)
+	if err != nil {
+		log.Errorf("Error getting the webhook deliveries that are due: %v\n", err)
+		return
+	}
+
+	var webhookIDs []uint
+	webhookDeliveries := map[uint][]*models.WebhookDelivery{}
+	for idx := range deliveries {
+		webhookID := deliveries[idx].WebhookID
+		if _, ok := webhookDeliveries[webhookID]; !ok {
+			webhookIDs = append(webhookIDs, webhookID)
+		}
+		webhookDeliveries[webhookID] = append(webhookDeliveries[webhookID], &deliveries[idx])
+	}
+
+	var wg sync.WaitGroup
+	for _, webhookID := range webhookIDs {
+		webhook, err := webhookQueries.GetWebhookByID(fmt.Sprint(webhookID))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// The webhook was deleted after the deliveries were queued
+			for _, delivery := range webhookDeliveries[webhookID] {
+				failDelivery(delivery, errWebhookDeleted)
+			}
+			continue
+		}
+		if err != nil {
+			log.Errorf("Unable to get webhook %d of the due deliveries: %v\n", webhookID, err)
+			continue
+		}
+
+		wg.Add(1)
+		go func(webhook *models.Webhook, deliveries []*models.WebhookDelivery) {
+			defer wg.Done()
+
+			for _, delivery := range deliveries {
+				postDelivery(webhook, delivery)
+			}
+		}(webhook, webhookDeliveries[webhookID])
+	}
+
+	wg.Wait()
+}
+
+// failDelivery marks the delivery as failed without posting it
+func failDelivery(delivery *models.WebhookDelivery, err error) {
+	webhookQueries := queries.NewWebhookRepository()
+
+	log.Warnf("Not posting %s delivery %d to webhook %d: %v\n", delivery.Event, delivery.ID, delivery.WebhookID, err)
+
+	lastError := err.Error()
+	delivery.LastError = &lastError
+	delivery.Status = models.OutboxFailed
+
+	if err := webhookQueries.SaveWebhookDelivery(delivery); err != nil {
+		log.Errorf("Unable to save webhook delivery %d: %v\n", delivery.ID, err)
+	}
+}
+
+// postDelivery posts the delivery to the webhook, a failed delivery is
+// tried again later until it runs out of attempts
+func postDelivery(webhook *models.Webhook, delivery *models.WebhookDelivery) {
+	webhookQueries := queries.NewWebhookRepository()
+
+	delivery.Attempts++
+
+	status, err := post(webhook, delivery)
+	delivery.ResponseStatus = status
+
+	if err != nil {
+		lastError := err.Error()
+		delivery.LastError = &lastError
+
+		if delivery.Attempts >= configs.GetenvInt("WEBHOOK_MAX_ATTEMPTS", defaultMaxAttempts) {
+			log.Errorf("Giving up on %s delivery %d to webhook %d after %d attempts: %v\n", delivery.Event, delivery.ID, webhook.ID, delivery.Attempts, err)
+			delivery.Status = models.OutboxFailed
+		} else {
+			delivery.NextAttemptAt = time.Now().Add(retryDelay(delivery.Attempts))
+			log.Warnf("Retrying %s delivery %d to webhook %d at %s\n", delivery.Event, delivery.ID, webhook.ID, delivery.NextAttemptAt.Format(time.RFC3339))
+		}
+	} else {
+		deliveredAt := time.Now()
+		delivery.DeliveredAt = &deliveredAt
+		delivery.Status = models.OutboxSent
+	}
+
+	if err := webhookQueries.SaveWebhookDelivery(delivery); err != nil {
+		log.Errorf("Unable to save webhook delivery %d: %v\n", delivery.ID, err)
+	}
+}
+
+// post sends the signed payload to the webhook, any status other than 2xx
+// is an error
+func post(webhook *models.Webhook, delivery *models.WebhookDelivery) (*int, error) {
+	payload := []byte(delivery.Payload)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "gshare-webhook")
+	req.Header.Set("X-Gshare-Event", string(delivery.Event))
+	req.Header.Set("X-Gshare-Delivery", fmt.Sprint(delivery.ID))
+	req.Header.Set("X-Gshare-Timestamp", timestamp)
+	req.Header.Set("X-Gshare-Signature", Sign(webhook.Secret, timestamp, payload))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	status := resp.StatusCode
+	if status < 200 || status > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return &status, fmt.Errorf("webhook responded with %s: %s", resp.Status, bytes.TrimSpace(body))
+	}
+
+	return &status, nil
+}
@@ -0,0 +1,78 @@
+package webhook_test
+
+import (
+	"testing"
+
+	"github.com/austinbspencer/gshare-server/internal/models"
+	"github.com/austinbspencer/gshare-server/internal/queries"
+	"github.com/austinbspencer/gshare-server/platform/database"
+	"github.com/austinbspencer/gshare-server/platform/webhook"
+)
+
+func TestSign(t *testing.T) {
+	payload := []byte(`{"event":"webhook.ping"}`)
+	// HMAC-SHA256 of "1700000000." and the payload with "secret" as the key
+	want := "sha256=06554b4d77f16d93aa3cef39197793c7853dea6a9eef4a85f64ec45e23773a8e"
+
+	if signature := webhook.Sign("secret", "1700000000", payload); signature != want {
+		t.Errorf("Sign() = %s, want: %s", signature, want)
+	}
+
+	tests := []struct {
+		name      string
+		secret    string
+		timestamp string
+		payload   []byte
+	}{
+		{"other secret", "other-secret", "1700000000", payload},
+		{"other timestamp", "secret", "1700000001", payload},
+		{"other payload", "secret", "1700000000", []byte(`{"event":"gallery.live"}`)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if signature := webhook.Sign(tt.secret, tt.timestamp, tt.payload); signature == want {
+				t.Errorf("Sign() = %s, want a different signature", signature)
+			}
+		})
+	}
+}
+
+func TestInvalidateWebhooks(t *testing.T) {
+	t.Setenv("DB_DRIVER", "sqlite")
+	t.Setenv("DB_PATH", t.TempDir())
+	database.Connect()
+	webhook.InvalidateWebhooks()
+
+	webhookQueries := queries.NewWebhookRepository()
+
+	if webhook.Subscribed(models.WebhookDownloadGallery) {
+		t.Fatalf("Subscribed() = true without webhooks")
+	}
+
+	hook := &models.Webhook{URL: "https://example.com/hook", Events: "download.gallery", Secret: "secret", Enabled: true}
+	if err := webhookQueries.CreateWebhook(hook); err != nil {
+		t.Fatalf("CreateWebhook() returned an error: %v", err)
+	}
+
+	// The enabled webhooks are kept in memory until they are invalidated
+	if webhook.Subscribed(models.WebhookDownloadGallery) {
+		t.Errorf("Subscribed() = true before the webhooks were invalidated")
+	}
+	webhook.InvalidateWebhooks()
+	if !webhook.Subscribed(models.WebhookDownloadGallery) {
+		t.Errorf("Subscribed() = false for the new webhook")
+	}
+	if webhook.Subscribed(models.WebhookAuthLogin) {
+		t.Errorf("Subscribed() = true for an event the webhook isn't sent")
+	}
+
+	hook.Enabled = false
+	if err := webhookQueries.UpdateWebhook(hook); err != nil {
+		t.Fatalf("UpdateWebhook() returned an error: %v", err)
+	}
+	webhook.InvalidateWebhooks()
+	if webhook.Subscribed(models.WebhookDownloadGallery) {
+		t.Errorf("Subscribed() = true for a disabled webhook")
+	}
+}
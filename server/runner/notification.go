@@ -0,0 +1,92 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/austinbspencer/gshare-server/internal/models"
+	"github.com/austinbspencer/gshare-server/internal/queries"
+	"github.com/austinbspencer/gshare-server/platform/email"
+	"github.com/gofiber/fiber/v2/log"
+)
+
+// notifyGalleryLive emails the clients of the live gallery that their
+// photos are live. The notification is marked as sent before it is sent so
+// it is only sent once, and cleared when sending fails so it is sent on the
+// next check.
+func notifyGalleryLive(gallery *models.Gallery) {
+	if !gallery.LiveNotification || gallery.LiveNotifiedAt != nil {
+		return
+	}
+
+	contactQueries := queries.NewContactRepository()
+
+	contacts, err := contactQueries.GetGalleryContacts(gallery.ID)
+	if err != nil {
+		log.Errorf("Error getting the contacts of %s gallery: %v\n", gallery.Title, err)
+		return
+	}
+
+	recipients := models.ContactEmails(contacts)
+	if len(recipients) == 0 {
+		log.Warnf("Missing contacts to send the live notification to for %s gallery.\n", gallery.Title)
+		return
+	}
+
+	galleryQueries := queries.NewGalleryRepository()
+
+	marked, err := galleryQueries.MarkLiveNotified(gallery)
+	if err != nil {
+		log.Errorf("Unable to mark the live notification of %s gallery: %v\n", gallery.Title, err)
+		return
+	}
+	if !marked {
+		return
+	}
+
+	passwordHint := ""
+	if gallery.Protected && gallery.PasswordHint != nil {
+		passwordHint = *gallery.PasswordHint
+	}
+
+	clientBaseURL := os.Getenv("NEXT_PUBLIC_CLIENT_URL")
+	galleryLink := fmt.Sprintf("%s/%s", clientBaseURL, gallery.Path)
+	expiration := gallery.Expiration.Format("January 2, 2006")
+
+	// Each contact gets their own email so the contacts don't see each other
+	notificationQueries := queries.NewNotificationRepository()
+
+	queued := 0
+	for _, recipient := range recipients {
+		outboxEmail, err := email.SendLiveEmail(recipient, gallery.Title, galleryLink, passwordHint, expiration)
+		if err != nil {
+			log.Errorf("Unable to send live notification for %s gallery to %s: %v\n", gallery.Title, recipient, err)
+			continue
+		}
+		queued++
+
+		// The outbox updates the status once it tried to send the email
+		notification := &models.LiveNotification{
+			GalleryID:     gallery.ID,
+			Email:         recipient,
+			OutboxEmailID: &outboxEmail.ID,
+			Status:        models.OutboxPending,
+		}
+		if err := notificationQueries.CreateNotification(notification); err != nil {
+			log.Errorf("Unable to save the live notification of %s gallery to %s: %v\n", gallery.Title, recipient, err)
+			continue
+		}
+		email.UpdateOutboxEmailSenders(outboxEmail.ID)
+	}
+
+	// Sent on the next check when none of the emails could be queued, the
+	// contacts that were queued would be sent a second email otherwise
+	if queued == 0 {
+		if err := galleryQueries.ClearLiveNotified(gallery); err != nil {
+			log.Errorf("Unable to clear the live notification of %s gallery: %v\n", gallery.Title, err)
+		}
+		return
+	}
+
+	log.Infof("%s gallery live notification queued for %d of %d contacts.\n", gallery.Title, queued, len(recipients))
+}
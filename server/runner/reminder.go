@@ -0,0 +1,154 @@
+package runner
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/austinbspencer/gshare-server/internal/models"
+	"github.com/austinbspencer/gshare-server/internal/queries"
+	"github.com/austinbspencer/gshare-server/pkg/configs"
+	"github.com/austinbspencer/gshare-server/platform/email"
+	"github.com/gofiber/fiber/v2/log"
+)
+
+var (
+	// reminderInterval is how often in minutes the reminders are checked,
+	// missed and failed reminders are sent on the next check
+	reminderInterval int = 60
+	// ReminderMaxAttempts is how many times a reminder is attempted before
+	// it is marked as failed
+	ReminderMaxAttempts int = 5
+)
+
+func init() {
+	reminderInterval = configs.GetenvInt("CRON_REMINDERS_INTERVAL", reminderInterval)
+	ReminderMaxAttempts = configs.GetenvInt("REMINDER_MAX_ATTEMPTS", ReminderMaxAttempts)
+}
+
+// sendReminders sends the reminders of the live galleries that are due and
+// haven't been sent to each recipient yet
+func sendReminders() {
+	log.Debug("Checking if reminders need to be sent out!")
+
+	galleryQueries := queries.NewGalleryRepository()
+
+	galleries, err := galleryQueries.GetLiveGalleries()
+	if err != nil {
+		log.Errorf("Error getting galleries in reminders cron: %v\n", err)
+		return
+	}
+
+	if len(galleries) == 0 {
+		log.Debug("No live galleries to check for reminders.")
+	}
+
+	log.Debugf("Checking %d live galleries for reminders to be sent.\n", len(galleries))
+
+	for idx := range galleries {
+		if !galleries[idx].Reminder {
+			log.Debugf("The %s gallery does not have reminders enabled.\n", galleries[idx].Title)
+			continue
+		}
+
+		sendGalleryReminders(&galleries[idx], time.Now())
+	}
+}
+
+// sendGalleryReminders sends the latest reminder of the gallery that is
+// due. Earlier reminders that were missed, such as while the server was
+// down, are skipped so recipients only get a single catch-up reminder.
+func sendGalleryReminders(gallery *models.Gallery, now time.Time) {
+	reminderQueries := queries.NewReminderRepository()
+
+	// The days are in descending order so the due reminders come first
+	var due []int
+	for _, days := range gallery.GetReminderDays() {
+		if !now.Before(gallery.Expiration.AddDate(0, 0, -days)) {
+			due = append(due, days)
+		}
+	}
+
+	if len(due) == 0 {
+		return
+	}
+
+	contactQueries := queries.NewContactRepository()
+
+	contacts, err := contactQueries.GetGalleryContacts(gallery.ID)
+	if err != nil {
+		log.Errorf("Error getting the contacts of %s gallery: %v\n", gallery.Title, err)
+		return
+	}
+
+	if len(contacts) == 0 {
+		log.Warnf("Missing contacts to send the reminders to for %s gallery.\n", gallery.Title)
+		return
+	}
+
+	existing, err := reminderQueries.GetGalleryReminders(gallery.ID)
+	if err != nil {
+		log.Errorf("Error getting the reminders of %s gallery: %v\n", gallery.Title, err)
+		return
+	}
+
+	reminders := map[string]*models.GalleryReminder{}
+	for idx := range existing {
+		reminders[reminderKey(existing[idx].Days, existing[idx].Email)] = &existing[idx]
+	}
+
+	clientBaseURL := os.Getenv("NEXT_PUBLIC_CLIENT_URL")
+	galleryLink := fmt.Sprintf("%s/%s", clientBaseURL, gallery.Path)
+	expiration := gallery.Expiration.Format("January 2, 2006")
+
+	for _, recipient := range models.ContactEmails(contacts) {
+		for idx, days := range due {
+			reminder, ok := reminders[reminderKey(days, recipient)]
+			if !ok {
+				reminder = &models.GalleryReminder{
+					GalleryID: gallery.ID,
+					Days:      days,
+					Email:     recipient,
+					Status:    models.ReminderPending,
+				}
+			}
+
+			if reminder.Status != models.ReminderPending {
+				continue
+			}
+
+			if idx < len(due)-1 {
+				// A later reminder is due so this one is no longer sent
+				reminder.Status = models.ReminderSkipped
+			} else if outboxEmail, err := email.SendReminderEmail(recipient, galleryLink, expiration); err != nil {
+				log.Errorf("Unable to send reminder email for %s gallery to %s: %v\n", gallery.Title, recipient, err)
+
+				lastError := err.Error()
+				reminder.LastError = &lastError
+				reminder.Attempts++
+				if reminder.Attempts >= ReminderMaxAttempts {
+					reminder.Status = models.ReminderFailed
+				}
+			} else {
+				log.Infof("%s gallery reminder email queued for %s.\n", gallery.Title, recipient)
+
+				// The outbox sets the reminder as sent or failed once it
+				// tried to send the email
+				reminder.OutboxEmailID = &outboxEmail.ID
+				reminder.Attempts++
+				reminder.Status = models.ReminderQueued
+			}
+
+			if err := reminderQueries.SaveReminder(reminder); err != nil {
+				log.Errorf("Unable to save the reminder for %s gallery to %s: %v\n", gallery.Title, recipient, err)
+			} else if reminder.OutboxEmailID != nil && reminder.Status == models.ReminderQueued {
+				email.UpdateOutboxEmailSenders(*reminder.OutboxEmailID)
+			}
+		}
+	}
+}
+
+// reminderKey identifies the reminder to the recipient
+func reminderKey(days int, email string) string {
+	return fmt.Sprintf("%d %s", days, email)
+}
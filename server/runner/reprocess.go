@@ -0,0 +1,263 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/austinbspencer/gshare-server/internal/models"
+	"github.com/austinbspencer/gshare-server/internal/queries"
+	"github.com/austinbspencer/gshare-server/pkg/cachestore"
+	"github.com/austinbspencer/gshare-server/pkg/images"
+	"github.com/austinbspencer/gshare-server/pkg/utils"
+	"github.com/gofiber/fiber/v2/log"
+)
+
+var (
+	// ErrReprocessRunning is returned when a reprocessing job is already running
+	ErrReprocessRunning = errors.New("a reprocessing job is already running")
+
+	jobIDLength = 16
+	// How long finished jobs are kept to look up their result
+	finishedJobTTL = 24 * time.Hour
+
+	// How often the server checks if the derivatives were rebuilt by
+	// another process, such as the reprocess command
+	derivativesInterval = 30 * time.Second
+	// Version of the derivatives the cache of this process is for
+	derivativesVersion atomic.Int64
+
+	reprocessMu   sync.Mutex
+	reprocessJobs = map[string]*models.ReprocessJob{}
+	reprocessing  bool
+)
+
+// StartReprocessJob rebuilds the image derivatives for the gallery, or for
+// every gallery when galleryID is nil, in the background
+func StartReprocessJob(galleryID *uint) (models.ReprocessJob, error) {
+	job, galleryImages, err := newReprocessJob(galleryID)
+	if err != nil {
+		return models.ReprocessJob{}, err
+	}
+
+	go runReprocessJob(job, galleryImages, nil)
+
+	return snapshotJob(job), nil
+}
+
+// RunReprocessJob rebuilds the image derivatives and blocks until it is
+// done, calling progress after each image
+func RunReprocessJob(galleryID *uint, progress func(models.ReprocessJob)) (models.ReprocessJob, error) {
+	job, galleryImages, err := newReprocessJob(galleryID)
+	if err != nil {
+		return models.ReprocessJob{}, err
+	}
+
+	runReprocessJob(job, galleryImages, progress)
+
+	return snapshotJob(job), nil
+}
+
+// WatchDerivatives clears the cached resizes and downloads in the
+// background when the derivatives were rebuilt by another process. The
+// memory cache is only cleared by the process it belongs to.
+func WatchDerivatives() {
+	settingsQueries := queries.NewSettingsRepository()
+
+	version, err := settingsQueries.GetDerivativesVersion()
+	if err != nil {
+		log.Errorf("Unable to get the derivatives version: %v\n", err)
+	}
+	derivativesVersion.Store(int64(version))
+
+	go func() {
+		ticker := time.NewTicker(derivativesInterval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			checkDerivativesVersion()
+		}
+	}()
+}
+
+// checkDerivativesVersion clears the cache when the derivatives version
+// changed since the last check
+func checkDerivativesVersion() {
+	settingsQueries := queries.NewSettingsRepository()
+
+	version, err := settingsQueries.GetDerivativesVersion()
+	if err != nil {
+		log.Errorf("Unable to get the derivatives version: %v\n", err)
+		return
+	}
+
+	if derivativesVersion.Swap(int64(version)) != int64(version) {
+		log.Info("The image derivatives were rebuilt by another process, clearing the cached images and downloads")
+		cachestore.InvalidateDerivatives()
+	}
+}
+
+// GetReprocessJob returns the job with the given ID
+func GetReprocessJob(id string) (models.ReprocessJob, bool) {
+	reprocessMu.Lock()
+	defer reprocessMu.Unlock()
+
+	pruneJobs()
+
+	job, ok := reprocessJobs[id]
+	if !ok {
+		return models.ReprocessJob{}, false
+	}
+
+	return copyJob(job), true
+}
+
+// GetReprocessJobs returns the running jobs and the jobs that finished
+// within the last day
+func GetReprocessJobs() []models.ReprocessJob {
+	reprocessMu.Lock()
+	defer reprocessMu.Unlock()
+
+	pruneJobs()
+
+	jobs := []models.ReprocessJob{}
+	for _, job := range reprocessJobs {
+		jobs = append(jobs, copyJob(job))
+	}
+
+	return jobs
+}
+
+func newReprocessJob(galleryID *uint) (*models.ReprocessJob, []models.Image, error) {
+	imageQueries := queries.NewImageRepository()
+
+	var galleryImages []models.Image
+	var err error
+	if galleryID != nil {
+		galleryImages, err = imageQueries.GetGalleryImages(*galleryID)
+	} else {
+		galleryImages, err = imageQueries.GetImages()
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	reprocessMu.Lock()
+	defer reprocessMu.Unlock()
+
+	// Only a single job runs at a time since every job already saturates
+	// the image processor
+	if reprocessing {
+		return nil, nil, ErrReprocessRunning
+	}
+	reprocessing = true
+
+	pruneJobs()
+
+	job := &models.ReprocessJob{
+		ID:        utils.GenerateRandomState(&jobIDLength),
+		GalleryID: galleryID,
+		Status:    models.JobRunning,
+		Total:     len(galleryImages),
+		Errors:    []string{},
+		StartedAt: time.Now(),
+	}
+	reprocessJobs[job.ID] = job
+
+	return job, galleryImages, nil
+}
+
+func runReprocessJob(job *models.ReprocessJob, galleryImages []models.Image, progress func(models.ReprocessJob)) {
+	log.Infof("Reprocessing %d images for job %s\n", job.Total, job.ID)
+
+	galleryIDs := map[uint]bool{}
+	var imageIDs []string
+
+	for _, img := range galleryImages {
+		err := images.RegenerateWebImage(img.GalleryID, img.Filename)
+
+		reprocessMu.Lock()
+		job.Processed++
+		if err != nil {
+			log.Errorf("Unable to reprocess image %d: %v\n", img.ID, err)
+			job.Failed++
+			job.Errors = append(job.Errors, fmt.Sprintf("image %d: %v", img.ID, err))
+		}
+		reprocessMu.Unlock()
+
+		galleryIDs[img.GalleryID] = true
+		imageIDs = append(imageIDs, img.PublicID)
+
+		if progress != nil {
+			progress(snapshotJob(job))
+		}
+	}
+
+	// The derivatives changed so cached resizes and zips are now stale
+	cachestore.InvalidateImages(imageIDs...)
+
+	galleryQueries := queries.NewGalleryRepository()
+	for galleryID := range galleryIDs {
+		gallery, err := galleryQueries.GetGalleryByID(fmt.Sprint(galleryID))
+		if err != nil {
+			log.Errorf("Unable to retrieve gallery %d after reprocessing: %v\n", galleryID, err)
+			continue
+		}
+
+		cachestore.InvalidateGallery(gallery.PublicID)
+
+		if err := galleryQueries.SetZipsReady(gallery, false); err != nil {
+			log.Errorf("Unable to update ZipsReady field in gallery: %v\n", err)
+		}
+	}
+
+	// Other processes sharing the DB drop their cache once they see the
+	// new version
+	settingsQueries := queries.NewSettingsRepository()
+	if version, err := settingsQueries.BumpDerivativesVersion(); err != nil {
+		log.Errorf("Unable to raise the derivatives version: %v\n", err)
+	} else {
+		derivativesVersion.Store(int64(version))
+	}
+
+	reprocessMu.Lock()
+	defer reprocessMu.Unlock()
+
+	finishedAt := time.Now()
+	job.FinishedAt = &finishedAt
+	job.Status = models.JobCompleted
+	if job.Failed > 0 {
+		job.Status = models.JobFailed
+	}
+	reprocessing = false
+
+	log.Infof("Reprocessing job %s finished: %d processed, %d failed\n", job.ID, job.Processed, job.Failed)
+}
+
+// pruneJobs removes the jobs that finished more than finishedJobTTL ago,
+// the caller must hold reprocessMu
+func pruneJobs() {
+	for id, job := range reprocessJobs {
+		if job.FinishedAt != nil && time.Since(*job.FinishedAt) > finishedJobTTL {
+			delete(reprocessJobs, id)
+		}
+	}
+}
+
+// snapshotJob returns a copy of the job that is safe to read
+func snapshotJob(job *models.ReprocessJob) models.ReprocessJob {
+	reprocessMu.Lock()
+	defer reprocessMu.Unlock()
+
+	return copyJob(job)
+}
+
+// copyJob copies the job, the caller must hold reprocessMu
+func copyJob(job *models.ReprocessJob) models.ReprocessJob {
+	jobCopy := *job
+	jobCopy.Errors = append([]string{}, job.Errors...)
+
+	return jobCopy
+}
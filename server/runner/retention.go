@@ -0,0 +1,184 @@
+package runner
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/austinbspencer/gshare-server/internal/models"
+	"github.com/austinbspencer/gshare-server/internal/queries"
+	"github.com/austinbspencer/gshare-server/pkg/configs"
+	"github.com/austinbspencer/gshare-server/pkg/images"
+	"github.com/austinbspencer/gshare-server/platform/email"
+	"github.com/gofiber/fiber/v2/log"
+)
+
+var (
+	// RetentionDays is how long galleries are kept after they expire before
+	// the retention action is taken, 0 keeps them forever
+	RetentionDays int = 0
+	// RetentionAction is taken once a gallery is past the retention days
+	RetentionAction = models.RetentionArchive
+	// RetentionWarningDays is how many days before the action the admin is
+	// sent a warning
+	RetentionWarningDays int = 7
+)
+
+func init() {
+	RetentionDays = configs.GetenvInt("RETENTION_DAYS", RetentionDays)
+	RetentionWarningDays = configs.GetenvInt("RETENTION_WARNING_DAYS", RetentionWarningDays)
+
+	action := models.RetentionAction(configs.Getenv("RETENTION_ACTION", string(RetentionAction)))
+	switch action {
+	case models.RetentionDelete, models.RetentionArchive:
+		RetentionAction = action
+	default:
+		log.Warnf("The RETENTION_ACTION value (%s) is invalid. Value must be delete or archive.\nDefaulting to %s.\n",
+			action, RetentionAction)
+	}
+}
+
+// GetRetentionReport returns the galleries the retention job acts on at the
+// given time, along with the galleries that are within the warning days.
+// Nothing is changed so it doubles as a dry run of the job.
+func GetRetentionReport(now time.Time) (models.RetentionReport, error) {
+	report := models.RetentionReport{
+		Days:        RetentionDays,
+		Action:      RetentionAction,
+		WarningDays: RetentionWarningDays,
+		Galleries:   []models.RetainedGallery{},
+	}
+
+	if RetentionDays <= 0 {
+		return report, nil
+	}
+
+	galleryQueries := queries.NewGalleryRepository()
+
+	galleries, err := galleryQueries.GetGalleriesExpiredBefore(now.AddDate(0, 0, RetentionWarningDays-RetentionDays))
+	if err != nil {
+		return report, err
+	}
+
+	for _, gallery := range galleries {
+		size, err := images.GalleryDiskUsage(gallery.ID)
+		if err != nil {
+			log.Warnf("Unable to get the disk usage of gallery %d: %v\n", gallery.ID, err)
+		}
+
+		retained := report.RetainGallery(gallery, now)
+		retained.Size = size
+
+		report.Galleries = append(report.Galleries, retained)
+	}
+
+	return report, nil
+}
+
+// applyRetention warns the admin about galleries reaching the end of their
+// retention and takes the retention action on the galleries that are due
+func applyRetention() {
+	if RetentionDays <= 0 {
+		return
+	}
+
+	log.Debug("Applying the retention policy to expired galleries")
+
+	now := time.Now()
+
+	report, err := GetRetentionReport(now)
+	if err != nil {
+		log.Errorf("Error getting retention report: %v\n", err)
+		return
+	}
+
+	// The admin is warned once about each gallery, the action is taken
+	// once the warning is at least the warning days old
+	var warned []models.RetainedGallery
+
+	galleryQueries := queries.NewGalleryRepository()
+
+	for _, retained := range report.Galleries {
+		if retained.Warn {
+			if err := galleryQueries.MarkRetentionWarned(retained.GalleryID, now); err != nil {
+				log.Errorf("Unable to mark the retention warning of gallery %d: %v\n", retained.GalleryID, err)
+				continue
+			}
+			warned = append(warned, retained)
+			continue
+		}
+
+		if !retained.Due {
+			continue
+		}
+
+		gallery, err := galleryQueries.GetGalleryByID(fmt.Sprint(retained.GalleryID))
+		if err != nil {
+			log.Errorf("Unable to retrieve gallery %d for retention: %v\n", retained.GalleryID, err)
+			continue
+		}
+
+		switch RetentionAction {
+		case models.RetentionDelete:
+			// Purged right away, the trash could keep it forever
+			err = PurgeGallery(gallery)
+		case models.RetentionArchive:
+			err = archiveGallery(gallery)
+		}
+		if err != nil {
+			log.Errorf("Unable to %s gallery %d for retention: %v\n", RetentionAction, gallery.ID, err)
+			continue
+		}
+
+		log.Infof("Retention policy applied to gallery %d: %s\n", gallery.ID, RetentionAction)
+	}
+
+	if len(warned) > 0 {
+		sendRetentionWarning(warned)
+	}
+}
+
+// archiveGallery keeps the originals of the gallery in a single archive and
+// removes its other files. Hidden images are part of the archive.
+func archiveGallery(gallery *models.Gallery) error {
+	entries := make([]images.ZipEntry, 0, len(gallery.Images))
+	for _, img := range gallery.Images {
+		entries = append(entries, images.ZipEntry{Filename: img.Filename, Name: img.DownloadName()})
+	}
+
+	if err := images.ArchiveGallery(gallery.ID, entries); err != nil {
+		return err
+	}
+
+	galleryQueries := queries.NewGalleryRepository()
+
+	return galleryQueries.ArchiveGallery(gallery)
+}
+
+// sendRetentionWarning emails the admin the galleries that the retention
+// action will be taken on, with the date of the action for each
+func sendRetentionWarning(warned []models.RetainedGallery) {
+	action := "archived"
+	if RetentionAction == models.RetentionDelete {
+		action = "deleted for good along with their original images"
+	}
+
+	galleries := make([]string, 0, len(warned))
+	for _, retained := range warned {
+		galleries = append(galleries, fmt.Sprintf("%s on %s", retained.Title, retained.RetainUntil.Format("January 2, 2006")))
+	}
+
+	msg := fmt.Sprintf("The expired galleries will be %s by the retention policy: %s. Extend the expiration of a gallery to keep it.",
+		action, strings.Join(galleries, ", "))
+
+	userQueries := queries.NewUserRepository()
+	admin, err := userQueries.GetUserByID("1")
+	if err != nil {
+		log.Errorf("Unable to retrieve admin user from DB: %v\n", err)
+		return
+	}
+
+	if err := email.SendAlertEmail(admin.Email, "gshare retention warning", msg); err != nil {
+		log.Errorf("Unable to send retention warning email: %v\n", err)
+	}
+}
@@ -2,20 +2,20 @@ package runner
 
 import (
 	"fmt"
-	"os"
 	"time"
 
+	"github.com/austinbspencer/gshare-server/internal/models"
 	"github.com/austinbspencer/gshare-server/internal/queries"
 	"github.com/austinbspencer/gshare-server/pkg/configs"
-	"github.com/austinbspencer/gshare-server/platform/docker"
+	"github.com/austinbspencer/gshare-server/platform/deploy"
 	"github.com/austinbspencer/gshare-server/platform/email"
+	"github.com/austinbspencer/gshare-server/platform/webhook"
 	"github.com/go-co-op/gocron"
 	"github.com/gofiber/fiber/v2/log"
 )
 
 var (
 	galleriesInterval int    = 10
-	reminderDays      int    = 5
 	reminderMessage   string = ""
 )
 
@@ -36,7 +36,9 @@ func RunScripts() {
 
 	s.Every(galleriesInterval).Minutes().Do(checkGalleries)
 	// s.Every(3).Hours().Do()
-	s.Every(1).Day().At("08:00").Do(sendReminders)
+	s.Every(reminderInterval).Minutes().Do(sendReminders)
+	s.Every(1).Day().At("03:00").Do(purgeTrash)
+	s.Every(1).Day().At("04:00").Do(applyRetention)
 
 	// starts the scheduler asynchronously
 	s.StartAsync()
@@ -48,7 +50,6 @@ func checkGalleries() {
 
 	// Get query interfaces
 	galleryQueries := queries.NewGalleryRepository()
-	settingsQueries := queries.NewSettingsRepository()
 
 	galleries, err := galleryQueries.GetGalleries()
 	if err != nil {
@@ -62,14 +63,22 @@ func checkGalleries() {
 	for _, gallery := range galleries {
 		if gallery.IsLive() {
 			log.Debugf("Gallery %d is live\n", gallery.ID)
-			// Check if the gallery went live within the galleriesInterval
-			if gallery.Live.Add(time.Duration(galleriesInterval) * time.Minute).After(time.Now()) {
-				log.Debugf("Gallery %d went live within the last %d minutes\n", gallery.ID, galleriesInterval)
-				// Since gallery went live within the last interval, we need to redeploy client
-				log.Debug("Restarting client container")
-				err = docker.RestartClientContainer()
+			notifyGalleryLive(&gallery)
+
+			// The gallery went live since the last check when it isn't
+			// marked yet, so it is handled once even when checks are missed
+			wentLive, err := galleryQueries.MarkLiveFired(&gallery)
+			if err != nil {
+				log.Errorf("Unable to mark gallery %d as live: %v\n", gallery.ID, err)
+			} else if wentLive {
+				log.Debugf("Gallery %d went live since the last check\n", gallery.ID)
+				webhook.Fire(models.WebhookGalleryLive, models.NewWebhookGallery(&gallery))
+
+				// Since the gallery went live, we need to redeploy client
+				log.Debugf("Deploying client with the %s deployer\n", deploy.Name())
+				err = deploy.Deploy(deploy.GalleryPaths(&gallery)...)
 				if err != nil {
-					msg := fmt.Sprintf("Error restarting client container: %v", err)
+					msg := fmt.Sprintf("Error deploying client: %v", err)
 					log.Error(msg)
 					// Send alert email
 					userQueries := queries.NewUserRepository()
@@ -82,13 +91,9 @@ func checkGalleries() {
 						}
 					}
 				} else {
-					msg := fmt.Sprintf("Client container restarted to make gallery %d live", gallery.ID)
+					msg := fmt.Sprintf("Client deployed to make gallery %d live", gallery.ID)
 					log.Info(msg)
 
-					if err := settingsQueries.SetSettingsUpdate(false); err != nil {
-						log.Errorf("Error setting settings update to false: %v\n", err)
-					}
-
 					// Send alert email
 					userQueries := queries.NewUserRepository()
 					admin, err := userQueries.GetUserByID("1")
@@ -106,19 +111,22 @@ func checkGalleries() {
 
 		if gallery.IsExpired() {
 			log.Debugf("Gallery %d has expired\n", gallery.ID)
-			// Check if the gallery expired within the galleriesInterval
-			if gallery.Expiration.Add(time.Duration(galleriesInterval) * time.Minute).After(time.Now()) {
-				log.Debugf("Gallery %d expired within the last %d minutes\n", gallery.ID, galleriesInterval)
-				// Since gallery expired within the last interval, we need to redeploy client
-				log.Debug("Restarting client container")
-				err = docker.RestartClientContainer()
+			// The gallery expired since the last check when it isn't marked
+			// yet
+			expired, err := galleryQueries.MarkExpiredFired(&gallery)
+			if err != nil {
+				log.Errorf("Unable to mark gallery %d as expired: %v\n", gallery.ID, err)
+			} else if expired {
+				log.Debugf("Gallery %d expired since the last check\n", gallery.ID)
+				webhook.Fire(models.WebhookGalleryExpired, models.NewWebhookGallery(&gallery))
+
+				// Since the gallery expired, we need to redeploy client
+				log.Debugf("Deploying client with the %s deployer\n", deploy.Name())
+				err = deploy.Deploy(deploy.GalleryPaths(&gallery)...)
 				if err != nil {
-					log.Errorf("Error restarting client container: %v\n", err)
+					log.Errorf("Error deploying client: %v\n", err)
 				} else {
-					log.Infof("Client container restarted to make gallery %d expired\n", gallery.ID)
-					if err := settingsQueries.SetSettingsUpdate(false); err != nil {
-						log.Errorf("Error setting settings update to false: %v\n", err)
-					}
+					log.Infof("Client deployed to make gallery %d expired\n", gallery.ID)
 				}
 			}
 		}
@@ -126,58 +134,3 @@ func checkGalleries() {
 
 	log.Debug("Finished checking galleries for newly live / expired")
 }
-
-func sendReminders() {
-	log.Debug("Checking if reminders need to be sent out!")
-
-	galleryQueries := queries.NewGalleryRepository()
-
-	galleries, err := galleryQueries.GetLiveGalleries()
-	if err != nil {
-		log.Errorf("Error getting galleries in reminders cron: %v\n", err)
-		return
-	}
-
-	if len(galleries) == 0 {
-		log.Debug("No live galleries to check for reminders.")
-	}
-
-	log.Debugf("Checking %d live galleries for reminders to be sent.\n", len(galleries))
-
-	for _, gallery := range galleries {
-		if !gallery.Reminder {
-			log.Debugf("The %s gallery does not have reminders enabled.\n", gallery.Title)
-			continue
-		}
-
-		// Set the reminder date
-		reminderDate := gallery.Expiration.AddDate(0, 0, -reminderDays)
-
-		// Get the current time
-		currentTime := time.Now()
-
-		// Check if the expiration time minus 5 days is equal to today
-		if currentTime.Year() == reminderDate.Year() &&
-			currentTime.Month() == reminderDate.Month() &&
-			currentTime.Day() == reminderDate.Day() {
-			// The expiration time minus 5 days is equal to today
-			log.Debugf("The gallery %s is ready for reminder.\n", gallery.Title)
-			if gallery.ReminderEmails == nil {
-				log.Warn("Missing reminder emails to send to.")
-				continue
-			}
-
-			clientBaseURL := os.Getenv("NEXT_PUBLIC_CLIENT_URL")
-			err = email.SendReminderEmail(*gallery.ReminderEmails,
-				fmt.Sprintf("%s/%s", clientBaseURL, gallery.Path),
-				gallery.Expiration.Format("January 2, 2006"))
-
-			if err != nil {
-				log.Fatalf("Unable to send reminder email for %s gallery: %v\n", gallery.Title, err)
-				continue
-			}
-
-			log.Infof("%s gallery reminder emails sent successfully.\n", gallery.Title)
-		}
-	}
-}
@@ -0,0 +1,104 @@
+package runner
+
+import (
+	"os"
+	"time"
+
+	"github.com/austinbspencer/gshare-server/internal/models"
+	"github.com/austinbspencer/gshare-server/internal/queries"
+	"github.com/austinbspencer/gshare-server/pkg/configs"
+	"github.com/austinbspencer/gshare-server/pkg/images"
+	"github.com/gofiber/fiber/v2/log"
+)
+
+// TrashRetentionDays is how long deleted galleries and images are kept in
+// the trash before being purged, 0 keeps them until purged manually
+var TrashRetentionDays int = 30
+
+func init() {
+	TrashRetentionDays = configs.GetenvInt("TRASH_RETENTION_DAYS", TrashRetentionDays)
+}
+
+// TrashPurgeTime returns when an item deleted at the given time is purged,
+// or nil when the trash is never purged automatically
+func TrashPurgeTime(deletedAt time.Time) *time.Time {
+	if TrashRetentionDays <= 0 {
+		return nil
+	}
+
+	purgeAt := deletedAt.AddDate(0, 0, TrashRetentionDays)
+
+	return &purgeAt
+}
+
+// PurgeGallery removes the gallery files and fully deletes the gallery
+func PurgeGallery(gallery *models.Gallery) error {
+	if err := os.RemoveAll(images.GetGalleryPath(gallery.ID)); err != nil {
+		log.Warnf("Unable to remove images directory for gallery: %v\n", err)
+	}
+
+	galleryQueries := queries.NewGalleryRepository()
+
+	return galleryQueries.DeleteGallery(gallery)
+}
+
+// PurgeImage removes the image files along with its prior originals and
+// fully deletes the image
+func PurgeImage(image *models.Image) error {
+	imageQueries := queries.NewImageRepository()
+
+	if err := images.RemoveImage(image.GalleryID, image.Filename); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	versions, err := imageQueries.GetImageVersions(image.ID)
+	if err != nil {
+		log.Errorf("Error retrieving image versions from DB: %v\n", err)
+	}
+	for _, version := range versions {
+		if err := images.RemoveImageVersion(image.GalleryID, version.Filename); err != nil {
+			log.Errorf("Error removing image version from gallery directory: %v\n", err)
+		}
+	}
+
+	return imageQueries.DeleteImage(image)
+}
+
+// purgeTrash purges the galleries and images that have been in the trash
+// longer than the retention period
+func purgeTrash() {
+	if TrashRetentionDays <= 0 {
+		return
+	}
+
+	before := time.Now().AddDate(0, 0, -TrashRetentionDays)
+
+	log.Debugf("Purging items trashed before %s\n", before)
+
+	galleryQueries := queries.NewGalleryRepository()
+	imageQueries := queries.NewImageRepository()
+
+	galleries, err := galleryQueries.GetGalleriesTrashedBefore(before)
+	if err != nil {
+		log.Errorf("Error getting trashed galleries: %v\n", err)
+	}
+	for idx := range galleries {
+		if err := PurgeGallery(&galleries[idx]); err != nil {
+			log.Errorf("Unable to purge gallery %d: %v\n", galleries[idx].ID, err)
+			continue
+		}
+		log.Infof("Purged gallery %d from the trash\n", galleries[idx].ID)
+	}
+
+	trashedImages, err := imageQueries.GetImagesTrashedBefore(before)
+	if err != nil {
+		log.Errorf("Error getting trashed images: %v\n", err)
+	}
+	for idx := range trashedImages {
+		if err := PurgeImage(&trashedImages[idx]); err != nil {
+			log.Errorf("Unable to purge image %d: %v\n", trashedImages[idx].ID, err)
+			continue
+		}
+		log.Infof("Purged image %d from the trash\n", trashedImages[idx].ID)
+	}
+}